@@ -4,19 +4,46 @@ import (
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"mime"
 	"net/http"
+	"strconv"
+	"strings"
 	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/oapi"
 	"talkliketv.com/tltv/internal/services"
 	"talkliketv.com/tltv/internal/services/audiofile"
 )
 
+const (
+	// fileEncodingHeader is the response header with the character encoding the uploaded
+	// file was decoded from
+	fileEncodingHeader = "X-File-Encoding"
+	// cleanupHeader is the response header with how many subtitles or lines each cleanup
+	// rule changed, like speaker_labels=12, sound_cues=4
+	cleanupHeader = "X-Cleanup-Rules"
+	// unalignedHeader is the response header with how many subtitles of two aligned subtitle
+	// files had no translation in the other file
+	unalignedHeader = "X-Unaligned-Cues"
+	// sentenceAlignmentHeader is the response header with how many groups of sentences of two
+	// aligned text files were matched in each way, like 1:1=40, 2:1=3, 0:1=1
+	sentenceAlignmentHeader = "X-Sentence-Alignment"
+	// chaptersHeader is the response header with how many chapters of an epub are in the
+	// chapter list of the parsed zip
+	chaptersHeader = "X-Epub-Chapters"
+)
+
 func (s *Server) ParseFile(e echo.Context) error {
 	fh, err := e.FormFile("file_path")
 	if err != nil {
 		e.Logger().Error(err)
 		return e.String(http.StatusBadRequest, "error getting form file: "+err.Error())
 	}
-	stringsSlice, err := audiofile.FileParse(fh, s.af, s.config.FileUploadLimit)
+	opts, err := parseOptions(e)
+	if err != nil {
+		e.Logger().Error(err)
+		return e.String(http.StatusBadRequest, "invalid request: "+err.Error())
+	}
+	result, err := audiofile.FileParse(fh, s.af, s.config.FileUploadLimit, opts)
 	if err != nil {
 		e.Logger().Error(err)
 		if services.IsFileTooLargeError(err) {
@@ -25,7 +52,17 @@ func (s *Server) ParseFile(e echo.Context) error {
 		return e.String(http.StatusInternalServerError, "error parsing file: "+err.Error())
 	}
 
-	zippedFile, err := audiofile.ZipStringsSlice(s.af, stringsSlice, s.config.MaxNumPhrases, s.config.TTSBasePath, fh.Filename)
+	e.Response().Header().Set(fileEncodingHeader, result.Encoding)
+	e.Response().Header().Set(cleanupHeader, formatCleanup(result.Cleanup))
+	if len(result.Chapters) > 0 {
+		e.Response().Header().Set(chaptersHeader, strconv.Itoa(len(result.Chapters)))
+	}
+	// the web UI and scripts get a report of what was kept and dropped instead of the zip
+	if acceptsJSON(e.Request()) {
+		return e.JSON(http.StatusOK, parseReport(result, s.config.MaxNumPhrases))
+	}
+
+	zippedFile, err := audiofile.ZipParseResult(s.af, result, s.config.MaxNumPhrases, s.config.TTSBasePath, fh.Filename)
 	if err != nil {
 		e.Logger().Error(err)
 		return e.String(http.StatusInternalServerError, "error zipping file: "+err.Error())
@@ -49,21 +86,50 @@ func (s *Server) AudioFromFile(e echo.Context) error {
 		return e.String(http.StatusBadRequest, "file too large")
 	}
 
+	opts, err := parseOptions(e)
+	if err != nil {
+		e.Logger().Error(err)
+		return e.String(http.StatusBadRequest, "invalid request: "+err.Error())
+	}
+
 	src, err := fh.Open()
 	if err != nil {
 		e.Logger().Error(err)
 		return e.String(http.StatusBadRequest, "error opening file: "+err.Error())
 	}
+	defer src.Close()
 	// make sure the file has been parsed before continuing
-	filetype, err := audiofile.DetectTextFormat(src)
+	detectOpts := opts
+	detectOpts.FileName, detectOpts.ContentType = fh.Filename, fh.Header.Get("Content-Type")
+	filetype, _, _, err := audiofile.DetectFormat(src, detectOpts)
 	if err != nil {
 		e.Logger().Error(err)
 		return e.String(http.StatusBadRequest, "error detecting file type: "+err.Error())
 	}
-	if filetype != audiofile.OnePhrasePerLine {
+	// a subtitle file in the native language of the user is aligned with the file instead of
+	// translating its phrases
+	translationFh, err := e.FormFile("translation_file_path")
+	if err != nil && !errors.Is(err, http.ErrMissingFile) {
+		e.Logger().Error(err)
+		return e.String(http.StatusBadRequest, "error getting translation file: "+err.Error())
+	}
+	if translationFh != nil {
+		if !audiofile.IsAlignable(filetype) {
+			return e.String(http.StatusBadRequest, "a translation file can only be used with subtitles or text")
+		}
+		if translationFh.Size > s.config.FileUploadLimit {
+			return e.String(http.StatusBadRequest, "file too large")
+		}
+	}
+	// a timed subtitle file can be used without parsing when a time range selects the phrases
+	// and an epub when chapters are chosen, a file aligned with a translation file is paired
+	// before it is split, bilingual files and Anki notes are never split, Kindle highlights
+	// are already phrases and the headings of a Markdown file are kept as its sections
+	if filetype != audiofile.OnePhrasePerLine && filetype != audiofile.Bilingual && filetype != audiofile.Anki &&
+		filetype != audiofile.Kindle && filetype != audiofile.Markdown && translationFh == nil &&
+		!(opts.HasTimeRange() && audiofile.IsTimed(filetype)) && !(len(opts.Chapters) > 0 && filetype == audiofile.Epub) {
 		return e.String(http.StatusBadRequest, "Please parse file before uploading")
 	}
-	src.Close()
 
 	title, fromVoice, toVoice, err := services.ValidateAudioRequest(e, s.m)
 	if err != nil {
@@ -76,7 +142,7 @@ func (s *Server) AudioFromFile(e echo.Context) error {
 		e.Logger().Error(err)
 		return e.String(http.StatusBadRequest, "error getting form file: "+err.Error())
 	}
-	phrases, phraseZipFile, err := audiofile.ProcessFile(fh, s.af, s.config, title.Name)
+	result, phraseZipFile, err := audiofile.ProcessFile(fh, translationFh, s.af, s.config, title.Name, opts)
 	if err != nil {
 		if errors.Is(err, interfaces.ErrTooManyPhrases) {
 			return e.Attachment(phraseZipFile.Name(), "TooManyPhrasesUseTheseFiles")
@@ -88,11 +154,8 @@ func (s *Server) AudioFromFile(e echo.Context) error {
 		return e.String(http.StatusInternalServerError, "unable to process file: "+err.Error())
 	}
 
-	var phraseTexts []string
-	for i := 0; i < len(phrases) && i < 3; i++ {
-		phraseTexts = append(phraseTexts, phrases[i].Text)
-	}
-	detectedFileLanguage, err := s.translate.DetectLanguage(e.Request().Context(), phraseTexts)
+	phrases := result.Phrases
+	detectedFileLanguage, err := s.translate.DetectLanguage(e.Request().Context(), sampleTexts(phrases))
 	if err != nil {
 		e.Logger().Error(err)
 		return e.String(http.StatusInternalServerError, "unable to detect language: "+err.Error())
@@ -100,6 +163,21 @@ func (s *Server) AudioFromFile(e echo.Context) error {
 
 	title.TitleLang = detectedFileLanguage.String()
 	title.TitlePhrases = phrases
+	// the translations of a bilingual file, the back of Anki notes or an aligned translation
+	// file are used instead of translating the phrases
+	title.ToPhrases = result.ToPhrases
+	if len(result.ToPhrases) > 0 {
+		// the translations are only used for the voice of their language
+		detectedToLanguage, err := s.translate.DetectLanguage(e.Request().Context(), sampleTexts(result.ToPhrases))
+		if err != nil {
+			e.Logger().Error(err)
+			return e.String(http.StatusInternalServerError, "unable to detect language: "+err.Error())
+		}
+		title.ToLang = detectedToLanguage.String()
+	}
+	title.Unaligned = result.Unaligned
+	title.Alignment = result.Alignment
+	title.Sections = result.Sections
 	zipFile, err := audiofile.AudioFromTitle(e.Request().Context(), s.translate, s.af, *fromVoice, *toVoice, *title, s.config.TTSBasePath)
 	if err != nil {
 		e.Logger().Error(err)
@@ -111,6 +189,100 @@ func (s *Server) AudioFromFile(e echo.Context) error {
 		return e.String(http.StatusInternalServerError, "unable to update token: "+err.Error())
 	}
 
+	if translationFh != nil {
+		e.Response().Header().Set(unalignedHeader, strconv.Itoa(len(result.Unaligned)))
+	}
+	if len(result.Alignment) > 0 {
+		e.Response().Header().Set(sentenceAlignmentHeader, formatAlignment(result.Alignment))
+	}
 	titleName := fmt.Sprintf("%s.%s-%s.zip", title.Name, title.TitleLang, title.ToVoice)
 	return e.Attachment(zipFile.Name(), titleName)
 }
+
+// sampleTexts returns the texts of the first three phrases to detect their language
+func sampleTexts(phrases []interfaces.Phrase) []string {
+	var texts []string
+	for i := 0; i < len(phrases) && i < 3; i++ {
+		texts = append(texts, phrases[i].Text)
+	}
+	return texts
+}
+
+// formatCleanup formats the cleanup counts for the cleanupHeader
+func formatCleanup(counts []interfaces.CleanupCount) string {
+	rules := make([]string, len(counts))
+	for i, count := range counts {
+		rules[i] = fmt.Sprintf("%s=%d", count.Rule, count.Count)
+	}
+	return strings.Join(rules, ", ")
+}
+
+// formatAlignment formats how many groups of sentences were matched in each way for the
+// sentenceAlignmentHeader in the order each way was first used
+func formatAlignment(alignment []interfaces.SentenceAlignment) string {
+	var kinds []string
+	counts := make(map[string]int)
+	for _, a := range alignment {
+		if counts[a.Kind] == 0 {
+			kinds = append(kinds, a.Kind)
+		}
+		counts[a.Kind]++
+	}
+	for i, kind := range kinds {
+		kinds[i] = fmt.Sprintf("%s=%d", kind, counts[kind])
+	}
+	return strings.Join(kinds, ", ")
+}
+
+// acceptsJSON checks if application/json is one of the media types of the Accept header of
+// the request
+func acceptsJSON(r *http.Request) bool {
+	for _, accepted := range strings.Split(r.Header.Get(echo.HeaderAccept), ",") {
+		if mediaType, _, err := mime.ParseMediaType(accepted); err == nil && mediaType == echo.MIMEApplicationJSON {
+			return true
+		}
+	}
+	return false
+}
+
+// parseReport returns the format, phrases and discarded lines of the parsed file and how many
+// phrases it has compared to the most audio is created for
+func parseReport(result interfaces.ParseResult, maxNumPhrases int) oapi.ParseReport {
+	report := oapi.ParseReport{
+		Format:        result.Format,
+		Encoding:      result.Encoding,
+		Phrases:       []oapi.ParsedPhrase{},
+		Discarded:     []oapi.DiscardedLine{},
+		Count:         len(result.Phrases),
+		MaxNumPhrases: maxNumPhrases,
+	}
+	for _, phrase := range result.Phrases {
+		report.Phrases = append(report.Phrases, oapi.ParsedPhrase{Id: phrase.ID, Text: phrase.Text})
+	}
+	for _, d := range result.Discarded {
+		line := oapi.DiscardedLine{Text: d.Text, Reason: oapi.DiscardedLineReason(d.Reason)}
+		if d.Rule != "" {
+			line.Rule = &d.Rule
+		}
+		if d.DuplicateOf != "" {
+			line.DuplicateOf = &d.DuplicateOf
+		}
+		report.Discarded = append(report.Discarded, line)
+	}
+	return report
+}
+
+// parseOptions validates the parse options of the request and that the format form field is
+// the name of a registered format
+func parseOptions(e echo.Context) (interfaces.ParseOptions, error) {
+	opts, err := services.ValidateParseOptions(e)
+	if err != nil {
+		return opts, err
+	}
+	if opts.Format != "" {
+		if _, err := audiofile.ParserNamed(opts.Format); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
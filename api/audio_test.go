@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"maps"
@@ -13,6 +15,7 @@ import (
 	"os"
 	"talkliketv.com/tltv/internal/interfaces"
 	"talkliketv.com/tltv/internal/models"
+	"talkliketv.com/tltv/internal/oapi"
 	"talkliketv.com/tltv/internal/services"
 	"talkliketv.com/tltv/internal/services/audiofile"
 	"talkliketv.com/tltv/internal/services/tokens"
@@ -20,6 +23,7 @@ import (
 	"talkliketv.com/tltv/internal/testutil"
 	"talkliketv.com/tltv/internal/util"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
@@ -96,8 +100,8 @@ func TestAudioFromFile(t *testing.T) {
 					GetVoice(gomock.Any(), title.ToVoice).
 					Return(toVoice, nil)
 				stubs.AudioFileX.EXPECT().
-					GetLines(gomock.Any()).
-					Return(stringsSlice, nil)
+					GetLines(gomock.Any(), gomock.Any()).
+					Return(interfaces.ParseResult{Lines: stringsSlice, Phrases: title.TitlePhrases}, nil)
 				// Add this expectation for DetectLanguage
 				stubs.TranslateX.EXPECT().
 					DetectLanguage(gomock.Any(), gomock.Eq(phraseTexts)).
@@ -151,6 +155,25 @@ func TestAudioFromFile(t *testing.T) {
 				require.Contains(t, resBody, "pause must be between 3 and 10")
 			},
 		},
+		{
+			name: "max_chars out of range",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(validSentences)
+				formMap := maps.Clone(okFormMap)
+				formMap["max_chars"] = "5"
+				return createMultiPartBody(t, data, audioFromFileName, formMap)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "max_chars must be between 10 and 500")
+			},
+		},
 		{
 			name: "pattern out of range",
 			mocks: func(stubs testutil.MockStubs) {
@@ -261,8 +284,8 @@ func TestAudioFromFile(t *testing.T) {
 					GetVoice(gomock.Any(), title.FromVoice).
 					Return(interfaces.Voice{}, nil)
 				stubs.AudioFileX.EXPECT().
-					GetLines(gomock.Any()).
-					Return(stringsSlice, nil)
+					GetLines(gomock.Any(), gomock.Any()).
+					Return(interfaces.ParseResult{Lines: stringsSlice}, nil)
 				// CreatePhrasesZip(e echo.Context, chunkedPhrases iter.Seq[[]string], tmpPath string, audioFromFileName string) (*os.File, error)
 				stubs.AudioFileX.EXPECT().
 					CreatePhrasesZip(gomock.Any(), tmpAudioBasePath, title.Name).
@@ -276,6 +299,35 @@ func TestAudioFromFile(t *testing.T) {
 				return createMultiPartBody(t, data, audioFromFileName, okFormMap)
 			},
 		},
+		{
+			name: "Skip Styles",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+				stubs.ModelsX.EXPECT().
+					GetVoice(gomock.Any(), title.ToVoice).
+					Return(toVoice, nil)
+				stubs.ModelsX.EXPECT().
+					GetVoice(gomock.Any(), title.FromVoice).
+					Return(fromVoice, nil)
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{SkipStyles: []string{"Signs", "Karaoke"}}, audioFromFileName)).
+					Return(interfaces.ParseResult{}, errors.New("no phrases"))
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(validSentences)
+				formMap := maps.Clone(okFormMap)
+				formMap["skip_styles"] = "Signs,Karaoke"
+				return createMultiPartBody(t, data, audioFromFileName, formMap)
+			},
+			checkResponse: func(res *http.Response) {
+				// skip_styles is accepted and passed to the parser
+				require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "no phrases")
+			},
+		},
 		{
 			name: "Used Token",
 			mocks: func(stubs testutil.MockStubs) {
@@ -327,8 +379,19 @@ func TestAudioFromFile_FileFormatDetection(t *testing.T) {
 	testFileName := testutil.AudioBasePath + "FileFormatDetection.txt"
 
 	title := testutil.RandomTitle()
+	fromVoice := testutil.RandomVoice()
+	toVoice := testutil.RandomVoice()
+	title.FromVoice = fromVoice.Name
+	title.ToVoice = toVoice.Name
 	randomToken := testutil.RandomString(32)
 
+	// create a base path for the zip file of the audio
+	tmpAudioBasePath := testutil.AudioBasePath + title.Name + "/"
+	err := os.MkdirAll(tmpAudioBasePath, 0777)
+	// remove directory after tests run
+	defer os.RemoveAll(tmpAudioBasePath)
+	require.NoError(t, err)
+
 	formMap := map[string]string{
 		"title_name":    title.Name,
 		"from_voice_id": title.FromVoice,
@@ -338,6 +401,25 @@ func TestAudioFromFile_FileFormatDetection(t *testing.T) {
 		"pattern":       "1",
 	}
 
+	phrases := []interfaces.Phrase{
+		{ID: 0, Text: "Esta es la primera línea."},
+		{ID: 1, Text: "Esta es la segunda línea."},
+	}
+	toPhrases := []interfaces.Phrase{
+		{ID: 0, Text: "This is the first line."},
+		{ID: 1, Text: "This is the second line."},
+	}
+
+	// audioTitle is the title the audio of the last case that created audio is created from
+	var audioTitle interfaces.Title
+	upload := audioUpload{
+		fromVoice:   fromVoice,
+		toVoice:     toVoice,
+		token:       randomToken,
+		zipFileName: tmpAudioBasePath + "FileFormatDetection.zip",
+		title:       &audioTitle,
+	}
+
 	testCases := []testCase{
 		{
 			name: "Detect Paragraph Format",
@@ -379,213 +461,915 @@ func TestAudioFromFile_FileFormatDetection(t *testing.T) {
 			},
 		},
 		{
-			name: "Error Opening File",
+			name: "SRT Format With Time Range",
 			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{Phrases: phrases}
 				stubs.ModelsX.EXPECT().
 					CheckToken(gomock.Any(), randomToken).
 					Return(nil)
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{StartTime: 5 * time.Second}, testFileName)).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
 			},
 			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
-				// Create a corrupt multipart form to force file open error
-				body := new(bytes.Buffer)
-				multiWriter := multipart.NewWriter(body)
-
-				// Create a form file with empty content
-				part, err := multiWriter.CreateFormFile("file_path", "corrupt_file.txt")
-				require.NoError(t, err)
-
-				// Write a partial/corrupt file content
-				_, err = part.Write([]byte{0xFF, 0xD8}) // Incomplete content
-				require.NoError(t, err)
-
-				// Add form fields
-				if err = multiWriter.WriteField("token", randomToken); err != nil {
-					require.NoError(t, err)
-				}
-				if err = multiWriter.WriteField("title_name", title.Name); err != nil {
-					require.NoError(t, err)
-				}
-				if err = multiWriter.WriteField("from_voice_id", title.FromVoice); err != nil {
-					require.NoError(t, err)
-				}
-				if err = multiWriter.WriteField("to_voice_id", title.ToVoice); err != nil {
-					require.NoError(t, err)
-				}
-				if err = multiWriter.WriteField("pause", "5"); err != nil {
-					require.NoError(t, err)
+				srtText := "1\n00:00:01,000 --> 00:00:04,000\nThis is the first subtitle.\n\n" +
+					"2\n00:00:05,000 --> 00:00:09,000\nThis is the second subtitle.\n"
+				timeRangeFormMap := maps.Clone(formMap)
+				timeRangeFormMap["start_time"] = "00:00:05"
+				return createMultiPartBody(t, []byte(srtText), testFileName, timeRangeFormMap)
+			},
+			checkResponse: func(res *http.Response) {
+				// the file is not rejected as unparsed so the subtitles in the range are used
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, phrases, audioTitle.TitlePhrases)
+			},
+		},
+		{
+			name: "SRT Format With Translation File",
+			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{
+					Phrases:   phrases,
+					ToPhrases: toPhrases,
+					Unaligned: []interfaces.UnalignedCue{{File: "translation_file_path", Start: 9 * time.Second, End: 12 * time.Second, Text: "Goodbye."}},
 				}
-				if err = multiWriter.WriteField("pattern", "1"); err != nil {
-					require.NoError(t, err)
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+				stubs.AudioFileX.EXPECT().
+					GetAlignedLines(gomock.Any(), gomock.Any(), uploadedOpts(interfaces.ParseOptions{}, "target.srt")).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				srtText := "1\n00:00:01,000 --> 00:00:04,000\nEsta es la primera línea.\n"
+				translationText := "1\n00:00:01,500 --> 00:00:04,500\nThis is the first line.\n"
+				return createDualMultiPartBody(t, []byte(srtText), []byte(translationText), formMap)
+			},
+			checkResponse: func(res *http.Response) {
+				// the aligned translations are spoken instead of translating the phrases
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "1", res.Header.Get(unalignedHeader))
+				require.Equal(t, toPhrases, audioTitle.ToPhrases)
+				require.Equal(t, "en", audioTitle.ToLang)
+				require.Len(t, audioTitle.Unaligned, 1)
+			},
+		},
+		{
+			name: "Text With Translation File",
+			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{
+					Phrases:   phrases,
+					ToPhrases: toPhrases,
+					Alignment: []interfaces.SentenceAlignment{
+						{Kind: "1:1", Text: phrases[0].Text, Translation: toPhrases[0].Text},
+						{Kind: "1:1", Text: phrases[1].Text, Translation: toPhrases[1].Text},
+					},
 				}
-
-				// Close the writer - this will actually make the form valid,
-				// so this test will need special handling in the ServerMock
-				multiWriter.Close()
-				return body, multiWriter
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+				stubs.AudioFileX.EXPECT().
+					GetAlignedLines(gomock.Any(), gomock.Any(), uploadedOpts(interfaces.ParseOptions{}, "target.srt")).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				text := "Ayer fuimos al mercado y compramos manzanas. El vendedor nos regaló unas naranjas muy dulces."
+				translationText := "Yesterday we went to the market and bought apples. The seller gave us some very sweet oranges."
+				return createDualMultiPartBody(t, []byte(text), []byte(translationText), formMap)
 			},
 			checkResponse: func(res *http.Response) {
-				// Error could be either in opening or format detection depending on the mock
-				require.True(t, res.StatusCode == http.StatusBadRequest)
+				// the paragraph is not rejected as unparsed and its sentences are aligned
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "1:1=2", res.Header.Get(sentenceAlignmentHeader))
+				require.Equal(t, toPhrases, audioTitle.ToPhrases)
+				require.Len(t, audioTitle.Alignment, 2)
 			},
 		},
 		{
-			name: "Missing Form File",
+			name: "Bilingual File With Translation File",
 			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
 			},
 			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
-				// Create a multipart form without the file_path field
-				body := new(bytes.Buffer)
-				multiWriter := multipart.NewWriter(body)
-
-				// Add other form fields but no file
-				if err := multiWriter.WriteField("token", randomToken); err != nil {
-					require.NoError(t, err)
-				}
-				if err := multiWriter.WriteField("title_name", title.Name); err != nil {
-					require.NoError(t, err)
-				}
-				if err := multiWriter.WriteField("from_voice_id", title.FromVoice); err != nil {
-					require.NoError(t, err)
-				}
-				if err := multiWriter.WriteField("to_voice_id", title.ToVoice); err != nil {
-					require.NoError(t, err)
-				}
-				if err := multiWriter.WriteField("pause", "5"); err != nil {
-					require.NoError(t, err)
-				}
-				if err := multiWriter.WriteField("pattern", "1"); err != nil {
-					require.NoError(t, err)
-				}
-
-				multiWriter.Close()
-				return body, multiWriter
+				tsvText := "Good morning.\tBuenos días.\nHow are you today?\t¿Cómo estás hoy?\n"
+				translationText := "1\n00:00:01,500 --> 00:00:04,500\nEsta es la primera línea.\n"
+				return createDualMultiPartBody(t, []byte(tsvText), []byte(translationText), formMap)
 			},
 			checkResponse: func(res *http.Response) {
 				require.Equal(t, http.StatusBadRequest, res.StatusCode)
 				resBody := readBody(t, res)
-				require.Contains(t, resBody, "request body has an error: doesn't match schema: Error at \\\"/file_path\\\":")
+				require.Contains(t, resBody, "a translation file can only be used with subtitles or text")
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
-
-			ts := setupServerTest(ctrl, tc)
-			multiBody, multiWriter := tc.multipartBody(t)
-			req, err := http.NewRequest(http.MethodPost, ts.URL+audioBasePath, multiBody)
-			require.NoError(t, err)
-
-			req.Header.Set("Content-Type", multiWriter.FormDataContentType())
-			res, err := ts.Client().Do(req)
-			require.NoError(t, err)
-			defer res.Body.Close()
-
-			tc.checkResponse(res)
-		})
-	}
-}
-
-func TestParseFile(t *testing.T) {
-	if util.Test != "unit" && !testing.Short() {
-		t.Skip("skipping unit test")
-	}
-
-	t.Parallel()
-
-	parseFileName := testutil.ParseBasePath + "TestParseFile.txt"
-	err := os.MkdirAll(testutil.ParseBasePath, 0777)
-	require.NoError(t, err)
-	defer os.RemoveAll(testutil.ParseBasePath)
-
-	testCases := []testCase{
 		{
-			name: "OK",
+			name: "Detect Anki Package",
 			mocks: func(stubs testutil.MockStubs) {
-				file, err := os.Create(parseFileName)
-				require.NoError(t, err)
-				defer file.Close()
-				stubs.AudioFileX.EXPECT().
-					GetLines(gomock.Any()).
-					Return([]string{"This is the first sentence.", "This is the second sentence."}, nil)
+				result := interfaces.ParseResult{Phrases: phrases, ToPhrases: toPhrases}
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
 				stubs.AudioFileX.EXPECT().
-					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
-					Return(file, nil)
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{Deck: "Spanish", BackField: "Back"}, testFileName)).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
 			},
 			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
-				data := []byte(testutil.FiveSentences)
-				return createMultiPartBody(t, data, parseFileName, nil)
+				apkg, err := os.ReadFile("../internal/services/audiofile/testdata/legacy.apkg")
+				require.NoError(t, err)
+				ankiFormMap := maps.Clone(formMap)
+				ankiFormMap["deck"] = "Spanish"
+				ankiFormMap["back_field"] = "Back"
+				return createMultiPartBody(t, apkg, testFileName, ankiFormMap)
 			},
 			checkResponse: func(res *http.Response) {
+				// the backs of the notes are spoken instead of translating the fronts
 				require.Equal(t, http.StatusOK, res.StatusCode)
-				require.Equal(t, "attachment; filename=\"TestParseFile.txt_parsed.zip\"",
-					res.Header.Get("Content-Disposition"))
+				require.Equal(t, phrases, audioTitle.TitlePhrases)
+				require.Equal(t, toPhrases, audioTitle.ToPhrases)
+				require.Equal(t, "en", audioTitle.ToLang)
 			},
 		},
 		{
-			name: "File Too Large",
+			name: "Epub With Chapters",
 			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{Phrases: phrases}
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
 				stubs.AudioFileX.EXPECT().
-					GetLines(gomock.Any()).
-					Return(nil, services.NewFileTooLargeError(65000, 64000))
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{Chapters: []int{2}}, testFileName)).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
 			},
 			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
-				data := []byte("This is a test file that is too large.\n")
-				return createMultiPartBody(t, data, parseFileName, nil)
+				epub, err := os.ReadFile("../internal/services/audiofile/testdata/book.epub")
+				require.NoError(t, err)
+				epubFormMap := maps.Clone(formMap)
+				epubFormMap["chapters"] = "2"
+				return createMultiPartBody(t, epub, testFileName, epubFormMap)
 			},
 			checkResponse: func(res *http.Response) {
-				require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+				// the phrases of the chosen chapters are translated
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, phrases, audioTitle.TitlePhrases)
+				require.Empty(t, audioTitle.ToPhrases)
+			},
+		},
+		{
+			name: "Epub Without Chapters",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				epub, err := os.ReadFile("../internal/services/audiofile/testdata/book.epub")
+				require.NoError(t, err)
+				return createMultiPartBody(t, epub, testFileName, formMap)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
 				resBody := readBody(t, res)
-				require.Contains(t, resBody, "file too large")
+				require.Contains(t, resBody, "Please parse file before uploading")
 			},
 		},
 		{
-			name: "Error Getting Form File",
+			name: "Docx With Translation File",
 			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
 			},
 			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
-				// Create a multipart form without the file_path field
-				body := new(bytes.Buffer)
-				multiWriter := multipart.NewWriter(body)
-				err := multiWriter.WriteField("other_field", "value")
+				docx, err := os.ReadFile("../internal/services/audiofile/testdata/lesson.docx")
 				require.NoError(t, err)
-				require.NoError(t, multiWriter.Close())
-				return body, multiWriter
+				return createDualMultiPartBody(t, docx, []byte("This is the first sentence.\n"), formMap)
 			},
 			checkResponse: func(res *http.Response) {
 				require.Equal(t, http.StatusBadRequest, res.StatusCode)
 				resBody := readBody(t, res)
-				require.Contains(t, resBody, "request body has an error: failed to decode request body: part other_field: undefined")
+				require.Contains(t, resBody, "a translation file can only be used with subtitles or text")
 			},
 		},
 		{
-			name: "Error Zipping File",
+			name: "Invalid Skip Notes",
 			mocks: func(stubs testutil.MockStubs) {
-				stubs.AudioFileX.EXPECT().
-					GetLines(gomock.Any()).
-					Return([]string{"This is a test sentence."}, nil)
-				stubs.AudioFileX.EXPECT().
-					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
-					Return(nil, fmt.Errorf("error creating zip file"))
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
 			},
 			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
-				data := []byte("This is a test sentence.\n")
-				return createMultiPartBody(t, data, parseFileName, nil)
+				notesFormMap := maps.Clone(formMap)
+				notesFormMap["skip_notes"] = "sometimes"
+				return createMultiPartBody(t, []byte("This is the first line.\n"), testFileName, notesFormMap)
 			},
 			checkResponse: func(res *http.Response) {
-				require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
 				resBody := readBody(t, res)
-				require.Contains(t, resBody, "error zipping file")
+				require.Contains(t, resBody, "skip_notes must be true or false")
 			},
 		},
-	}
-
-	for _, tc := range testCases {
-		t.Run(tc.name, func(t *testing.T) {
-			ctrl := gomock.NewController(t)
-			defer ctrl.Finish()
+		{
+			name: "Detect Kindle Clippings",
+			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{Phrases: phrases}
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{Book: "principito", FromDate: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)}, testFileName)).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				clippings, err := os.ReadFile("../internal/services/audiofile/testdata/clippings.txt")
+				require.NoError(t, err)
+				kindleFormMap := maps.Clone(formMap)
+				kindleFormMap["book"] = "principito"
+				kindleFormMap["from_date"] = "2024-04-01"
+				return createMultiPartBody(t, clippings, testFileName, kindleFormMap)
+			},
+			checkResponse: func(res *http.Response) {
+				// the chosen highlights are translated
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, phrases, audioTitle.TitlePhrases)
+				require.Empty(t, audioTitle.ToPhrases)
+			},
+		},
+		{
+			name: "Detect Markdown",
+			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{
+					Phrases:  phrases,
+					Sections: []interfaces.Section{{Name: "Greetings", Phrases: 2}},
+				}
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{}, testFileName)).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				course := "# Greetings\n\nThis is how you say hello to a friend. And this is how you say goodbye to them.\n"
+				return createMultiPartBody(t, []byte(course), testFileName, maps.Clone(formMap))
+			},
+			checkResponse: func(res *http.Response) {
+				// a markdown file is not rejected as unparsed so its sections are kept
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, phrases, audioTitle.TitlePhrases)
+				require.Equal(t, []interfaces.Section{{Name: "Greetings", Phrases: 2}}, audioTitle.Sections)
+			},
+		},
+		{
+			name: "Invalid From Date",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				kindleFormMap := maps.Clone(formMap)
+				kindleFormMap["from_date"] = "04/01/2024"
+				return createMultiPartBody(t, []byte("This is the first line.\n"), testFileName, kindleFormMap)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "from_date must be a date like 2024-03-31")
+			},
+		},
+		{
+			name: "To Date Before From Date",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				kindleFormMap := maps.Clone(formMap)
+				kindleFormMap["from_date"] = "2024-04-01"
+				kindleFormMap["to_date"] = "2024-03-01"
+				return createMultiPartBody(t, []byte("This is the first line.\n"), testFileName, kindleFormMap)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "to_date must not be before from_date")
+			},
+		},
+		{
+			name: "Same Anki Front And Back Field",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				ankiFormMap := maps.Clone(formMap)
+				ankiFormMap["front_field"] = "Front"
+				ankiFormMap["back_field"] = "front"
+				return createMultiPartBody(t, []byte("This is the first line.\n"), testFileName, ankiFormMap)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "front_field and back_field must be different fields")
+			},
+		},
+		{
+			name: "Detect Bilingual Format",
+			mocks: func(stubs testutil.MockStubs) {
+				result := interfaces.ParseResult{Phrases: phrases, ToPhrases: toPhrases}
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(interfaces.ParseOptions{}, testFileName)).
+					Return(result, nil)
+				upload.expectAudio(t, stubs, result)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				tsvText := "Good morning.\tBuenos días.\nHow are you today?\t¿Cómo estás hoy?\n"
+				return createMultiPartBody(t, []byte(tsvText), testFileName, formMap)
+			},
+			checkResponse: func(res *http.Response) {
+				// the translations of the file are spoken instead of translating the phrases
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, phrases, audioTitle.TitlePhrases)
+				require.Equal(t, toPhrases, audioTitle.ToPhrases)
+				require.Equal(t, "en", audioTitle.ToLang)
+			},
+		},
+		{
+			name: "Error Opening File",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.ModelsX.EXPECT().
+					CheckToken(gomock.Any(), randomToken).
+					Return(nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				// Create a corrupt multipart form to force file open error
+				body := new(bytes.Buffer)
+				multiWriter := multipart.NewWriter(body)
+
+				// Create a form file with empty content
+				part, err := multiWriter.CreateFormFile("file_path", "corrupt_file.txt")
+				require.NoError(t, err)
+
+				// Write a partial/corrupt file content
+				_, err = part.Write([]byte{0xFF, 0xD8}) // Incomplete content
+				require.NoError(t, err)
+
+				// Add form fields
+				if err = multiWriter.WriteField("token", randomToken); err != nil {
+					require.NoError(t, err)
+				}
+				if err = multiWriter.WriteField("title_name", title.Name); err != nil {
+					require.NoError(t, err)
+				}
+				if err = multiWriter.WriteField("from_voice_id", title.FromVoice); err != nil {
+					require.NoError(t, err)
+				}
+				if err = multiWriter.WriteField("to_voice_id", title.ToVoice); err != nil {
+					require.NoError(t, err)
+				}
+				if err = multiWriter.WriteField("pause", "5"); err != nil {
+					require.NoError(t, err)
+				}
+				if err = multiWriter.WriteField("pattern", "1"); err != nil {
+					require.NoError(t, err)
+				}
+
+				// Close the writer - this will actually make the form valid,
+				// so this test will need special handling in the ServerMock
+				multiWriter.Close()
+				return body, multiWriter
+			},
+			checkResponse: func(res *http.Response) {
+				// Error could be either in opening or format detection depending on the mock
+				require.True(t, res.StatusCode == http.StatusBadRequest)
+			},
+		},
+		{
+			name: "Missing Form File",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				// Create a multipart form without the file_path field
+				body := new(bytes.Buffer)
+				multiWriter := multipart.NewWriter(body)
+
+				// Add other form fields but no file
+				if err := multiWriter.WriteField("token", randomToken); err != nil {
+					require.NoError(t, err)
+				}
+				if err := multiWriter.WriteField("title_name", title.Name); err != nil {
+					require.NoError(t, err)
+				}
+				if err := multiWriter.WriteField("from_voice_id", title.FromVoice); err != nil {
+					require.NoError(t, err)
+				}
+				if err := multiWriter.WriteField("to_voice_id", title.ToVoice); err != nil {
+					require.NoError(t, err)
+				}
+				if err := multiWriter.WriteField("pause", "5"); err != nil {
+					require.NoError(t, err)
+				}
+				if err := multiWriter.WriteField("pattern", "1"); err != nil {
+					require.NoError(t, err)
+				}
+
+				multiWriter.Close()
+				return body, multiWriter
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "request body has an error: doesn't match schema: Error at \\\"/file_path\\\":")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ts := setupServerTest(ctrl, tc)
+			multiBody, multiWriter := tc.multipartBody(t)
+			req, err := http.NewRequest(http.MethodPost, ts.URL+audioBasePath, multiBody)
+			require.NoError(t, err)
+
+			req.Header.Set("Content-Type", multiWriter.FormDataContentType())
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			tc.checkResponse(res)
+		})
+	}
+}
+
+func TestParseFile(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	t.Parallel()
+
+	parseFileName := testutil.ParseBasePath + "TestParseFile.txt"
+	err := os.MkdirAll(testutil.ParseBasePath, 0777)
+	require.NoError(t, err)
+	defer os.RemoveAll(testutil.ParseBasePath)
+
+	testCases := []testCase{
+		{
+			name: "OK",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), gomock.Any()).
+					Return(interfaces.ParseResult{Lines: []string{"This is the first sentence.", "This is the second sentence."}}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, nil)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "attachment; filename=\"TestParseFile.txt_parsed.zip\"",
+					res.Header.Get("Content-Disposition"))
+			},
+		},
+		{
+			name: "Encoding",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{Encoding: "sjis"}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(interfaces.ParseResult{Lines: []string{"This is the first sentence."}, Encoding: "shift_jis"}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"encoding": "sjis"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "shift_jis", res.Header.Get(fileEncodingHeader))
+			},
+		},
+		{
+			name: "Epub Chapters",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{Chapters: []int{1, 2, 3}}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(interfaces.ParseResult{
+						Lines: []string{"This is the first sentence."},
+						Chapters: []interfaces.Chapter{
+							{Number: 1, Title: "Chapter One", Phrases: 1},
+							{Number: 2, Title: "Chapter Two", Phrases: 0},
+							{Number: 3, Title: "Chapter Three", Phrases: 0},
+						},
+					}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"chapters": "3, 1-2"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "3", res.Header.Get(chaptersHeader))
+			},
+		},
+		{
+			name: "Pdf Extracted Text",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), gomock.Any()).
+					Return(interfaces.ParseResult{
+						Lines: []string{"This is the first sentence."},
+						Text:  "This is the first sentence.",
+					}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ any, path, name string) (*os.File, error) {
+						// the extracted text is in the directory that is zipped
+						text, err := os.ReadFile(path + name + "-text.txt")
+						require.NoError(t, err)
+						require.Equal(t, "This is the first sentence.\n", string(text))
+						return file, nil
+					})
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, nil)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+			},
+		},
+		{
+			name: "Invalid Chapters",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"chapters": "2-1"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid chapters: 2-1 must be a chapter number or a range like 1-3")
+			},
+		},
+		{
+			name: "Chapters Range Too Large",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"chapters": "1-2000000000"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid chapters: 1-2000000000 is after chapter 10000")
+			},
+		},
+		{
+			name: "Invalid Encoding",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"encoding": "klingon"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid encoding")
+			},
+		},
+		{
+			name: "Skip Styles",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{SkipStyles: []string{"Signs", "Karaoke"}}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(interfaces.ParseResult{Lines: []string{"This is the first sentence.", "This is the second sentence."}}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"skip_styles": "Signs, Karaoke,"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+			},
+		},
+		{
+			name: "Phrase Policy",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{Policy: interfaces.PhrasePolicy{MinWords: 2, MaxWords: 15, MaxChars: 200, SentencesOnly: true}}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(interfaces.ParseResult{Lines: []string{"This is the first sentence."}}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				formMap := map[string]string{"min_words": "2", "max_words": "15", "max_chars": "200", "split_on_commas": "false"}
+				return createMultiPartBody(t, data, parseFileName, formMap)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+			},
+		},
+		{
+			name: "Invalid Phrase Policy",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"min_words": "8", "max_words": "5"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "min_words must not be greater than max_words")
+			},
+		},
+		{
+			name: "Cleanup",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{Cleanup: []string{"speaker_labels", "caps_descriptions"}}
+				result := interfaces.ParseResult{
+					Lines: []string{"This is the first sentence."},
+					Cleanup: []interfaces.CleanupCount{
+						{Rule: "speaker_labels", Count: 12},
+						{Rule: "caps_descriptions", Count: 4},
+					},
+				}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(result, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"cleanup": "speaker_labels, caps_descriptions"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+				require.Equal(t, "speaker_labels=12, caps_descriptions=4", res.Header.Get(cleanupHeader))
+			},
+		},
+		{
+			name: "Invalid Cleanup Rule",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"cleanup": "laugh_track"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid cleanup rule: laugh_track")
+			},
+		},
+		{
+			name: "Invalid Format",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"format": "doc"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid request: format must be one of")
+			},
+		},
+		{
+			name: "Fuzzy Dedupe",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{Dedupe: interfaces.DedupeFuzzy, Similarity: 0.85}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(interfaces.ParseResult{Lines: []string{"This is the first sentence."}}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"dedupe": "Fuzzy", "similarity": "0.85"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+			},
+		},
+		{
+			name: "Invalid Dedupe",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"dedupe": "phonetic"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid request: dedupe must be one of exact, normalized, fuzzy")
+			},
+		},
+		{
+			name: "Similarity Without Fuzzy Dedupe",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"dedupe": "normalized", "similarity": "0.8"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "similarity can only be used with dedupe fuzzy")
+			},
+		},
+		{
+			name: "Invalid Similarity",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"dedupe": "fuzzy", "similarity": "1.5"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "similarity must be a number greater than 0 and at most 1")
+			},
+		},
+		{
+			name: "Time Range",
+			mocks: func(stubs testutil.MockStubs) {
+				file, err := os.Create(parseFileName)
+				require.NoError(t, err)
+				defer file.Close()
+				opts := interfaces.ParseOptions{StartTime: 12*time.Minute + 30*time.Second, EndTime: 18 * time.Minute}
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), uploadedOpts(opts, parseFileName)).
+					Return(interfaces.ParseResult{Lines: []string{"This is the first sentence."}}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(file, nil)
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"start_time": "12:30", "end_time": "00:18:00"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusOK, res.StatusCode)
+			},
+		},
+		{
+			name: "Invalid Time Range",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"start_time": "18:00", "end_time": "12:30"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "end_time must be after start_time")
+			},
+		},
+		{
+			name: "Invalid Language",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte(testutil.FiveSentences)
+				return createMultiPartBody(t, data, parseFileName, map[string]string{"language": "not a language"})
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "invalid language")
+			},
+		},
+		{
+			name: "File Too Large",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), gomock.Any()).
+					Return(interfaces.ParseResult{}, services.NewFileTooLargeError(65000, 64000))
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte("This is a test file that is too large.\n")
+				return createMultiPartBody(t, data, parseFileName, nil)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "file too large")
+			},
+		},
+		{
+			name: "Error Getting Form File",
+			mocks: func(stubs testutil.MockStubs) {
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				// Create a multipart form without the file_path field
+				body := new(bytes.Buffer)
+				multiWriter := multipart.NewWriter(body)
+				err := multiWriter.WriteField("other_field", "value")
+				require.NoError(t, err)
+				require.NoError(t, multiWriter.Close())
+				return body, multiWriter
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusBadRequest, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "request body has an error: failed to decode request body: part other_field: undefined")
+			},
+		},
+		{
+			name: "Error Zipping File",
+			mocks: func(stubs testutil.MockStubs) {
+				stubs.AudioFileX.EXPECT().
+					GetLines(gomock.Any(), gomock.Any()).
+					Return(interfaces.ParseResult{Lines: []string{"This is a test sentence."}}, nil)
+				stubs.AudioFileX.EXPECT().
+					CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, fmt.Errorf("error creating zip file"))
+			},
+			multipartBody: func(t *testing.T) (*bytes.Buffer, *multipart.Writer) {
+				data := []byte("This is a test sentence.\n")
+				return createMultiPartBody(t, data, parseFileName, nil)
+			},
+			checkResponse: func(res *http.Response) {
+				require.Equal(t, http.StatusInternalServerError, res.StatusCode)
+				resBody := readBody(t, res)
+				require.Contains(t, resBody, "error zipping file")
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
 
 			ts := setupServerTest(ctrl, tc)
 			multiBody, multiWriter := tc.multipartBody(t)
@@ -602,6 +1386,67 @@ func TestParseFile(t *testing.T) {
 	}
 }
 
+func TestParseFileReport(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	parseFileName := testutil.ParseBasePath + "TestParseFileReport.txt"
+	err := os.MkdirAll(testutil.ParseBasePath, 0777)
+	require.NoError(t, err)
+	defer os.RemoveAll(testutil.ParseBasePath)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	tc := testCase{
+		mocks: func(stubs testutil.MockStubs) {
+			result := interfaces.ParseResult{
+				Format:   "srt",
+				Encoding: "utf-8",
+				Lines:    []string{"Where are you going tonight?"},
+				Phrases:  []interfaces.Phrase{{ID: 0, Text: "Where are you going tonight?"}},
+				Discarded: []interfaces.DiscardedLine{
+					{Text: "(LAUGHS)", Reason: interfaces.DiscardFiltered, Rule: "sound_cues"},
+					{Text: "Where are you going tonight?", Reason: interfaces.DiscardDuplicate},
+				},
+			}
+			stubs.AudioFileX.EXPECT().
+				GetLines(gomock.Any(), gomock.Any()).
+				Return(result, nil)
+			// the report is returned instead of the zip
+			stubs.AudioFileX.EXPECT().
+				CreatePhrasesZip(gomock.Any(), gomock.Any(), gomock.Any()).
+				Times(0)
+		},
+	}
+	ts := setupServerTest(ctrl, tc)
+	multiBody, multiWriter := createMultiPartBody(t, []byte(testutil.FiveSentences), parseFileName, nil)
+	req, err := http.NewRequest(http.MethodPost, ts.URL+parseBasePath, multiBody)
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", multiWriter.FormDataContentType())
+	req.Header.Set("Accept", "text/html, application/json;q=0.9")
+	res, err := ts.Client().Do(req)
+	require.NoError(t, err)
+	defer res.Body.Close()
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var report oapi.ParseReport
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&report))
+	rule := "sound_cues"
+	require.Equal(t, oapi.ParseReport{
+		Format:   "srt",
+		Encoding: "utf-8",
+		Phrases:  []oapi.ParsedPhrase{{Id: 0, Text: "Where are you going tonight?"}},
+		Discarded: []oapi.DiscardedLine{
+			{Text: "(LAUGHS)", Reason: oapi.FilteredByRule, Rule: &rule},
+			{Text: "Where are you going tonight?", Reason: oapi.Duplicate},
+		},
+		Count:         1,
+		MaxNumPhrases: testCfg.MaxNumPhrases,
+	}, report)
+}
+
 // TestGoogleIntegration tests the audio from file endpoint with the google tts client
 // Program arguments: -test=integration -project-id=token-tltv-test
 func TestGoogleIntegration(t *testing.T) {
@@ -11,7 +11,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"talkliketv.com/tltv/internal/config"
+	"talkliketv.com/tltv/internal/interfaces"
 	"talkliketv.com/tltv/internal/models"
 	"talkliketv.com/tltv/internal/services/tokens"
 	"talkliketv.com/tltv/internal/testflags"
@@ -23,6 +25,7 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"golang.org/x/text/language"
 )
 
 var (
@@ -180,3 +183,86 @@ func createMultiPartBody(t *testing.T, data []byte, filename string, m map[strin
 	require.NoError(t, writer.Close())
 	return body, writer
 }
+
+// uploadedOpts adds the name of the file and the content type createMultiPartBody gives it to
+// the parse options the file is parsed with
+func uploadedOpts(opts interfaces.ParseOptions, filename string) interfaces.ParseOptions {
+	opts.FileName = filepath.Base(filename)
+	opts.ContentType = "application/octet-stream"
+	return opts
+}
+
+// createDualMultiPartBody creates a multipart body with a file_path file and a
+// translation_file_path file of the same video
+func createDualMultiPartBody(t *testing.T, data, translation []byte, m map[string]string) (*bytes.Buffer, *multipart.Writer) {
+	body := new(bytes.Buffer)
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file_path", "target.srt")
+	require.NoError(t, err)
+	_, err = part.Write(data)
+	require.NoError(t, err)
+	part, err = writer.CreateFormFile("translation_file_path", "native.srt")
+	require.NoError(t, err)
+	_, err = part.Write(translation)
+	require.NoError(t, err)
+	for key, val := range m {
+		err = writer.WriteField(key, val)
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return body, writer
+}
+
+// audioUpload is an upload of a file whose audio is created with mocked text-to-speech
+type audioUpload struct {
+	fromVoice interfaces.Voice
+	toVoice   interfaces.Voice
+	token     string
+	// zipFileName is the name of the zip file returned as the audio
+	zipFileName string
+	// title is set to the title passed to AudioFromTitle when the audio is created
+	title *interfaces.Title
+}
+
+// expectAudio expects the audio of the result of parsing the uploaded file to be created
+// with the voices of the upload
+func (u audioUpload) expectAudio(t *testing.T, stubs testutil.MockStubs, result interfaces.ParseResult) {
+	zipFile, err := os.Create(u.zipFileName)
+	require.NoError(t, err)
+	defer zipFile.Close()
+	*u.title = interfaces.Title{}
+
+	stubs.ModelsX.EXPECT().
+		GetVoice(gomock.Any(), u.fromVoice.Name).
+		Return(u.fromVoice, nil)
+	stubs.ModelsX.EXPECT().
+		GetVoice(gomock.Any(), u.toVoice.Name).
+		Return(u.toVoice, nil)
+	stubs.TranslateX.EXPECT().
+		DetectLanguage(gomock.Any(), sampleTexts(result.Phrases)).
+		Return(language.Spanish, nil)
+	if len(result.ToPhrases) > 0 {
+		stubs.TranslateX.EXPECT().
+			DetectLanguage(gomock.Any(), sampleTexts(result.ToPhrases)).
+			Return(language.English, nil)
+	}
+	// the text-to-speech of the from voice is the first created from the title
+	stubs.TranslateX.EXPECT().
+		CreateTTS(gomock.Any(), gomock.Any(), u.fromVoice, gomock.Any()).
+		DoAndReturn(func(_ context.Context, title interfaces.Title, _ interfaces.Voice, _ string) ([]interfaces.Phrase, error) {
+			*u.title = title
+			return title.TitlePhrases, nil
+		})
+	stubs.TranslateX.EXPECT().
+		CreateTTS(gomock.Any(), gomock.Any(), u.toVoice, gomock.Any()).
+		Return(result.Phrases, nil)
+	stubs.AudioFileX.EXPECT().
+		BuildAudioInputFiles(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+	stubs.AudioFileX.EXPECT().
+		CreateMp3Zip(gomock.Any(), gomock.Any()).
+		Return(zipFile, nil)
+	stubs.ModelsX.EXPECT().
+		UpdateTokenField(gomock.Any(), true, u.token, "UploadUsed").
+		Return(nil)
+}
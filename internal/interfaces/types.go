@@ -43,11 +43,251 @@ type Title struct {
 	TitlePhrases []Phrase
 	ToPhrases    []Phrase
 	Pattern      int
+	// ToLang is the language of the ToPhrases. They are only spoken by a voice of this
+	// language and the phrases are translated for any other voice.
+	ToLang string
+	// Unaligned are the subtitles or sentences of two aligned files that have no translation
+	// in the other file
+	Unaligned []UnalignedCue
+	// Alignment is how the sentences of two aligned text files were matched
+	Alignment []SentenceAlignment
+	// Sections split the TitlePhrases into named parts that are each made into their own mp3
+	Sections []Section
+}
+
+// ParseOptions are the optional form values sent with an uploaded file that change
+// how it is parsed into phrases
+type ParseOptions struct {
+	// SkipStyles are the ASS/SSA styles (e.g. Signs or Karaoke) whose dialogue is ignored
+	SkipStyles []string
+	// Language is the BCP 47 language of the file (e.g. ja, zh-TW). When it is empty the
+	// language is detected from the script of each line.
+	Language string
+	// Encoding is the WHATWG label of the file's character encoding (e.g. shift_jis,
+	// windows-1252). When it is empty the encoding is detected.
+	Encoding string
+	// Policy controls the length of the phrases
+	Policy PhrasePolicy
+	// StartTime and EndTime select the cues of a timed subtitle file that begin in the
+	// range. An EndTime of 0 is the end of the file.
+	StartTime time.Duration
+	EndTime   time.Duration
+	// Cleanup are the names of the CleanupRules used to remove subtitle annotations that are
+	// not spoken. When it is empty the default rules are used and "none" turns cleanup off.
+	Cleanup []string
+	// Deck is the name of the deck of an Anki package whose notes are used with its subdecks.
+	// When it is empty the notes of every deck are used.
+	Deck string
+	// FrontField and BackField are the names or numbers starting at 1 of the fields of the
+	// Anki notes used as the phrases and their translations. When FrontField is empty the
+	// first field is used and when BackField is empty the phrases are translated.
+	FrontField string
+	BackField  string
+	// Chapters are the numbers starting at 1 of the chapters of an epub whose text is used.
+	// When it is empty every chapter is used.
+	Chapters []int
+	// SkipNotes drops the headers, footers, footnotes and endnotes of a docx or odt document
+	SkipNotes bool
+	// Book selects the highlights of Kindle clippings of the books whose title or author
+	// contains it
+	Book string
+	// FromDate and ToDate select the Kindle highlights added from the start of FromDate to
+	// the end of ToDate. A zero date does not limit the range.
+	FromDate time.Time
+	ToDate   time.Time
+	// Format is the name of the registered format the file is parsed as (e.g. srt,
+	// paragraph). When it is empty the format is detected.
+	Format string
+	// FileName and ContentType are the name and MIME type of the uploaded file, which are
+	// hints for detecting its format
+	FileName    string
+	ContentType string
+	// Dedupe is one of the DedupeModes. When it is empty only the phrases that are the same as
+	// an earlier phrase are removed.
+	Dedupe string
+	// Similarity is how similar from 0 to 1 a phrase has to be to an earlier phrase to be a
+	// near duplicate of it with DedupeFuzzy. When it is 0 the default of 0.9 is used.
+	Similarity float64
+}
+
+// HasTimeRange checks if a start or end time was given
+func (o ParseOptions) HasTimeRange() bool {
+	return o.StartTime > 0 || o.EndTime > 0
+}
+
+// HasClippingFilter checks if a book or a date range of Kindle highlights was given
+func (o ParseOptions) HasClippingFilter() bool {
+	return o.Book != "" || !o.FromDate.IsZero() || !o.ToDate.IsZero()
+}
+
+// PhrasePolicy controls the length of the phrases a file is split into. Zero values use
+// the defaults of 4 to 10 words and 150 characters.
+type PhrasePolicy struct {
+	// MinWords is the fewest words of a phrase. Shorter pieces are combined or dropped.
+	MinWords int
+	// MaxWords is the number of words at which a phrase is split on its punctuation
+	MaxWords int
+	// MaxChars is the most characters a phrase can have. Longer phrases are dropped.
+	MaxChars int
+	// SentencesOnly splits long phrases only at the end of a sentence and not on commas
+	SentencesOnly bool
+}
+
+// CleanupRules are the names of the rules that remove the annotations of subtitles for the
+// deaf and hard of hearing (SDH) and scripts that should not be translated and spoken
+var CleanupRules = []string{
+	// speaker_labels removes upper case speaker labels like JOHN: or - DR. SMITH:
+	"speaker_labels",
+	// sound_cues removes parenthetical sound cues like (LAUGHS) or (door slams)
+	"sound_cues",
+	// music_lines removes the lyrics between music notes like ♪ la la la ♪
+	"music_lines",
+	// dialogue_dashes removes the dashes that start the line of each speaker
+	"dialogue_dashes",
+	// caps_descriptions removes subtitles that are only upper case like PHONE RINGING. It
+	// is not a default rule because some subtitles are written in upper case.
+	"caps_descriptions",
+}
+
+// The ways the phrases of a file are deduplicated
+const (
+	// DedupeExact removes the phrases that are the same as an earlier phrase
+	DedupeExact = "exact"
+	// DedupeNormalized also removes the phrases that are the same as an earlier phrase after
+	// Unicode NFKC normalization, case folding and removing punctuation and extra spaces, like
+	// I don't know! after I don't know.
+	DedupeNormalized = "normalized"
+	// DedupeFuzzy also removes the phrases whose normalized text has an edit distance or a
+	// Jaccard similarity of its words to the normalized text of an earlier phrase that is at
+	// least the Similarity
+	DedupeFuzzy = "fuzzy"
+)
+
+// DedupeModes are the names of the ways the phrases of a file are deduplicated
+var DedupeModes = []string{DedupeExact, DedupeNormalized, DedupeFuzzy}
+
+// CleanupCount is how many subtitles or lines of a file a cleanup rule changed
+type CleanupCount struct {
+	Rule  string
+	Count int
+}
+
+// The reasons a line or sentence of an uploaded file is not one of its phrases
+const (
+	// DiscardTooShort is a line with fewer words or characters than the phrase policy allows
+	DiscardTooShort = "too_short"
+	// DiscardTooLong is a phrase with more characters than the phrase policy allows
+	DiscardTooLong = "too_long"
+	// DiscardDuplicate is a phrase that is already one of the phrases
+	DiscardDuplicate = "duplicate"
+	// DiscardNearDuplicate is a phrase that was merged into a phrase it is a near duplicate of
+	DiscardNearDuplicate = "near_duplicate"
+	// DiscardMarkupOnly is a line that only has tags or text between brackets
+	DiscardMarkupOnly = "markup_only"
+	// DiscardFontStyled is a subtitle styled with font tags, which are usually signs or lyrics
+	DiscardFontStyled = "font_styled"
+	// DiscardFiltered is a line that a cleanup rule removed all of
+	DiscardFiltered = "filtered_by_rule"
+)
+
+// DiscardedLine is a line or sentence of an uploaded file that is not one of its phrases with
+// the reason it was dropped
+type DiscardedLine struct {
+	Text   string
+	Reason string
+	// Rule is the cleanup rule that removed the line when the Reason is DiscardFiltered
+	Rule string
+	// DuplicateOf is the phrase the line was merged into when the Reason is
+	// DiscardNearDuplicate
+	DuplicateOf string
+}
+
+// ParseResult is what was found in an uploaded file
+type ParseResult struct {
+	// Format is the name of the format the file was parsed as
+	Format string
+	// Lines are the phrases parsed from the file
+	Lines []string
+	// Phrases are the Lines with their IDs and, for timed subtitle files, the time of the
+	// cue they are spoken in
+	Phrases []Phrase
+	// ToPhrases are the translations of the Phrases from a bilingual file with the same IDs.
+	// They are used instead of machine translation.
+	ToPhrases []Phrase
+	// Encoding is the character encoding the file was decoded from
+	Encoding string
+	// Cleanup is how many subtitles or lines each cleanup rule that was used changed
+	Cleanup []CleanupCount
+	// Unaligned are the subtitles or sentences of two aligned files that have no translation
+	// in the other file
+	Unaligned []UnalignedCue
+	// Alignment is how the sentences of two aligned text files were matched
+	Alignment []SentenceAlignment
+	// Chapters are the chapters of an epub so they can be chosen with ParseOptions.Chapters
+	Chapters []Chapter
+	// Text is the text extracted from a pdf before it was parsed into phrases
+	Text string
+	// Sections are the headings of a Markdown file with how many of the Phrases are under each
+	Sections []Section
+	// Discarded are the lines and sentences of the file that were dropped in the order they
+	// were found
+	Discarded []DiscardedLine
+}
+
+// Chapter is a chapter of an epub with the title from its table of contents and how many
+// phrases its text was split into
+type Chapter struct {
+	Number  int
+	Title   string
+	Phrases int
+}
+
+// Section is a named part of a title, like the phrases under a heading of a Markdown file.
+// The phrases of a section follow the phrases of the sections before it.
+type Section struct {
+	Name    string
+	Phrases int
+}
+
+// UnalignedCue is a subtitle or sentence of one of two aligned files that has no translation
+// in the other file. The Start and End are 0 for text files.
+type UnalignedCue struct {
+	// File is the form field of the file the subtitle or sentence is from
+	File  string
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// SentenceAlignment is a group of sentences of a text file matched with a group of sentences
+// of its translation
+type SentenceAlignment struct {
+	// Kind is the number of sentences of each file in the group like 1:1, 2:1 or 0:1
+	Kind        string
+	Text        string
+	Translation string
+}
+
+// FileLines returns the Lines to write to a file. The phrases of a bilingual file are
+// written with their translation after a tab so the file can be uploaded again.
+func (r ParseResult) FileLines() []string {
+	if len(r.ToPhrases) != len(r.Lines) {
+		return r.Lines
+	}
+	lines := make([]string, len(r.Lines))
+	for i, line := range r.Lines {
+		lines[i] = line + "\t" + r.ToPhrases[i].Text
+	}
+	return lines
 }
 
 type Phrase struct {
 	ID   int
 	Text string
+	// Start and End are the time of the subtitle cue the phrase is from. They are 0 for
+	// files that are not timed.
+	Start time.Duration
+	End   time.Duration
 }
 
 type Status int
@@ -88,19 +88,34 @@ func (mr *MockAudioFileXMockRecorder) CreatePhrasesZip(arg0, arg1, arg2 any) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreatePhrasesZip", reflect.TypeOf((*MockAudioFileX)(nil).CreatePhrasesZip), arg0, arg1, arg2)
 }
 
+// GetAlignedLines mocks base method.
+func (m *MockAudioFileX) GetAlignedLines(arg0, arg1 multipart.File, arg2 interfaces.ParseOptions) (interfaces.ParseResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAlignedLines", arg0, arg1, arg2)
+	ret0, _ := ret[0].(interfaces.ParseResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAlignedLines indicates an expected call of GetAlignedLines.
+func (mr *MockAudioFileXMockRecorder) GetAlignedLines(arg0, arg1, arg2 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAlignedLines", reflect.TypeOf((*MockAudioFileX)(nil).GetAlignedLines), arg0, arg1, arg2)
+}
+
 // GetLines mocks base method.
-func (m *MockAudioFileX) GetLines(arg0 multipart.File) ([]string, error) {
+func (m *MockAudioFileX) GetLines(arg0 multipart.File, arg1 interfaces.ParseOptions) (interfaces.ParseResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetLines", arg0)
-	ret0, _ := ret[0].([]string)
+	ret := m.ctrl.Call(m, "GetLines", arg0, arg1)
+	ret0, _ := ret[0].(interfaces.ParseResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetLines indicates an expected call of GetLines.
-func (mr *MockAudioFileXMockRecorder) GetLines(arg0 any) *gomock.Call {
+func (mr *MockAudioFileXMockRecorder) GetLines(arg0, arg1 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLines", reflect.TypeOf((*MockAudioFileX)(nil).GetLines), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLines", reflect.TypeOf((*MockAudioFileX)(nil).GetLines), arg0, arg1)
 }
 
 // MockcmdRunnerX is a mock of cmdRunnerX interface.
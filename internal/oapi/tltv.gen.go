@@ -17,6 +17,31 @@ import (
 	openapi_types "github.com/oapi-codegen/runtime/types"
 )
 
+// Defines values for DiscardedLineReason.
+const (
+	Duplicate      DiscardedLineReason = "duplicate"
+	FilteredByRule DiscardedLineReason = "filtered_by_rule"
+	FontStyled     DiscardedLineReason = "font_styled"
+	MarkupOnly     DiscardedLineReason = "markup_only"
+	NearDuplicate  DiscardedLineReason = "near_duplicate"
+	TooLong        DiscardedLineReason = "too_long"
+	TooShort       DiscardedLineReason = "too_short"
+)
+
+// DiscardedLine defines model for DiscardedLine.
+type DiscardedLine struct {
+	// DuplicateOf the phrase the line was merged into when the reason is near_duplicate
+	DuplicateOf *string             `json:"duplicateOf,omitempty"`
+	Reason      DiscardedLineReason `json:"reason"`
+
+	// Rule the cleanup rule that removed the line when the reason is filtered_by_rule
+	Rule *string `json:"rule,omitempty"`
+	Text string  `json:"text"`
+}
+
+// DiscardedLineReason defines model for DiscardedLine.Reason.
+type DiscardedLineReason string
+
 // Error defines model for Error.
 type Error struct {
 	// Code Error code
@@ -26,13 +51,111 @@ type Error struct {
 	Message string `json:"message"`
 }
 
+// ParseReport the report of a parsed file that is returned instead of the zip when the request accepts
+// application/json
+type ParseReport struct {
+	// Count how many phrases the file was parsed into
+	Count int `json:"count"`
+
+	// Discarded the lines and sentences of the file that are not phrases in the order they were found
+	Discarded []DiscardedLine `json:"discarded"`
+
+	// Encoding the character encoding the uploaded file was decoded from
+	Encoding string `json:"encoding"`
+
+	// Format the format the file was parsed as, like srt or paragraph
+	Format string `json:"format"`
+
+	// MaxNumPhrases the most phrases audio is created for from one file. A file with more phrases is
+	// split into files of at most this many phrases
+	MaxNumPhrases int            `json:"maxNumPhrases"`
+	Phrases       []ParsedPhrase `json:"phrases"`
+}
+
+// ParsedPhrase defines model for ParsedPhrase.
+type ParsedPhrase struct {
+	Id   int    `json:"id"`
+	Text string `json:"text"`
+}
+
 // AudioFromFileMultipartBody defines parameters for AudioFromFile.
 type AudioFromFileMultipartBody struct {
+	// BackField the name or number (starting at 1) of the field of the Anki notes used as the translations
+	// of the phrases instead of machine translation. The phrases are translated if it is not set
+	BackField *string `json:"back_field,omitempty"`
+
+	// Book only the highlights of Kindle clippings (My Clippings.txt) of the books whose title or
+	// author contains this text. Kindle clippings can be uploaded without parsing.
+	// The highlights of every book are used if it is not set
+	Book *string `json:"book,omitempty"`
+
+	// Chapters the numbers of the chapters of an epub to create phrases for, as a comma separated list
+	// of numbers and ranges. An epub can be uploaded without parsing when chapters are chosen.
+	// Every chapter is used if it is not set
+	Chapters *string `json:"chapters,omitempty"`
+
+	// Cleanup comma separated list of the rules that remove the annotations of subtitles for the
+	// deaf and hard of hearing and of scripts from subtitle and one phrase per line files.
+	// speaker_labels removes labels like JOHN: or - MARY:, sound_cues removes (LAUGHS) or
+	// (door slams), music_lines removes ♪ lyrics ♪, dialogue_dashes removes the dash in front
+	// of each speaker's line and caps_descriptions removes subtitles that are all upper case
+	// like PHONE RINGING. The default is every rule except caps_descriptions and none turns
+	// cleanup off
+	Cleanup *string `json:"cleanup,omitempty"`
+
+	// Deck the deck of an Anki .apkg or .colpkg file whose notes are used, including its subdecks.
+	// The notes of every deck are used if it is not set
+	Deck *string `json:"deck,omitempty"`
+
+	// Dedupe how the phrases are deduplicated. exact (the default) removes the phrases that are the same,
+	// normalized also removes the ones that are the same after Unicode NFKC normalization, case
+	// folding and removing punctuation and extra spaces, and fuzzy also removes the ones that
+	// are as similar as similarity by their edit distance or the words they share
+	Dedupe *string `json:"dedupe,omitempty"`
+
+	// Encoding the character encoding of the uploaded file (e.g. windows-1252, shift_jis, gbk, utf-16le).
+	// If it is not set the encoding is detected from the byte order mark or the text
+	Encoding *string `json:"encoding,omitempty"`
+
+	// EndTime only the cues of a srt, vtt, ass, ttml, lrc or youtube transcript file that begin before this time
+	// (hh:mm:ss, mm:ss or seconds) become phrases. On /audio a subtitle file can be
+	// uploaded without parsing it first when start_time or end_time is sent
+	EndTime *string `json:"end_time,omitempty"`
+
+	// FilePath the file to create audio from. A Markdown file can be uploaded without parsing, and the
+	// phrases under each of its headings are made into their own mp3 named after the heading
 	FilePath openapi_types.File `json:"file_path"`
 
+	// Format the format the uploaded file is parsed as instead of detecting it. One of srt, vtt,
+	// ass, ttml, lrc, json3, srv3, json, html, kindle, markdown, bilingual, anki, epub,
+	// docx, odt, pdf, paragraph or lines
+	Format *string `json:"format,omitempty"`
+
+	// FromDate only the Kindle highlights added on or after this date
+	FromDate *string `json:"from_date,omitempty"`
+
 	// FromVoiceId the language you know
 	FromVoiceId string `json:"from_voice_id"`
 
+	// FrontField the name or number (starting at 1) of the field of the Anki notes used as the phrases.
+	// The first field is used if it is not set
+	FrontField *string `json:"front_field,omitempty"`
+
+	// Language the language of the uploaded file (e.g. ja, zh-TW). Chinese, Japanese and other languages
+	// that do not use spaces between words are split into phrases by characters instead of words.
+	// If it is not set the language is detected from the text
+	Language *string `json:"language,omitempty"`
+
+	// MaxChars phrases with more characters than this (10 to 500, default 150) are dropped
+	MaxChars *string `json:"max_chars,omitempty"`
+
+	// MaxWords phrases with this many words or more (1 to 50, default 10) are split on their punctuation
+	MaxWords *string `json:"max_words,omitempty"`
+
+	// MinWords the fewest words in a phrase (1 to 50, default 4). Shorter pieces of a split phrase are
+	// combined and shorter phrases are dropped
+	MinWords *string `json:"min_words,omitempty"`
+
 	// Pattern pattern is the pattern used to construct the audio files. You have 3 choices:
 	// 1 is standard and repeats closer together --
 	// 2 is advanced and repeats phrases less often and should only be used if you are at an advanced level --
@@ -42,19 +165,140 @@ type AudioFromFileMultipartBody struct {
 	// Pause the pause in seconds between phrases in the audiofile (default is 4)
 	Pause string `json:"pause"`
 
+	// Similarity how similar from 0 to 1 (default 0.9) a phrase has to be to an earlier phrase to be removed
+	// with dedupe fuzzy
+	Similarity *string `json:"similarity,omitempty"`
+
+	// SkipNotes if true the headers, footers, footnotes and endnotes of a docx or odt document are not
+	// parsed. They are parsed if it is not set
+	SkipNotes *string `json:"skip_notes,omitempty"`
+
+	// SkipStyles comma separated list of ASS/SSA subtitle styles whose lines will not be parsed
+	SkipStyles *string `json:"skip_styles,omitempty"`
+
+	// SplitOnCommas if true (the default) long phrases are split on commas and other clause punctuation,
+	// if false they are only split at the end of a sentence
+	SplitOnCommas *string `json:"split_on_commas,omitempty"`
+
+	// StartTime only the cues of a srt, vtt, ass, ttml, lrc or youtube transcript file that begin at or after this time
+	// (hh:mm:ss, mm:ss or seconds) become phrases
+	StartTime *string `json:"start_time,omitempty"`
+
 	// TitleName choose a descriptive title that includes to and from languages
 	TitleName string `json:"title_name"`
 
+	// ToDate only the Kindle highlights added on or before this date
+	ToDate *string `json:"to_date,omitempty"`
+
 	// ToVoiceId the language you want to learn
 	ToVoiceId string `json:"to_voice_id"`
 
 	// Token tokens are required to be able to successfully request an audio file
 	Token string `json:"token"`
+
+	// TranslationFilePath a srt, vtt, ass, ttml, lrc or youtube transcript subtitle file of the same video, or the translation of a text file, in the
+	// language you know. Subtitles are aligned with the subtitles of file_path by time and the sentences
+	// of text files are aligned by their length and punctuation. They are used as the translations of the
+	// phrases instead of machine translation. Subtitles and sentences that could not be aligned are listed
+	// in a text file in the zip and counted in the X-Unaligned-Cues response header. How the sentences of
+	// text files were matched is listed in a text file in the zip and counted in the X-Sentence-Alignment
+	// response header
+	TranslationFilePath *openapi_types.File `json:"translation_file_path,omitempty"`
 }
 
 // ParseFileMultipartBody defines parameters for ParseFile.
 type ParseFileMultipartBody struct {
+	// BackField the name or number (starting at 1) of the field of the Anki notes used as the translations
+	// of the phrases instead of machine translation. The phrases are translated if it is not set
+	BackField *string `json:"back_field,omitempty"`
+
+	// Book only the highlights of Kindle clippings (My Clippings.txt) of the books whose title or
+	// author contains this text. Kindle clippings can be uploaded without parsing.
+	// The highlights of every book are used if it is not set
+	Book *string `json:"book,omitempty"`
+
+	// Chapters the numbers of the chapters of an epub to parse, as a comma separated list of numbers
+	// and ranges. Every chapter is used if it is not set. The zip of an epub has a chapters
+	// file with the number, title and how many phrases each chapter has, and the
+	// X-Epub-Chapters header is how many chapters there are
+	Chapters *string `json:"chapters,omitempty"`
+
+	// Cleanup comma separated list of the rules that remove the annotations of subtitles for the
+	// deaf and hard of hearing and of scripts from subtitle and one phrase per line files.
+	// speaker_labels removes labels like JOHN: or - MARY:, sound_cues removes (LAUGHS) or
+	// (door slams), music_lines removes ♪ lyrics ♪, dialogue_dashes removes the dash in front
+	// of each speaker's line and caps_descriptions removes subtitles that are all upper case
+	// like PHONE RINGING. The default is every rule except caps_descriptions and none turns
+	// cleanup off
+	Cleanup *string `json:"cleanup,omitempty"`
+
+	// Deck the deck of an Anki .apkg or .colpkg file whose notes are used, including its subdecks.
+	// The notes of every deck are used if it is not set
+	Deck *string `json:"deck,omitempty"`
+
+	// Dedupe how the phrases are deduplicated. exact (the default) removes the phrases that are the same,
+	// normalized also removes the ones that are the same after Unicode NFKC normalization, case
+	// folding and removing punctuation and extra spaces, and fuzzy also removes the ones that
+	// are as similar as similarity by their edit distance or the words they share
+	Dedupe *string `json:"dedupe,omitempty"`
+
+	// Encoding the character encoding of the uploaded file (e.g. windows-1252, shift_jis, gbk, utf-16le).
+	// If it is not set the encoding is detected from the byte order mark or the text
+	Encoding *string `json:"encoding,omitempty"`
+
+	// EndTime only the cues of a srt, vtt, ass, ttml, lrc or youtube transcript file that begin before this time
+	// (hh:mm:ss, mm:ss or seconds) become phrases
+	EndTime  *string            `json:"end_time,omitempty"`
 	FilePath openapi_types.File `json:"file_path"`
+
+	// Format the format the uploaded file is parsed as instead of detecting it. One of srt, vtt,
+	// ass, ttml, lrc, json3, srv3, json, html, kindle, markdown, bilingual, anki, epub,
+	// docx, odt, pdf, paragraph or lines
+	Format *string `json:"format,omitempty"`
+
+	// FromDate only the Kindle highlights added on or after this date
+	FromDate *string `json:"from_date,omitempty"`
+
+	// FrontField the name or number (starting at 1) of the field of the Anki notes used as the phrases.
+	// The first field is used if it is not set
+	FrontField *string `json:"front_field,omitempty"`
+
+	// Language the language of the uploaded file (e.g. ja, zh-TW). Chinese, Japanese and other languages
+	// that do not use spaces between words are split into phrases by characters instead of words.
+	// If it is not set the language is detected from the text
+	Language *string `json:"language,omitempty"`
+
+	// MaxChars phrases with more characters than this (10 to 500, default 150) are dropped
+	MaxChars *string `json:"max_chars,omitempty"`
+
+	// MaxWords phrases with this many words or more (1 to 50, default 10) are split on their punctuation
+	MaxWords *string `json:"max_words,omitempty"`
+
+	// MinWords the fewest words in a phrase (1 to 50, default 4). Shorter pieces of a split phrase are
+	// combined and shorter phrases are dropped
+	MinWords *string `json:"min_words,omitempty"`
+
+	// Similarity how similar from 0 to 1 (default 0.9) a phrase has to be to an earlier phrase to be removed
+	// with dedupe fuzzy
+	Similarity *string `json:"similarity,omitempty"`
+
+	// SkipNotes if true the headers, footers, footnotes and endnotes of a docx or odt document are not
+	// parsed. They are parsed if it is not set
+	SkipNotes *string `json:"skip_notes,omitempty"`
+
+	// SkipStyles comma separated list of ASS/SSA subtitle styles whose lines will not be parsed
+	SkipStyles *string `json:"skip_styles,omitempty"`
+
+	// SplitOnCommas if true (the default) long phrases are split on commas and other clause punctuation,
+	// if false they are only split at the end of a sentence
+	SplitOnCommas *string `json:"split_on_commas,omitempty"`
+
+	// StartTime only the cues of a srt, vtt, ass, ttml, lrc or youtube transcript file that begin at or after this time
+	// (hh:mm:ss, mm:ss or seconds) become phrases
+	StartTime *string `json:"start_time,omitempty"`
+
+	// ToDate only the Kindle highlights added on or before this date
+	ToDate *string `json:"to_date,omitempty"`
 }
 
 // AudioFromFileMultipartRequestBody defines body for AudioFromFile for multipart/form-data ContentType.
@@ -132,30 +376,77 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 // Base64 encoded, gzipped, json marshaled Swagger object
 var swaggerSpec = []string{
 
-	"H4sIAAAAAAAC/8xWXW8ruQ39K4TahxaYO3aSRVH4qV/ZIsDdRdCbFijqRUBrOB5tNJQqcew4i/z3ghqP",
-	"P669d9un2yd7JIqkDg+P+JOxoY+BiSWbxU8m2456LH/vUwpJ/8QUIiVxVJZtaEh/G8o2uSgusFmMxlD2",
-	"KtOG1KOYhXEsd7emMrKLNH7SmpJ5r0xPOeP6Zx1N24ejWZLjtXl/r0yifw8uUWMW/zL7gJP5D+9q4LgN",
-	"Y6YsaEX/Uo/Om4Vphiy7Le6Y/mBDbzFLzSSmMoy9hvmL7sMnfBmzPE/tCf3LR/dCT/8AlwHBI68HXBN4",
-	"wsSO14AxemdR7aGh7NZMDUiAjnyEIVPKEDaUbOgJpCOIHoVwgCWHVoiB2IaBhRI1sHXSQZCO0jEQxphr",
-	"eBAIbavOECKlHBi9e6PmmAe9RkqO2BIsebUD9D5sdWPMQQLYLoQ8JpEjWdc6C7FLmCnr4g62yKKGbbBD",
-	"hsA1/L2ctcgwRB+wgSUjCL0KtM7TmO/kwjFETLhOGDtQOlQQmPbbmjR4x1RBSEAbYkCGT397Ko4qQFbf",
-	"mtopnlvnPayJKaEQIGRSGOC7xzvAoXGhHC53a9E670TNDohIl8Kw7sC7LKQrNSx5yf8MQ7mRTVS88okv",
-	"yJLQrTuBNoW+QFWWUeAxZIFZMZ3ppq7X8NAWI3HiCTrMsOQ+JDrBFblY9Pha0kcBG7h16/o7fP1+6B8n",
-	"9GS8bSIZEgPCm4uRmjF8aI+gZ8jROwHHEibPS+ahX1FSw33kGh4gkQ19T9xAFkwyYuIybHEHOcBuAqIj",
-	"+6Ig9vhCkIekHEHR/VRCLnmLWYubqQEbUiIrflcv2VTGO0ucS1Pv++mPEW1HcFvPTWWGpC3YicS8mM22",
-	"222NZbsOaT3bn82zjw9/vv/+0/2H23ped9J77cMC6WkHbkxlNpTy2Jg39byeq12IxBidWZi7slSZiNIV",
-	"2RqrVeQsZLnUnYkB17r6SInChJH+2tmvogzOaaxGXRaKnUK5orM2yGp6pQkKciqwheYPjaKmAb9Nof/W",
-	"eZU3VTzK8qfQ7CZdIy536AcvLmKSmTbZhwYFjyp+qd2a27NCUj4mlV45xrS71NrK6HWfN8FZenbNJWbK",
-	"uANcyqAXDltTGXrFPpaC3fzu9prfiCKU+NLjfkP1tejj/nPIo47awFnSYKXsnrR9DdrJHW4I7lTbnKW8",
-	"gCXfqKcsyA2mpghLokgoGawPmRJIWFPR2A8fYMm3RdibDbKlc/Ophz3lDKNY63buwuAbCOx3Wu+Sp2sL",
-	"FpiKVKikTA49bciPke40UqKNo+1ZHEI7qWghi7ieisGeURMS01GfCJvdyFVqpjQLp06qcL0GQ6brNS1b",
-	"St5MNnCTYUWyJeJTeT8UoPD9Nw21OHjRW33z29Pg31Smx1fXD71Z3Mwr0zseP+6u5FT6/HnUjosGHV8s",
-	"hMPyZtLaolCOrR+a8QVQwEqrTuzM1xCQ8L9we3oQC9Rn8N7Of3/d/QtdoXhZzoUe0ySjblcEuNK7BMiD",
-	"tZRzO3i/g33vnz9NvzgXnQD5eRef37s60YQp5Ykbxz794RAvrH4kK6bMWef30igf94A9NEqE8mYwjoUK",
-	"vB5UIcO5zZdhrkGnDvjr/RPMDqXUeUJ12uUyABxOuyaPz9ARCEkDFWRyDPq4aDVu5/PPRPRkypi9uXgu",
-	"oL+okpdI7Ouk/CvNMYUfJ8rSJ1/I4Mcc+DyFXydqzcL8anYc1Wf7OX02DulXkhhYh0Ar1ABNNu+VmZWX",
-	"++efwbKdj8PO4a0bRUrnkfzFgSSMQ9CkFOOz2lx55B410td94D5rmuPh/4rvk/VE4wLGKYULlv8XjHxz",
-	"8bJM+xluX6mvT8739/8EAAD//3sWhGONDgAA",
+	"H4sIAAAAAAAC/+xc724jR3J/lQITIBIwoihpfXAIGIhub23veXe9WMmXO4QGUZwpcno50z3X3SOKe9gH",
+	"ySvkW54hb5InCaq65w/JoaSNkzv4oC82Z7qnu7q66ld/V38ZpaasjCbt3Wj6l5FLcypRfv5OuRRtRtkb",
+	"pYlfVNZUZL0iGc7qqlApevpxKY/kUqsqr4weTUc+J6hyi46AfxZKE2zQQUl2RRko7Q1sctIyagmd0aAc",
+	"aEI7bxceJSO/rWg0HTlvlV6NPiejMJc3JF2Xo+m/jbwxc5cb63m6MfPC6NUoGfVXOVi2RLuuq7nRxXaU",
+	"jJZG+7nz24IyflKFJ0vZfLGd27qg0c9DZPDA4KnTglDXFfAM8Dl6sFSaO8p6jDg8+MGmA3t6uve8596A",
+	"MOXPtbKUCTt4Vsunnz8no1fWGnt4f6nJBo4gk0HGmDG2RD+ajpT2V5cdTUp7WpFlokpyDldHF2qGk0eo",
+	"jhs205ns92gdfaCKb3aQ01bGwCwBoeLJGbMxMl05sORrq0XanCfMeCZ/90lV/Sv4c03OA6YpVd7NNFZB",
+	"TJTR5x+d0TM9Sg44V+sBmnKzgRL1Ngq+k/WFIpb8SCFL/iAjs0bbhg/LguMAdQaOtCedkmvO0x0aLYE2",
+	"viVAhTMam5HlX1vYkCVYmlqzpCtPpRzoHy0tR9PRP5x3WHAegeB8FwU+t6SjtbjlZ9KpyfhOh9UhR4up",
+	"JwvNPCGprgqDGWUdfzJiGchgaU05JP2NLA5tEsYG+Y0ugUKtCRyLiuXXuLJY5UN7lHj/ri7fB+4Nb1Ua",
+	"1/EX60wZUA5SS+iZeGPlAGB0IGUM15Ek5XMojaXuctxMu6pQPqAhz5IrRR828blyO/I00x3NPcGpOnKf",
+	"dKGiV1k45OF97ull5HrvlrsN+zKbRKXY52GryM2GBzCksh6m9Y71NLRT2ShO/fkzjym9NEFFtcdUFqAS",
+	"VTGajrLa+e0Gt5r+JTVlis6PNTHFGkve4Xc8Dje4jvq4c/W3WKzfqDXd/gGUA4QC9arGFUFBaDVLdQ83",
+	"ICOnVpoy8AZyKiqoHVkH5o5saspgEqsCPWENM22WnrSoR63FCARhMT4n222EVeXG8JrxbsmLIVRkndFY",
+	"qE+UdXTQfUVWMUDATC+2gEVhNjwQaPAG0tyYaJddRalaqrQPWVvYoPY8cWnS2oHRY/hJvk1RR8WFmUZg",
+	"tveEuwc6rZKJZiaiDWGYiRYsS8BYoDvSgBpuPtwm8Ifb2wSub2544Pb27RtZOhHIm2kmts/hjSoKWJEm",
+	"i54AwZEYgrfvr6JSBnXiQ2CqCuV5Wssjn1tTr3IolPPEb8Yw0zP9J1PLGYM2M2HdWuC8RbXKfdDvFmrQ",
+	"w3vW1nOZes6DQe9fB3D2yhcEOTqY6R319zkGdC7xXshHD6nRS7Uav+2rECgfThvMGSDbr6pBTrPsrsFB",
+	"D07iyjOt63JBlifGncfwGiylpixJZ+A8Wh94ohxscAvOwLZhRE7pmplYIiNobaOd2ZraypYz3YPa1FhL",
+	"qS+2Y0GqQqWkg8ZHDbuuMM0JLseTUTKqLStl7n3lpufnm81mjDI8NnZ1Hr91529ev3z17ubV2eV4Ms59",
+	"WQgyMEv7Onk3SkZ3ZF1Q1YvxZDzheaYijZUaTUdX8ioZVehzAZ1wW/yrMm7AqDQSMKTnnUiIJLSWzN+L",
+	"gXHWR+T3jXowKxe0oxiOpw6oxXimr2GhCsXbFpC6O57o3V1P0RBSU9Sl7t2paEn/vc9JWfAWtStEYxyc",
+	"yBwNRg6JBeSEGVmwZjPTYiJJrwrl8sRVqJXLRUN1Qu4UFMuWWZMOFCjvwGz07vo9L6vENFea+uMz3bpc",
+	"O1+hpcZNaXltlsLa+Z1RKc1VJiww7aNIF5sQWeJ1xpLFl/KtNeW3Snzn6NX91mTbxhpQcNnKuvCqQuvP",
+	"GZrOMvTYhT2H1mmB6Xq+VFQccctYspm8qGUnrTqhh4vTzkOjonU/r/VagTaeHNTBQzngCtsE6EKox3g7",
+	"htveXLTdIGWglqDEHdbGgyMv3KN7LCtRot9iuh5yhRbGrA+PzAGTEJarVV4wHIrL8oPSGct5oapK6ZWD",
+	"k7dbeNk8jf29b1nB6zrY5GKBBBuNnWmsfS5hh/aotAtgxMA2Plw7alOrdyyRpvaCQgzlM317QCDdkd3K",
+	"3sKe2j3OmJeKNOB//adxkBE4U1CG2RCn0hwrT/aIvxgEo/XVm8n8jBqoqhdikgPeNFe4NDYBdKLRZYng",
+	"iIGDb5MtlkhHsy4rtUW9Yly/jgs+wqEQ/LSUMENSvg89nulXwqg4Bso9iVUXZ1cJfDXImxAMDwDswLka",
+	"HnH86/qhs7xFrY2PoGGW4OqFiI8wiyfMdEa4FH7kaEVVckIruqjlMezvAmw334fBQyAOFnXMLjqxSzgv",
+	"cEGFixQ5iI+Cm7//8ft3UzAWzuDt9Yc/TRNwHGHN05q6D07eXP/03fc3pyLvJ5kxFlyBpTtNoKydSuch",
+	"wmum//e//wcUW6tS+ZlAprAwq5rmGbq8N49Zw69AaT6YDtJBmOYQKf8nFw7EB02xcvPeTXTrdPxsI0ks",
+	"CqgrZkiKjqKNeP/9j+9ewYfX7757/e67ADwZLbEuRD6CnvEFAt1zQD2wI9OhmePszriZjjLCfu2eXO2y",
+	"vs/V5HDdIfHLKF0PqyWPRB0UPB5jtV7xFY5TU/DPYG4FpgJWN7iRgNJpUUskq7wwjhdzEXfC5BZyZJ+n",
+	"Qs5NsLvT6U0T4g8fKqsrGk4/+D07IHND1isbA91j6uHEd3d2uiNHPdc0SAC/dFhSMtOaA8EQamDhzM53",
+	"Rg99BLj0ZOEnrTiwh3ff/vASmlVEj5MoV0tTZI2ayrL8UNU69bXMkwG69xbBVZjy7fObZf3p0/YBYmZa",
+	"hNiBU6Uq0PZ+Kr+FxTY6SZQpD5lyHnUqtpxX2RibxWjI5Whp76Zk76HL+eJkSMS83XzICY1XY9gonZmN",
+	"O7u4/OoyAZerpZ9/VC6B1WKdQO2XZxe/Keh0PNOv9+RKlmy3UA4y8pT6mF0Jdnjrm8QQJ0Obc7PN3dfC",
+	"ZuPh82Zzr0p6wFUQFGRVA2d9Anfes21zCXhfFgkUNuXNt6b29SJ6LrJML6+1oJVik7Y0lqJvoEqa6ZM8",
+	"n5bllBeT//FCjlKjM3cKC5JYu416ftQxSANs0a7vn8/0UYOpmBjrfLCc4uPJqYOHHDggPjLpA9v49XQy",
+	"GcxnqYLmHJAcSWnJ4VvPIEYd1pScUXqLdp2xA94j/6i5D9oiFrJR8FrzvYuRMEtBMY4FxLtCS1BiRm0U",
+	"qay4+mV1Jc5uFtVanMDwkZy4TRUvlEa7/QUZvF1VUL1MXt8NDhIdLofvVqKGVsBmelfCEuBU7lUCzt5d",
+	"hYcEchldi3+ZQBl5mnThF3NurRJxqpKZzkx6n4DJfAJVtkx6WQ4TfAa3d/cP5holwsnQP6Q60fft+bKY",
+	"MWeM5i2bi2D9Rr8PUZeTyxdnk6uzycXR3ZuA6kjGuQnHOBuw1mazK9e/uTyyrvZ/nYCpUexgd4N+hu+e",
+	"6LZ+y7QOHaI5+SN8eQC6P2ICn/Kz2389HcPLnEWDEvg9Vsi/gsu5k91znOFCD5kRWmtH0dTBgvyGSEeD",
+	"hJb6SZ5GnxfbzrTsaIl8dcxAtAcZNBADluAjHkmaz3n3gQCooa9LfvfIjAkw5eDkYgLewFeTSdI6kxdf",
+	"TU6DC2MNp7v2ZHtyjBI58SOUdJl1mQ3GBuJOLgIZPSompz2eGx0Bseec7MP9YBhUKn2MMJF22hAbFyFG",
+	"acB4sQMEvTgdw01urCcLlaK0Na1CYPxM/JXUlAulWV10Bq75pO8bBsbuw8YQ/RV6T1YPsDUMgIoqGR9F",
+	"+9h4Ge28rdMgb73E7Bg415rjHcEVB58qJTeFmb7glZxHnaHNokNYEXoHaWEcWfBmRaI5Z2cw05c8HbM7",
+	"9tx2pzcHLcgxhzzphg81AwsD7KJzyhnhxFn0gLpbsKA7KsJOV6GgeKdos7OP2NDIdolpVNlEWrrdwZv2",
+	"08ISZtuQTaRsp7LTE6LhO6jdEUSSIVC68X1a0NirA8oFBJTqxWwvTvubvxA1UiXX1i8mIrrh4WqAps6d",
+	"Hg5H4ngAFdHxi27ryfifTztRz1Gy9QtxelADoS1UK7FxKFbSZ1rUOARCIQ7Y4+Bk/PWgIrq1quZiSg4J",
+	"VkvwtqbWsyHrElga49sfMRDUGZDO2kAPgR0DyedmjOBpXZJua7EzHdwXCZW38ja8eMw6MS1HjyDNCu7p",
+	"aZXrm5vzm5vrzu8NC8QAN2QepMjAtCwaEneDU7XSLvkBLZr1MGEMQXOj50LEAwzeDUC5XWMHl1qsDev0",
+	"rGVaiKD30DeZabWEJRahnhX4K9odVsEmFMoiTMbAej+c4wUGz9S6+n+NAAf9nlv3xVHOPpBcTq8GLaUI",
+	"wVzj0LlieRChfX3XpGqF1pD+CMU1icNZuVtHZnA388sc3X7g94Cne3VxZPMv8HOb0qcA9N4uXw8vv6YB",
+	"wyivgzw35eqIYbgIgZ2r05ScW9ZFse26UPolx8Htuqz//IEI8otFcTcgNssui3OnMjJJmx/o9g8i31Yf",
+	"k2hlZvogcBjDTZtdDInFUB2PzhhBl3zk0k9zLEnRNPZUpjVpsVAf6eqe/UXbvE5BeuVz+biHGD0cPlZ9",
+	"icfvwuXHqi+90+3054i6pOJxRFxtiOTtpficzbQ4fO1hGlvNTUriR0hTQNa8/uPZTzoucvYypJddZbRr",
+	"TNYYvo9pwH6b0Ez3mCUtQCX6NCcJlQId8IVkNCnKs2umppTExx4tT8sL7Levdci0H6Xu6nI/g9KoYeMl",
+	"dR5r17tnFh8p9SPpEtlVFt7lTZTZ18ISqW9rDMhn9Krmaq7ZnfMwdIyBeybgu1e3cN7MCqUKbI1y+7WS",
+	"GG3UZwQbSuFM4KhYU456douZ/V61T6raLWQ+zvkDTnRZpiAEzfajZBQ9Il546PIf6IZbWVNXoWYz2LnG",
+	"4qW824EWGegLqqiJONsb3MaOrovpxTcvJglcTi++uUpgMr345iIJKTo8XK1BDOXaiF143jHsUDD3te2B",
+	"U+5A2ONny5ExYee90qHNJfg6AVIfOEy34aMnaruq4pWL5/WAKH2MHbbdQg/1k4X20gFpqjX3IklWgZo5",
+	"n5PRuTiXx3svZLjXPNmcLcZdUjV6sAtmr3Yee/NinogBLXSMZstQPMgbE8ArSJmhS4QoD87wf2M4Jw0x",
+	"lDUeyX7/n3RvNLY8FERCWynssxdWxG97Pax9khuDFxxzWYiVYabbhrvGJg30Qki73XMfxHMfxK+wD0J0",
+	"/4HWB+g6H2a63/rwtL6FMfQhIG6bh90iQTPdNVp1dCfQNQsctHmLWWq2ztH1Ci5/PHtV1Yuzl81pgxVl",
+	"itpVWk4w7Mfc3XN3xXN3xXN3xXN3xXN3xXN3xa+iu+J/1fTw3DLwa2sZeC7tP5f2n0v7f1el/ecS7nMJ",
+	"97mE+2ss4f7tiqr7/0a4deqeVG1pZjdFFH6xU0ARyf3F9ZAvS2L3/9gA0/x/XVmJOafdLHVEkXjxbY11",
+	"JydcHv1TBQc55f0qzcsQ+p99qIunli6iUxpTWgd/yWKD0f1Kc9QrjtY5a7Gfxfnm4rKfTfjmxRPqLDtp",
+	"sgdo7ecNBRY4g9f7N4RxfCfjxff3YFWECeCE+dmr/5c/ZXD85H/7csznz/8zAL/tjvsBRgAA",
 }
 
 // GetSwagger returns the content of the embedded swagger specification file
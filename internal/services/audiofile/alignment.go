@@ -0,0 +1,369 @@
+package audiofile
+
+import (
+	"cmp"
+	"errors"
+	"math"
+	"mime/multipart"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"time"
+)
+
+const (
+	// wholeCueWords is a maximum phrase length that is never reached so the text of each
+	// subtitle or sentence is kept whole until two files are aligned
+	wholeCueWords = 1000
+	// maxSubtitleOffset is the largest difference between the times of the two files that
+	// is searched for when the subtitles of one file are shifted
+	maxSubtitleOffset = 30 * time.Second
+	// offsetStep is the precision of the offset between the two files
+	offsetStep = 250 * time.Millisecond
+	// offsetCandidates is how many of the most common offsets are compared
+	offsetCandidates = 5
+	// minimumOverlap is how much of the shorter of two subtitles must be shown at the same time
+	// as the other for them to be aligned
+	minimumOverlap = 0.5
+	// minimumDriftPairs is the fewest aligned pairs that the drift of the times of the native
+	// file is measured from
+	minimumDriftPairs = 3
+)
+
+// frameRateScales are the scales of the native times that are tried, which are no change and
+// the drift between subtitles made for videos of 23.976, 24 and 25 frames per second
+var frameRateScales = []float64{1, 25 / 23.976, 23.976 / 25, 24 / 23.976, 23.976 / 24, 25.0 / 24, 24.0 / 25}
+
+// GetAlignedLines parses two files of the same video or text, the target file in the
+// language of the phrases and the native file with their translations, and pairs them.
+// Subtitle files are paired by the subtitles that are shown at the same time and the
+// subtitles that are split or merged differently in the two files are combined and an
+// offset or drift between the times of the files is corrected. Text files like a graded
+// reader and its official translation are paired by sentence with alignSentences. The
+// subtitles or sentences that could not be aligned are returned in the result.
+func (af *AudioFile) GetAlignedLines(target, native multipart.File, opts interfaces.ParseOptions) (interfaces.ParseResult, error) {
+	c := newCleanup(opts.Cleanup)
+	targetCues, targetType, enc, err := wholeCues(target, opts, c)
+	if err != nil {
+		return interfaces.ParseResult{}, err
+	}
+	// the format and the hints of the upload are the ones of the target file
+	nativeOpts := opts
+	nativeOpts.Format, nativeOpts.FileName, nativeOpts.ContentType = "", "", ""
+	nativeCues, nativeType, _, err := wholeCues(native, nativeOpts, c)
+	if err != nil {
+		return interfaces.ParseResult{}, err
+	}
+
+	var pairs []cue
+	var unaligned []interfaces.UnalignedCue
+	var alignment []interfaces.SentenceAlignment
+	switch {
+	case IsTimed(targetType) && IsTimed(nativeType):
+		var unalignedTarget, unalignedNative []cue
+		pairs, unalignedTarget, unalignedNative = alignCues(targetCues, nativeCues)
+		pairs = inTimeRange(pairs, opts.StartTime, opts.EndTime)
+		for _, u := range inTimeRange(unalignedTarget, opts.StartTime, opts.EndTime) {
+			unaligned = append(unaligned, interfaces.UnalignedCue{File: "file_path", Start: u.start, End: u.end, Text: u.text})
+		}
+		for _, u := range inTimeRange(unalignedNative, opts.StartTime, opts.EndTime) {
+			unaligned = append(unaligned, interfaces.UnalignedCue{File: "translation_file_path", Start: u.start, End: u.end, Text: u.text})
+		}
+		slices.SortStableFunc(unaligned, func(a, b interfaces.UnalignedCue) int {
+			return cmp.Compare(a.Start, b.Start)
+		})
+	case isText(targetType) && isText(nativeType):
+		if opts.HasTimeRange() {
+			return interfaces.ParseResult{}, errors.New("start_time and end_time can only be used with srt, vtt, ass, ttml, lrc or youtube transcript files")
+		}
+		links := alignSentences(cueTexts(targetCues), cueTexts(nativeCues))
+		for _, link := range links {
+			switch {
+			case len(link.translations) == 0:
+				unaligned = append(unaligned, interfaces.UnalignedCue{File: "file_path", Text: strings.Join(link.sentences, " ")})
+			case len(link.sentences) == 0:
+				unaligned = append(unaligned, interfaces.UnalignedCue{File: "translation_file_path", Text: strings.Join(link.translations, " ")})
+			default:
+				pairs = append(pairs, cue{text: strings.Join(link.sentences, " "), translation: strings.Join(link.translations, " ")})
+			}
+		}
+		alignment = sentenceAlignmentReport(links)
+	default:
+		return interfaces.ParseResult{}, errors.New("both files must be srt, vtt, ass, ttml, lrc or youtube transcript subtitles or both must be text to be aligned")
+	}
+	if len(pairs) == 0 {
+		return interfaces.ParseResult{}, errors.New("no subtitles or sentences of the two files could be aligned")
+	}
+
+	phrases, translations := uniquePhrases(pairs, maxPhraseCharacters(opts.Policy), newDedupe(opts), c)
+	return interfaces.ParseResult{
+		Lines:     phraseTexts(phrases),
+		Phrases:   phrases,
+		ToPhrases: translations,
+		Encoding:  enc,
+		Cleanup:   c.report(),
+		Unaligned: unaligned,
+		Alignment: alignment,
+		Discarded: c.discards(),
+	}, nil
+}
+
+// wholeCues detects the format of and parses a file to be aligned into cues with the whole text of each
+// subtitle or sentence so they are only split where the other file is split
+func wholeCues(f multipart.File, opts interfaces.ParseOptions, c *cleanup) ([]cue, TextFormat, string, error) {
+	fileType, f, enc, err := DetectFormat(f, opts)
+	if err != nil {
+		return nil, 0, "", err
+	}
+
+	// the time range is applied to the aligned pairs
+	opts.StartTime, opts.EndTime = 0, 0
+	opts.Policy = interfaces.PhrasePolicy{MinWords: 1, MaxWords: wholeCueWords, MaxChars: opts.Policy.MaxChars}
+	result, err := parseFile(f, fileType, opts, c)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return result.cues, fileType, enc, nil
+}
+
+// isText checks if the format is a text file that is split into sentences
+func isText(fileType TextFormat) bool {
+	return fileType == Paragraph || fileType == OnePhrasePerLine
+}
+
+// alignCues pairs the target subtitles with the native subtitles shown at the same time.
+// The native times are first scaled by the frame rate change and shifted by the offset that
+// makes the most of the subtitles overlap, and then the remaining drift is corrected with the
+// pairs that were found. Groups of subtitles that overlap each other are combined into one
+// pair, so a subtitle that is split in two in the other file is still aligned. The subtitles
+// without a partner in the other file are returned with their own times.
+func alignCues(target, native []cue) ([]cue, []cue, []cue) {
+	target = slices.Clone(target)
+	slices.SortStableFunc(target, func(a, b cue) int { return cmp.Compare(a.start, b.start) })
+	native = slices.Clone(native)
+	slices.SortStableFunc(native, func(a, b cue) int { return cmp.Compare(a.start, b.start) })
+
+	var shifted []cue
+	bestOverlap := time.Duration(-1)
+	for _, scale := range frameRateScales {
+		offset := estimateOffset(target, transformCues(native, scale, 0))
+		candidate := transformCues(native, scale, float64(offset))
+		if overlap := totalOverlap(target, candidate); overlap > bestOverlap {
+			shifted, bestOverlap = candidate, overlap
+		}
+	}
+	groups := overlapGroups(target, shifted)
+	if scale, intercept, ok := estimateDrift(target, native, groups); ok {
+		shifted = transformCues(native, scale, intercept)
+		groups = overlapGroups(target, shifted)
+	}
+
+	var pairs, unalignedTarget, unalignedNative []cue
+	for _, group := range groups {
+		switch {
+		case len(group.native) == 0:
+			for _, i := range group.target {
+				unalignedTarget = append(unalignedTarget, target[i])
+			}
+		case len(group.target) == 0:
+			for _, i := range group.native {
+				unalignedNative = append(unalignedNative, native[i])
+			}
+		default:
+			pair := cue{start: target[group.target[0]].start}
+			var texts, translations []string
+			for _, i := range group.target {
+				pair.end = max(pair.end, target[i].end)
+				texts = append(texts, target[i].text)
+			}
+			for _, i := range group.native {
+				translations = append(translations, native[i].text)
+			}
+			pair.text = strings.Join(texts, " ")
+			pair.translation = strings.Join(translations, " ")
+			pairs = append(pairs, pair)
+		}
+	}
+
+	return pairs, unalignedTarget, unalignedNative
+}
+
+// cueGroup is the indexes of target and native subtitles that overlap each other
+type cueGroup struct {
+	target []int
+	native []int
+}
+
+// overlapGroups joins every target and native subtitle that overlap by at least
+// minimumOverlap of the shorter one into groups. The groups are in the order of their first
+// subtitle and a subtitle that overlaps nothing is a group of its own.
+func overlapGroups(target, native []cue) []cueGroup {
+	// parents is a union find forest of the target subtitles followed by the native subtitles
+	parents := make([]int, len(target)+len(native))
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	first := 0
+	for i, t := range target {
+		// skip the native subtitles that end before this and every later target subtitle starts
+		for first < len(native) && native[first].end <= t.start {
+			first++
+		}
+		for j := first; j < len(native) && native[j].start < t.end; j++ {
+			if overlapRatio(t, native[j]) >= minimumOverlap {
+				parents[find(i)] = find(len(target) + j)
+			}
+		}
+	}
+
+	index := make(map[int]int)
+	var groups []cueGroup
+	for i := range parents {
+		root := find(i)
+		g, ok := index[root]
+		if !ok {
+			g = len(groups)
+			index[root] = g
+			groups = append(groups, cueGroup{})
+		}
+		if i < len(target) {
+			groups[g].target = append(groups[g].target, i)
+		} else {
+			groups[g].native = append(groups[g].native, i-len(target))
+		}
+	}
+
+	slices.SortStableFunc(groups, func(a, b cueGroup) int {
+		return cmp.Compare(groupStart(a, target, native), groupStart(b, target, native))
+	})
+	return groups
+}
+
+// groupStart returns the earliest start of the subtitles of a group
+func groupStart(group cueGroup, target, native []cue) time.Duration {
+	start := time.Duration(math.MaxInt64)
+	for _, i := range group.target {
+		start = min(start, target[i].start)
+	}
+	for _, i := range group.native {
+		start = min(start, native[i].start)
+	}
+	return start
+}
+
+// overlapRatio returns how much of the shorter of the two subtitles is shown at the same
+// time as the other
+func overlapRatio(a, b cue) float64 {
+	overlap := min(a.end, b.end) - max(a.start, b.start)
+	shorter := min(a.end-a.start, b.end-b.start)
+	if overlap <= 0 || shorter <= 0 {
+		return 0
+	}
+	return float64(overlap) / float64(shorter)
+}
+
+// estimateOffset returns the offset to add to the native times that makes the most of the
+// subtitles of the two files overlap. The offsets between the starts of nearby subtitles are
+// rounded to offsetStep and the most common ones are compared with no offset.
+func estimateOffset(target, native []cue) time.Duration {
+	counts := make(map[time.Duration]int)
+	for _, t := range target {
+		for _, n := range native {
+			if d := t.start - n.start; d >= -maxSubtitleOffset && d <= maxSubtitleOffset {
+				counts[d.Round(offsetStep)]++
+			}
+		}
+	}
+	candidates := make([]time.Duration, 0, len(counts))
+	for offset := range counts {
+		candidates = append(candidates, offset)
+	}
+	slices.SortFunc(candidates, func(a, b time.Duration) int {
+		return cmp.Or(cmp.Compare(counts[b], counts[a]), cmp.Compare(a, b))
+	})
+	candidates = append([]time.Duration{0}, candidates[:min(len(candidates), offsetCandidates)]...)
+
+	best, bestOverlap := time.Duration(0), time.Duration(-1)
+	for _, offset := range candidates {
+		if overlap := totalOverlap(target, transformCues(native, 1, float64(offset))); overlap > bestOverlap {
+			best, bestOverlap = offset, overlap
+		}
+	}
+	return best
+}
+
+// totalOverlap returns how long the subtitles of the two files are shown at the same time
+func totalOverlap(target, native []cue) time.Duration {
+	var total time.Duration
+	j := 0
+	for _, t := range target {
+		for j < len(native) && native[j].end <= t.start {
+			j++
+		}
+		for k := j; k < len(native) && native[k].start < t.end; k++ {
+			if overlap := min(t.end, native[k].end) - max(t.start, native[k].start); overlap > 0 {
+				total += overlap
+			}
+		}
+	}
+	return total
+}
+
+// estimateDrift fits the times of the native subtitles of the groups with one subtitle from
+// each file to the times of their target subtitle with a line (target = scale * native +
+// intercept). It returns false if there are too few pairs or the scale is not within 10%.
+func estimateDrift(target, native []cue, groups []cueGroup) (float64, float64, bool) {
+	var xs, ys []float64
+	for _, group := range groups {
+		if len(group.target) != 1 || len(group.native) != 1 {
+			continue
+		}
+		t, n := target[group.target[0]], native[group.native[0]]
+		xs = append(xs, float64(n.start+n.end)/2)
+		ys = append(ys, float64(t.start+t.end)/2)
+	}
+	if len(xs) < minimumDriftPairs {
+		return 0, 0, false
+	}
+
+	var meanX, meanY float64
+	for i := range xs {
+		meanX += xs[i]
+		meanY += ys[i]
+	}
+	meanX /= float64(len(xs))
+	meanY /= float64(len(ys))
+	var covariance, variance float64
+	for i := range xs {
+		covariance += (xs[i] - meanX) * (ys[i] - meanY)
+		variance += (xs[i] - meanX) * (xs[i] - meanX)
+	}
+	if variance == 0 {
+		return 0, 0, false
+	}
+	scale := covariance / variance
+	if scale < 0.9 || scale > 1.1 {
+		return 0, 0, false
+	}
+	return scale, meanY - scale*meanX, true
+}
+
+// transformCues returns copies of the cues with their times scaled and then offset by
+// intercept nanoseconds
+func transformCues(cues []cue, scale, intercept float64) []cue {
+	transformed := make([]cue, len(cues))
+	for i, c := range cues {
+		c.start = time.Duration(float64(c.start)*scale + intercept)
+		c.end = time.Duration(float64(c.end)*scale + intercept)
+		transformed[i] = c
+	}
+	return transformed
+}
@@ -0,0 +1,219 @@
+package audiofile
+
+import (
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignCues(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	s := time.Second
+	tests := []struct {
+		name            string
+		target          []cue
+		native          []cue
+		pairs           []cue
+		unalignedTarget int
+		unalignedNative int
+	}{
+		{
+			name:   "same times",
+			target: []cue{{start: 1 * s, end: 3 * s, text: "Hola."}, {start: 4 * s, end: 6 * s, text: "Adiós."}},
+			native: []cue{{start: 1 * s, end: 3 * s, text: "Hello."}, {start: 4 * s, end: 6 * s, text: "Goodbye."}},
+			pairs: []cue{
+				{start: 1 * s, end: 3 * s, text: "Hola.", translation: "Hello."},
+				{start: 4 * s, end: 6 * s, text: "Adiós.", translation: "Goodbye."},
+			},
+		},
+		{
+			name:   "native split in two",
+			target: []cue{{start: 1 * s, end: 5 * s, text: "¿Dónde está la estación de tren?"}},
+			native: []cue{{start: 1 * s, end: 3 * s, text: "Where is"}, {start: 3 * s, end: 5 * s, text: "the train station?"}},
+			pairs: []cue{
+				{start: 1 * s, end: 5 * s, text: "¿Dónde está la estación de tren?", translation: "Where is the train station?"},
+			},
+		},
+		{
+			name:   "target split in two",
+			target: []cue{{start: 1 * s, end: 3 * s, text: "¿Dónde está"}, {start: 3 * s, end: 5 * s, text: "la estación?"}},
+			native: []cue{{start: 1 * s, end: 5 * s, text: "Where is the station?"}},
+			pairs: []cue{
+				{start: 1 * s, end: 5 * s, text: "¿Dónde está la estación?", translation: "Where is the station?"},
+			},
+		},
+		{
+			name: "native offset by two seconds",
+			target: []cue{
+				{start: 10 * s, end: 12 * s, text: "Uno."},
+				{start: 13 * s, end: 15 * s, text: "Dos."},
+				{start: 16 * s, end: 18 * s, text: "Tres."},
+			},
+			native: []cue{
+				{start: 12 * s, end: 14 * s, text: "One."},
+				{start: 15 * s, end: 17 * s, text: "Two."},
+				{start: 18 * s, end: 20 * s, text: "Three."},
+			},
+			pairs: []cue{
+				{start: 10 * s, end: 12 * s, text: "Uno.", translation: "One."},
+				{start: 13 * s, end: 15 * s, text: "Dos.", translation: "Two."},
+				{start: 16 * s, end: 18 * s, text: "Tres.", translation: "Three."},
+			},
+		},
+		{
+			name: "native drifts four percent",
+			target: []cue{
+				{start: 100 * s, end: 102 * s, text: "Uno."},
+				{start: 200 * s, end: 202 * s, text: "Dos."},
+				{start: 300 * s, end: 302 * s, text: "Tres."},
+				{start: 400 * s, end: 402 * s, text: "Cuatro."},
+			},
+			native: []cue{
+				{start: 104 * s, end: 106080 * time.Millisecond, text: "One."},
+				{start: 208 * s, end: 210080 * time.Millisecond, text: "Two."},
+				{start: 312 * s, end: 314080 * time.Millisecond, text: "Three."},
+				{start: 416 * s, end: 418080 * time.Millisecond, text: "Four."},
+			},
+			pairs: []cue{
+				{start: 100 * s, end: 102 * s, text: "Uno.", translation: "One."},
+				{start: 200 * s, end: 202 * s, text: "Dos.", translation: "Two."},
+				{start: 300 * s, end: 302 * s, text: "Tres.", translation: "Three."},
+				{start: 400 * s, end: 402 * s, text: "Cuatro.", translation: "Four."},
+			},
+		},
+		{
+			name: "unaligned cues",
+			target: []cue{
+				{start: 1 * s, end: 3 * s, text: "Hola."},
+				{start: 20 * s, end: 22 * s, text: "Nadie lo traduce."},
+			},
+			native: []cue{
+				{start: 1 * s, end: 3 * s, text: "Hello."},
+				{start: 40 * s, end: 42 * s, text: "Nobody says this."},
+			},
+			pairs: []cue{
+				{start: 1 * s, end: 3 * s, text: "Hola.", translation: "Hello."},
+			},
+			unalignedTarget: 1,
+			unalignedNative: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pairs, unalignedTarget, unalignedNative := alignCues(tt.target, tt.native)
+			require.Equal(t, tt.pairs, pairs)
+			require.Len(t, unalignedTarget, tt.unalignedTarget)
+			require.Len(t, unalignedNative, tt.unalignedNative)
+		})
+	}
+}
+
+func TestGetAlignedLines(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	target := "1\n00:00:01,000 --> 00:00:03,000\nBuenos días a todos.\n\n" +
+		"2\n00:00:04,000 --> 00:00:06,000\n¿Cómo están ustedes hoy?\n\n" +
+		"3\n00:00:30,000 --> 00:00:32,000\nEsta línea no tiene traducción.\n"
+	native := "WEBVTT\n\n00:01.500 --> 00:03.500\nGood morning everyone.\n\n" +
+		"00:04.500 --> 00:05.500\nHow are you\n\n00:05.500 --> 00:06.500\ndoing today?\n"
+
+	tests := []struct {
+		name   string
+		native string
+		opts   interfaces.ParseOptions
+		check  func(*testing.T, interfaces.ParseResult, error)
+	}{
+		{
+			name:   "srt aligned with vtt",
+			native: native,
+			check: func(t *testing.T, result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"Buenos días a todos.", "¿Cómo están ustedes hoy?"}, result.Lines)
+				require.Equal(t, []interfaces.Phrase{
+					{ID: 0, Text: "Good morning everyone."},
+					{ID: 1, Text: "How are you doing today?"},
+				}, result.ToPhrases)
+				require.Equal(t, []interfaces.UnalignedCue{
+					{File: "file_path", Start: 30 * time.Second, End: 32 * time.Second, Text: "Esta línea no tiene traducción."},
+				}, result.Unaligned)
+			},
+		},
+		{
+			name:   "time range",
+			native: native,
+			opts:   interfaces.ParseOptions{EndTime: 10 * time.Second},
+			check: func(t *testing.T, result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Len(t, result.Lines, 2)
+				require.Empty(t, result.Unaligned)
+			},
+		},
+		{
+			name:   "translation is not subtitles",
+			native: "Good morning everyone.\nHow are you doing today?\n",
+			check: func(t *testing.T, result interfaces.ParseResult, err error) {
+				require.ErrorContains(t, err, "both files must be srt, vtt, ass, ttml, lrc or youtube transcript subtitles or both must be text")
+			},
+		},
+		{
+			name:   "nothing aligned",
+			native: "WEBVTT\n\n05:00.000 --> 05:02.000\nMuch later.\n",
+			check: func(t *testing.T, result interfaces.ParseResult, err error) {
+				require.ErrorContains(t, err, "no subtitles or sentences of the two files could be aligned")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			af := AudioFile{}
+			result, err := af.GetAlignedLines(
+				createTmpFile(t, "target", target),
+				createTmpFile(t, "native", tt.native),
+				tt.opts)
+			tt.check(t, result, err)
+		})
+	}
+}
+
+func TestGetAlignedLinesText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	target := "Ayer fuimos al mercado. Compramos manzanas y pan. El vendedor, que era muy simpático, nos regaló unas naranjas.\n\n" +
+		"¿Quieres venir mañana?"
+	native := "Yesterday we went to the market. We bought apples and bread. The seller was very nice. " +
+		"He gave us some oranges.\n\nDo you want to come tomorrow?"
+
+	af := AudioFile{}
+	result, err := af.GetAlignedLines(createTmpFile(t, "target", target), createTmpFile(t, "native", native), interfaces.ParseOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"Ayer fuimos al mercado.",
+		"Compramos manzanas y pan.",
+		"El vendedor, que era muy simpático, nos regaló unas naranjas.",
+		"¿Quieres venir mañana?",
+	}, result.Lines)
+	require.Equal(t, "The seller was very nice. He gave us some oranges.", result.ToPhrases[2].Text)
+	require.Equal(t, []interfaces.SentenceAlignment{
+		{Kind: "1:1", Text: "Ayer fuimos al mercado.", Translation: "Yesterday we went to the market."},
+		{Kind: "1:1", Text: "Compramos manzanas y pan.", Translation: "We bought apples and bread."},
+		{Kind: "1:2", Text: "El vendedor, que era muy simpático, nos regaló unas naranjas.", Translation: "The seller was very nice. He gave us some oranges."},
+		{Kind: "1:1", Text: "¿Quieres venir mañana?", Translation: "Do you want to come tomorrow?"},
+	}, result.Alignment)
+	require.Empty(t, result.Unaligned)
+
+	_, err = af.GetAlignedLines(createTmpFile(t, "target", target), createTmpFile(t, "native", native),
+		interfaces.ParseOptions{StartTime: time.Second})
+	require.ErrorContains(t, err, "start_time and end_time can only be used")
+}
@@ -0,0 +1,377 @@
+package audiofile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/klauspost/compress/zstd"
+	"html"
+	"io"
+	"maps"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "anki",
+		format:     Anki,
+		binary:     true,
+		extensions: []string{".apkg", ".colpkg"},
+		sniff:      sniffIf(isAnki, certainConfidence),
+		parse: parsedCues(func(f multipart.File, opts interfaces.ParseOptions, _ segmenter) ([]cue, error) {
+			return parseAnki(f, opts)
+		}),
+	})
+}
+
+// ankiCollections are the names of the collection database in an .apkg or .colpkg in the
+// order they are preferred. Newer versions of Anki compress collection.anki21b with zstd and
+// add a collection.anki2 that only asks the user to update Anki.
+var ankiCollections = []string{"collection.anki21b", "collection.anki21", "collection.anki2"}
+
+var (
+	// ankiSoundRegex matches the audio of a field like [sound:word.mp3]
+	ankiSoundRegex = regexp.MustCompile(`\[sound:[^\]]*\]`)
+	// ankiClozeRegex matches a cloze deletion like {{c1::answer}} or {{c1::answer::hint}}
+	ankiClozeRegex = regexp.MustCompile(`\{\{c\d+::(.*?)\}\}`)
+	// ankiBreakRegex matches the tags that separate the lines of a field
+	ankiBreakRegex = regexp.MustCompile(`(?i)<br\s*/?>|</(?:div|p|li)>`)
+	// ankiTagRegex matches any other html tag
+	ankiTagRegex = regexp.MustCompile(`<[^>]*>`)
+)
+
+// ankiCollection is the decks, note types and notes of an Anki collection
+type ankiCollection struct {
+	// decks are the full names of the decks like Spanish::Verbs by id
+	decks map[int64]string
+	// fields are the names of the fields of each note type by id in order
+	fields map[int64][]string
+	notes  []ankiNote
+	// cardDecks are the ids of the decks of the cards of each note
+	cardDecks map[int64][]int64
+}
+
+// ankiModelField is a field of a note type in the json of older versions of Anki
+type ankiModelField struct {
+	Name string `json:"name"`
+	Ord  int    `json:"ord"`
+}
+
+// ankiNote is a note with the values of its fields in the order of its note type
+type ankiNote struct {
+	id       int64
+	noteType int64
+	fields   []string
+}
+
+// isAnki checks if the content is an Anki deck or collection package
+func isAnki(content []byte) bool {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	for _, f := range reader.File {
+		if slices.Contains(ankiCollections, f.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAnki takes an Anki .apkg or .colpkg file and returns a cue for each note of the
+// chosen deck and its subdecks, or of every deck, with the front field as the text and the
+// back field as its translation if one is chosen. The html, cloze deletions and sounds are
+// removed from the fields and the notes are not split into shorter phrases so they stay
+// pairs.
+func parseAnki(f io.Reader, opts interfaces.ParseOptions) ([]cue, error) {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	collection, err := readAnkiCollection(content)
+	if err != nil {
+		return nil, err
+	}
+
+	decks, err := collection.selectDecks(opts.Deck)
+	if err != nil {
+		return nil, err
+	}
+
+	var cues []cue
+	found := false
+	for _, note := range collection.notes {
+		if decks != nil && !slices.ContainsFunc(collection.cardDecks[note.id], func(id int64) bool { return decks[id] }) {
+			continue
+		}
+		names := collection.fields[note.noteType]
+		front, ok := ankiFieldIndex(names, len(note.fields), opts.FrontField)
+		if !ok {
+			continue
+		}
+		back := -1
+		if opts.BackField != "" {
+			if back, ok = ankiFieldIndex(names, len(note.fields), opts.BackField); !ok {
+				continue
+			}
+		}
+		found = true
+
+		c := cue{text: ankiText(note.fields[front])}
+		if back >= 0 {
+			c.translation = ankiText(note.fields[back])
+			if c.translation == "" {
+				continue
+			}
+		}
+		if c.text != "" {
+			cues = append(cues, c)
+		}
+	}
+	if !found && (opts.FrontField != "" || opts.BackField != "") {
+		return nil, fmt.Errorf("fields %s not found, the fields are: %s",
+			strings.Trim(opts.FrontField+", "+opts.BackField, ", "), collection.fieldNames())
+	}
+
+	return cues, nil
+}
+
+// readAnkiCollection opens the collection database of the package and reads its decks,
+// note types and notes from the tables of newer versions of Anki or the json of the col
+// table of older versions
+func readAnkiCollection(content []byte) (ankiCollection, error) {
+	data, err := ankiDatabase(content)
+	if err != nil {
+		return ankiCollection{}, err
+	}
+	db, err := openSQLite(data)
+	if err != nil {
+		return ankiCollection{}, err
+	}
+
+	collection := ankiCollection{
+		decks:     make(map[int64]string),
+		fields:    make(map[int64][]string),
+		cardDecks: make(map[int64][]int64),
+	}
+	if db.hasTable("decks") && db.hasTable("fields") {
+		err = collection.readTables(db)
+	} else {
+		err = collection.readCol(db)
+	}
+	if err != nil {
+		return ankiCollection{}, err
+	}
+
+	cards, err := db.rows("cards")
+	if err != nil {
+		return ankiCollection{}, err
+	}
+	for _, card := range cards {
+		nid, _ := card["nid"].(int64)
+		did, _ := card["did"].(int64)
+		collection.cardDecks[nid] = append(collection.cardDecks[nid], did)
+	}
+
+	notes, err := db.rows("notes")
+	if err != nil {
+		return ankiCollection{}, err
+	}
+	for _, row := range notes {
+		id, _ := row["id"].(int64)
+		mid, _ := row["mid"].(int64)
+		flds, _ := row["flds"].(string)
+		collection.notes = append(collection.notes, ankiNote{id: id, noteType: mid, fields: strings.Split(flds, "\x1f")})
+	}
+	return collection, nil
+}
+
+// ankiDatabase returns the SQLite database of the package, decompressing it if it is from a
+// newer version of Anki
+func ankiDatabase(content []byte) ([]byte, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range ankiCollections {
+		for _, f := range reader.File {
+			if f.Name != name {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return nil, err
+			}
+			defer rc.Close()
+			var r io.Reader = rc
+			if strings.HasSuffix(name, "b") {
+				decoder, err := zstd.NewReader(rc)
+				if err != nil {
+					return nil, err
+				}
+				defer decoder.Close()
+				r = decoder
+			}
+			data, err := io.ReadAll(io.LimitReader(r, maxTotalSize+1))
+			if err != nil {
+				return nil, err
+			}
+			if len(data) > maxTotalSize {
+				return nil, errors.New("anki collection is too large")
+			}
+			return data, nil
+		}
+	}
+	return nil, errors.New("anki package has no collection")
+}
+
+// readTables reads the decks and note type fields of newer versions of Anki, which separate
+// the parts of deck names with \x1f
+func (c *ankiCollection) readTables(db *sqliteDB) error {
+	decks, err := db.rows("decks")
+	if err != nil {
+		return err
+	}
+	for _, deck := range decks {
+		id, _ := deck["id"].(int64)
+		name, _ := deck["name"].(string)
+		c.decks[id] = strings.ReplaceAll(name, "\x1f", "::")
+	}
+
+	fields, err := db.rows("fields")
+	if err != nil {
+		return err
+	}
+	slices.SortStableFunc(fields, func(a, b sqliteRow) int {
+		ordA, _ := a["ord"].(int64)
+		ordB, _ := b["ord"].(int64)
+		return int(ordA - ordB)
+	})
+	for _, field := range fields {
+		ntid, _ := field["ntid"].(int64)
+		name, _ := field["name"].(string)
+		c.fields[ntid] = append(c.fields[ntid], name)
+	}
+	return nil
+}
+
+// readCol reads the decks and note type fields from the json of the col table of older
+// versions of Anki
+func (c *ankiCollection) readCol(db *sqliteDB) error {
+	rows, err := db.rows("col")
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return errors.New("anki collection is empty")
+	}
+
+	var decks map[string]struct {
+		Name string `json:"name"`
+	}
+	if text, _ := rows[0]["decks"].(string); text != "" {
+		if err := json.Unmarshal([]byte(text), &decks); err != nil {
+			return err
+		}
+	}
+	for id, deck := range decks {
+		if n, err := strconv.ParseInt(id, 10, 64); err == nil {
+			c.decks[n] = deck.Name
+		}
+	}
+
+	var models map[string]struct {
+		Fields []ankiModelField `json:"flds"`
+	}
+	if text, _ := rows[0]["models"].(string); text != "" {
+		if err := json.Unmarshal([]byte(text), &models); err != nil {
+			return err
+		}
+	}
+	for id, model := range models {
+		n, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			continue
+		}
+		slices.SortStableFunc(model.Fields, func(a, b ankiModelField) int {
+			return a.Ord - b.Ord
+		})
+		for _, field := range model.Fields {
+			c.fields[n] = append(c.fields[n], field.Name)
+		}
+	}
+	return nil
+}
+
+// selectDecks returns the ids of the deck with the name, ignoring case, and its subdecks.
+// It returns nil if there is no name so every deck is used.
+func (c ankiCollection) selectDecks(name string) (map[int64]bool, error) {
+	if name == "" {
+		return nil, nil
+	}
+	selected := make(map[int64]bool)
+	for id, deck := range c.decks {
+		if strings.EqualFold(deck, name) || strings.HasPrefix(strings.ToLower(deck), strings.ToLower(name)+"::") {
+			selected[id] = true
+		}
+	}
+	if len(selected) == 0 {
+		names := slices.Sorted(maps.Values(c.decks))
+		return nil, fmt.Errorf("deck %s not found, the decks are: %s", name, strings.Join(names, ", "))
+	}
+	return selected, nil
+}
+
+// fieldNames returns the field names of every note type for the error of a field that is
+// not found
+func (c ankiCollection) fieldNames() string {
+	var names []string
+	for _, fields := range c.fields {
+		for _, field := range fields {
+			if !slices.Contains(names, field) {
+				names = append(names, field)
+			}
+		}
+	}
+	slices.Sort(names)
+	return strings.Join(names, ", ")
+}
+
+// ankiFieldIndex returns the index of the field chosen by its name, ignoring case, or its
+// number starting at 1. It returns the first field if no field is chosen and false if the
+// note does not have the field.
+func ankiFieldIndex(names []string, count int, choice string) (int, bool) {
+	if choice == "" {
+		return 0, count > 0
+	}
+	if n, err := strconv.Atoi(choice); err == nil {
+		return n - 1, n >= 1 && n <= count
+	}
+	for i, name := range names {
+		if strings.EqualFold(name, choice) {
+			return i, i < count
+		}
+	}
+	return -1, false
+}
+
+// ankiText removes the sounds, cloze deletions and html of a field
+func ankiText(field string) string {
+	field = ankiSoundRegex.ReplaceAllString(field, " ")
+	field = ankiClozeRegex.ReplaceAllStringFunc(field, func(cloze string) string {
+		answer := ankiClozeRegex.FindStringSubmatch(cloze)[1]
+		answer, _, _ = strings.Cut(answer, "::")
+		return answer
+	})
+	field = ankiBreakRegex.ReplaceAllString(field, " ")
+	field = ankiTagRegex.ReplaceAllString(field, "")
+	field = html.UnescapeString(field)
+	return strings.Join(strings.Fields(field), " ")
+}
@@ -0,0 +1,122 @@
+package audiofile
+
+import (
+	"os"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAnki(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		opts     interfaces.ParseOptions
+		expected []cue
+		count    int
+		err      string
+	}{
+		{
+			name: "deck with subdecks and back field",
+			file: "testdata/legacy.apkg",
+			opts: interfaces.ParseOptions{Deck: "spanish", BackField: "Back"},
+			expected: []cue{
+				{text: "Buenos días", translation: "Good morning"},
+				{text: "Yo hablo español.", translation: "I speak Spanish."},
+			},
+		},
+		{
+			name: "front only",
+			file: "testdata/legacy.apkg",
+			opts: interfaces.ParseOptions{Deck: "Spanish"},
+			expected: []cue{
+				{text: "Buenos días"},
+				{text: "Yo hablo español."},
+				{text: "¿Dónde está el baño?"},
+			},
+		},
+		{
+			name: "fields by number",
+			file: "testdata/legacy.apkg",
+			opts: interfaces.ParseOptions{Deck: "Spanish::Verbs", FrontField: "2", BackField: "1"},
+			expected: []cue{
+				{text: "I speak Spanish.", translation: "Yo hablo español."},
+			},
+		},
+		{
+			name:  "every deck",
+			file:  "testdata/legacy.apkg",
+			count: 204,
+		},
+		{
+			name: "unknown deck",
+			file: "testdata/legacy.apkg",
+			opts: interfaces.ParseOptions{Deck: "German"},
+			err:  "deck German not found, the decks are: Default, French, Spanish, Spanish::Verbs",
+		},
+		{
+			name: "unknown field",
+			file: "testdata/legacy.apkg",
+			opts: interfaces.ParseOptions{FrontField: "Sentence"},
+			err:  "fields Sentence not found, the fields are: Back, Extra, Front",
+		},
+		{
+			name: "newer collection",
+			file: "testdata/modern.colpkg",
+			opts: interfaces.ParseOptions{Deck: "Japanese", FrontField: "japanese", BackField: "English"},
+			expected: []cue{
+				{text: "おはようございます", translation: "Good morning"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			cues, err := parseAnki(f, tt.opts)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			if tt.expected != nil {
+				require.Equal(t, tt.expected, cues)
+			} else {
+				require.Len(t, cues, tt.count)
+			}
+		})
+	}
+}
+
+func TestAnkiText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		field    string
+		expected string
+	}{
+		{name: "html", field: "<div><b>Hola</b>&nbsp;mundo</div>", expected: "Hola mundo"},
+		{name: "line breaks", field: "Buenos<br>días<br/>a todos", expected: "Buenos días a todos"},
+		{name: "cloze", field: "{{c1::Yo}} {{c2::hablo::verb}} español", expected: "Yo hablo español"},
+		{name: "sound", field: "[sound:hola.mp3]Hola", expected: "Hola"},
+		{name: "entities", field: "Tom &amp; Jerry &lt;3", expected: "Tom & Jerry <3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, ankiText(tt.field))
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package audiofile
+
+import (
+	"bufio"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "ass",
+		format:     Ass,
+		mimeTypes:  []string{"text/x-ssa", "text/x-ass"},
+		extensions: []string{".ass", ".ssa"},
+		sniff:      sniffFirstLines(assFormatCheck, likelyConfidence),
+		parse: parsedCues(func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseAss(f, opts.SkipStyles, seg), nil
+		}),
+	})
+}
+
+// assDefaultFormat is the column order of the [Events] section used when a file does not
+// have a Format line
+var assDefaultFormat = []string{"layer", "start", "end", "style", "name", "marginl", "marginr", "marginv", "effect", "text"}
+
+// assDrawingRegex matches drawing mode ({\p1} to {\p0} or the end of the line) whose
+// text is vector drawing commands and not dialogue
+var assDrawingRegex = regexp.MustCompile(`\{[^}]*\\p[1-9][^}]*}.*?(\{[^}]*\\p0[^}]*}|$)`)
+
+// assLineBreakReplacer replaces the hard (\N), soft (\n) line breaks and hard spaces (\h)
+var assLineBreakReplacer = strings.NewReplacer(`\N`, " ", `\n`, " ", `\h`, " ")
+
+// parseAss takes an Advanced SubStation (.ass) or SubStation Alpha (.ssa) file and parses
+// the Text field of the Dialogue lines in the [Events] section into phrases with the Start
+// and End of the line. Dialogue lines whose Style is in skipStyles are ignored.
+func parseAss(f io.Reader, skipStyles []string, seg segmenter) []cue {
+	var phrases []cue
+	format := assDefaultFormat
+	inEvents := false
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line == "" || line[0] == ';' {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			inEvents = strings.EqualFold(line, "[Events]")
+			continue
+		}
+		if !inEvents {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "format":
+			format = assFormat(value)
+		case "dialogue":
+			fields := strings.SplitN(strings.TrimSpace(value), ",", len(format))
+			if len(fields) != len(format) {
+				continue
+			}
+			if style := assField(format, fields, "style"); assSkipStyle(style, skipStyles) {
+				continue
+			}
+			c := cue{text: assText(assField(format, fields, "text"), seg.cleanup)}
+			c.start, _ = util.ParseTimestamp(assField(format, fields, "start"))
+			c.end, _ = util.ParseTimestamp(assField(format, fields, "end"))
+
+			phrases = append(phrases, cuePhrases(c, seg)...)
+		}
+	}
+
+	return phrases
+}
+
+// assFormat returns the lower case column names of a Format line
+func assFormat(value string) []string {
+	var format []string
+	for _, column := range strings.Split(value, ",") {
+		format = append(format, strings.ToLower(strings.TrimSpace(column)))
+	}
+	return format
+}
+
+// assField returns the value of the column name from the split Dialogue fields
+func assField(format, fields []string, name string) string {
+	i := slices.Index(format, name)
+	if i == -1 {
+		return ""
+	}
+	return strings.TrimSpace(fields[i])
+}
+
+// assSkipStyle checks if the style is one of the styles that should be skipped
+func assSkipStyle(style string, skipStyles []string) bool {
+	return slices.ContainsFunc(skipStyles, func(s string) bool {
+		return strings.EqualFold(strings.TrimSpace(s), style)
+	})
+}
+
+// assText removes drawings, override blocks like {\an8\i1} and line breaks from the
+// Text field of a Dialogue line
+func assText(text string, c *cleanup) string {
+	text = assDrawingRegex.ReplaceAllString(text, "")
+	text = assLineBreakReplacer.Replace(text)
+
+	return replaceFmt(text, c)
+}
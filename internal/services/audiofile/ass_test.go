@@ -0,0 +1,89 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseAss tests the parseAss function
+func TestParseAss(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	header := `[Script Info]
+Title: Test
+ScriptType: v4.00+
+
+[V4+ Styles]
+Format: Name, Fontname, Fontsize, PrimaryColour, SecondaryColour, OutlineColour, BackColour, Bold, Italic, Underline, StrikeOut, ScaleX, ScaleY, Spacing, Angle, BorderStyle, Outline, Shadow, Alignment, MarginL, MarginR, MarginV, Encoding
+Style: Default,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,10,1
+Style: Signs,Arial,20,&H00FFFFFF,&H000000FF,&H00000000,&H00000000,0,0,0,0,100,100,0,0,1,2,2,2,10,10,10,1
+
+`
+	tests := []struct {
+		name       string
+		content    string
+		skipStyles []string
+		expected   []string
+	}{
+		{
+			name: "Basic events",
+			content: header + `[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,Where are you going, my friend?
+Comment: 0,0:00:02.00,0:00:04.00,Default,,0,0,0,,This is a comment and not dialogue.
+Dialogue: 0,0:00:05.00,0:00:09.00,Signs,,0,0,0,,The Tokyo Metro Station Entrance`,
+			expected: []string{"Where are you going, my friend?", "The Tokyo Metro Station Entrance"},
+		},
+		{
+			name: "Override blocks and line breaks",
+			content: header + `[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:04.00,Default,Yuki,0,0,0,,{\an8\i1}I told you already,{\i0}\Nwe leave tomorrow.
+Dialogue: 0,0:00:05.00,0:00:09.00,Default,,0,0,0,,{\p1}m 0 0 l 100 0 100 100 0 100{\p0}The drawing should be removed here.`,
+			expected: []string{"I told you already, we leave tomorrow.", "The drawing should be removed here."},
+		},
+		{
+			name: "Skip styles",
+			content: header + `[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,Where are you going, my friend?
+Dialogue: 0,0:00:05.00,0:00:09.00,Signs,,0,0,0,,The Tokyo Metro Station Entrance
+Dialogue: 0,0:00:05.00,0:00:09.00,Karaoke,,0,0,0,,{\k20}La {\k30}la {\k20}la {\k40}la`,
+			skipStyles: []string{"signs", "Karaoke"},
+			expected:   []string{"Where are you going, my friend?"},
+		},
+		{
+			name: "SSA v4 column order",
+			content: `[Script Info]
+ScriptType: v4.00
+
+[Events]
+Format: Marked, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: Marked=0,0:00:01.00,0:00:04.00,*Default,NTP,0000,0000,0000,,This subtitle is from an old file.`,
+			expected: []string{"This subtitle is from an old file."},
+		},
+		{
+			name: "Missing format line uses the default columns",
+			content: `[Events]
+Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,There is no format line in this file.`,
+			expected: []string{"There is no format line in this file."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseAss(strings.NewReader(tt.content), tt.skipStyles, segmenter{})
+
+			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
+			for i, phrase := range tt.expected {
+				if i < len(result) {
+					assert.Equal(t, phrase, strings.TrimSpace(result[i].text), "Result should match expected phrase")
+				}
+			}
+		})
+	}
+}
@@ -11,7 +11,6 @@ import (
 	"strconv"
 	"talkliketv.com/tltv/internal/interfaces"
 	audio "talkliketv.com/tltv/internal/services/pattern"
-	"talkliketv.com/tltv/internal/util"
 )
 
 // AudioPauseFilePath is a map to the silence mp3's of the embedded FS in
@@ -28,8 +27,13 @@ var AudioPauseFilePath = map[int]string{
 	10: "silence/10SecSilence.mp3",
 }
 
+// patternChunkSize is how many phrases of the pattern are spoken in each mp3, which is about
+// 15 minutes of audio
+const patternChunkSize = 125
+
 type AudioFileX interface {
-	GetLines(multipart.File) ([]string, error)
+	GetLines(multipart.File, interfaces.ParseOptions) (interfaces.ParseResult, error)
+	GetAlignedLines(multipart.File, multipart.File, interfaces.ParseOptions) (interfaces.ParseResult, error)
 	CreateMp3Zip(interfaces.Title, string) (*os.File, error)
 	BuildAudioInputFiles(interfaces.Title, string, string, string, string) error
 	CreatePhrasesZip(iter.Seq[[]string], string, string) (*os.File, error)
@@ -57,30 +61,53 @@ func (r *RealCmdRunner) CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
 	return cmd.CombinedOutput()
 }
 
-// GetLines determines if the uploaded file is an srt, in paragraph form, or one phrase per
-// line and then parses the file accordingly, returning a string slice containing the
-// phrases to be translated
-func (af *AudioFile) GetLines(f multipart.File) ([]string, error) {
-	fileType, err := DetectTextFormat(f)
+// GetLines decodes the uploaded file to UTF-8 and parses it with the registered parser of its
+// format, which is detected unless opts.Format names it
+func (af *AudioFile) GetLines(f multipart.File, opts interfaces.ParseOptions) (interfaces.ParseResult, error) {
+	fileType, f, enc, err := DetectFormat(f, opts)
 	if err != nil {
-		return nil, err
+		return interfaces.ParseResult{}, err
 	}
 
 	// Reset file pointer again
 	if _, err := f.Seek(0, 0); err != nil {
-		return nil, err
+		return interfaces.ParseResult{}, err
 	}
 
-	lines, err := parseFileContent(f, fileType)
-	if err != nil || len(lines) == 0 {
-		return nil, errors.New("unable to parse file")
+	c := newCleanup(opts.Cleanup)
+	// the chapters of an epub are listed so they can be chosen and the text of a pdf is
+	// returned so it can be checked before audio is created
+	result, err := parseFile(f, fileType, opts, c)
+	if err != nil {
+		return interfaces.ParseResult{}, err
+	}
+	cues := result.cues
+	if len(cues) == 0 {
+		if opts.HasTimeRange() {
+			return interfaces.ParseResult{}, errors.New("no subtitles between start_time and end_time")
+		}
+		return interfaces.ParseResult{}, errors.New("unable to parse file")
 	}
 
-	return util.RemoveLongStr(util.RemoveDuplicateStr(lines)), nil
+	phrases, translations := uniquePhrases(cues, maxPhraseCharacters(opts.Policy), newDedupe(opts), c)
+	p, _ := parserOf(fileType)
+	return interfaces.ParseResult{
+		Format:    p.Name(),
+		Lines:     phraseTexts(phrases),
+		Phrases:   phrases,
+		ToPhrases: translations,
+		Encoding:  enc,
+		Cleanup:   c.report(),
+		Chapters:  result.chapters,
+		Text:      result.text,
+		Sections:  phraseSections(cues, phrases),
+		Discarded: c.discards(),
+	}, nil
 }
 
 // BuildAudioInputFiles creates a file with the filepaths of the mp3's used to construct
-// the output files with ffmpeg in CreateMp3Zip
+// the output files with ffmpeg in CreateMp3Zip. A title with sections has one file for each
+// section.
 func (af *AudioFile) BuildAudioInputFiles(t interfaces.Title, pause, fromLang, toLang, tmpDir string) error {
 	maxP := len(t.TitlePhrases) - 1
 
@@ -88,8 +115,11 @@ func (af *AudioFile) BuildAudioInputFiles(t interfaces.Title, pause, fromLang, t
 	if pattern == nil {
 		return errors.New("error getting pattern from audio file")
 	}
+	if len(t.Sections) > 0 {
+		return buildSectionInputFiles(t, pattern, pause, fromLang, toLang, tmpDir)
+	}
 	// create chunks of []Audio pattern to split up audio files into ~15 minute lengths
-	chunkedSlice := slices.Chunk(pattern, 125)
+	chunkedSlice := slices.Chunk(pattern, patternChunkSize)
 	count := 1
 	last := false
 	for chunk := range chunkedSlice {
@@ -119,7 +149,7 @@ func (af *AudioFile) BuildAudioInputFiles(t interfaces.Title, pause, fromLang, t
 			// else if: skip if phraseId does not exist (is greater than maxP)
 			// else if: native language then we add filepath for from language audio mp3
 			// else: add audio filepath for to language mp3
-			phraseId, err := strconv.Atoi(phraseIdKey)
+			phraseId, err := patternPhraseID(phraseIdKey)
 			if err != nil {
 				return err
 			}
@@ -127,7 +157,7 @@ func (af *AudioFile) BuildAudioInputFiles(t interfaces.Title, pause, fromLang, t
 				last = true
 			}
 
-			if err = writeStringToFile(native, f, fromLang, toLang, phraseIdKey, pause); err != nil {
+			if err = writeStringToFile(native, f, fromLang, toLang, strconv.Itoa(phraseId), pause); err != nil {
 				return err
 			}
 		}
@@ -148,6 +178,83 @@ func (af *AudioFile) BuildAudioInputFiles(t interfaces.Title, pause, fromLang, t
 	return nil
 }
 
+// buildSectionInputFiles creates an input file for each section of the title that repeats
+// only the phrases of the section. The pattern is used as if the section were its own title
+// and ends with the chunk of the pattern its last phrase is first spoken in.
+func buildSectionInputFiles(t interfaces.Title, pattern []uint16, pause, fromLang, toLang, tmpDir string) error {
+	patternPhrases := 0
+	for _, audioTok := range pattern {
+		patternPhrases = max(patternPhrases, int(audioTok)/10)
+	}
+	for _, section := range t.Sections {
+		if section.Phrases > patternPhrases {
+			return fmt.Errorf("section %s has %d phrases but the pattern can only repeat %d", section.Name, section.Phrases, patternPhrases)
+		}
+	}
+
+	first := 0
+	for i, section := range t.Sections {
+		f, err := os.Create(tmpDir + fmt.Sprintf("%s-input-%s", t.Name, sectionNumber(i+1, len(t.Sections))))
+		if err != nil {
+			return err
+		}
+
+		// start audiofile with silence
+		if _, err = f.WriteString(fmt.Sprintf("file '%s'\n", pause)); err != nil {
+			return err
+		}
+		last := false
+		for j, audioTok := range pattern {
+			if last && j%patternChunkSize == 0 {
+				break
+			}
+			phraseIdKey, nativeLang, err := SplitShortString(strconv.Itoa(int(audioTok)))
+			if err != nil {
+				return err
+			}
+			phraseId, err := patternPhraseID(phraseIdKey)
+			if err != nil {
+				return err
+			}
+			if phraseId >= section.Phrases {
+				continue
+			}
+			if phraseId == section.Phrases-1 {
+				last = true
+			}
+			id := strconv.Itoa(first + phraseId)
+			if err = writeStringToFile(nativeLang == "1", f, fromLang, toLang, id, pause); err != nil {
+				return err
+			}
+		}
+		// end audiofile with silence
+		if _, err = f.WriteString(fmt.Sprintf("file '%s'\n", pause)); err != nil {
+			return err
+		}
+		if err = f.Close(); err != nil {
+			return err
+		}
+		first += section.Phrases
+	}
+	return nil
+}
+
+// patternPhraseID returns the ID of the phrase a phrase id of the pattern is spoken from. The
+// phrase ids of the pattern start at 1 and the IDs of the phrases at 0.
+func patternPhraseID(phraseIdKey string) (int, error) {
+	phraseId, err := strconv.Atoi(phraseIdKey)
+	if err != nil {
+		return 0, err
+	}
+	return phraseId - 1, nil
+}
+
+// sectionNumber returns the number of a section padded to the width of the number of
+// sections, and at least two digits, so the files of the sections are sorted in order
+func sectionNumber(n, sections int) string {
+	return fmt.Sprintf("%0*d", max(2, len(strconv.Itoa(sections))), n)
+}
+
 func writeStringToFile(native bool, f *os.File, fromLang, toLang, phraseIdKey, pause string) error {
 	audioString := ""
 	if native {
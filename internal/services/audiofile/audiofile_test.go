@@ -2,13 +2,18 @@ package audiofile
 
 import (
 	"flag"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	xunicode "golang.org/x/text/encoding/unicode"
 	"os"
+	"strconv"
 	"talkliketv.com/tltv/internal/interfaces"
 	"talkliketv.com/tltv/internal/mock"
 	"talkliketv.com/tltv/internal/testflags"
 	"talkliketv.com/tltv/internal/testutil"
 	"talkliketv.com/tltv/internal/util"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -20,7 +25,8 @@ type audioFileTestCase struct {
 	values       map[string]any
 	stringsSlice []string
 	buildFile    func(*testing.T) *os.File
-	checkLines   func([]string, error)
+	checkLines   func(interfaces.ParseResult, error)
+	opts         interfaces.ParseOptions
 	buildStubs   func(*mock.MockcmdRunnerX)
 	createTitle  func(*testing.T) (interfaces.Title, string)
 	checkReturn  func(*testing.T, *os.File, error)
@@ -49,9 +55,65 @@ func TestGetLines(t *testing.T) {
 					"noerror",
 					"This is the first sentence.\nThis is the second sentence.\nThis is the third sentence.\nThis is the fourth sentence.\nThis is the fifth sentence.\n")
 			},
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.NoError(t, err)
-				require.Equal(t, len(lines), 5)
+				require.Equal(t, len(result.Lines), 5)
+			},
+		},
+		{
+			name: "shift_jis",
+			buildFile: func(t *testing.T) *os.File {
+				content, err := japanese.ShiftJIS.NewEncoder().String("今日はいい天気ですね。\n明日は雨が降るそうです。\n駅まで歩いて行きます。\n一緒に映画を見ましょう。\n")
+				require.NoError(t, err)
+				return createTmpFile(t, "shiftjis", content)
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "shift_jis", result.Encoding)
+				require.Equal(t, []string{"今日はいい天気ですね。", "明日は雨が降るそうです。", "駅まで歩いて行きます。", "一緒に映画を見ましょう。"}, result.Lines)
+			},
+		},
+		{
+			name: "utf-16le with bom",
+			buildFile: func(t *testing.T) *os.File {
+				encoder := xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM).NewEncoder()
+				content, err := encoder.String("This is the first sentence.\nThis is the second sentence.\nThis is the third sentence.\nThis is the fourth sentence.\nThis is the fifth sentence.\n")
+				require.NoError(t, err)
+				return createTmpFile(t, "utf16", content)
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "utf-16le", result.Encoding)
+				require.Equal(t, "This is the first sentence.", result.Lines[0])
+				require.Equal(t, len(result.Lines), 5)
+			},
+		},
+		{
+			name: "encoding override",
+			buildFile: func(t *testing.T) *os.File {
+				content, err := charmap.Windows1252.NewEncoder().String("C'était vraiment très agréable.\nJe suis allé au marché.\nNous avons mangé ensemble.\nÇa coûte cher à Noël.\n")
+				require.NoError(t, err)
+				return createTmpFile(t, "latin1", content)
+			},
+			opts: interfaces.ParseOptions{Encoding: "latin1"},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "windows-1252", result.Encoding)
+				require.Equal(t, []string{"C'était vraiment très agréable.", "Je suis allé au marché.", "Nous avons mangé ensemble.", "Ça coûte cher à Noël."}, result.Lines)
+			},
+		},
+		{
+			name: "phrase policy",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(
+					t,
+					"policy",
+					"Hi there.\nThis is the second sentence.\nThis sentence is a lot longer than the others are.\nGood morning.\nThis is the fifth sentence.\n")
+			},
+			opts: interfaces.ParseOptions{Policy: interfaces.PhrasePolicy{MinWords: 2, MaxChars: 30}},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"Hi there.", "This is the second sentence.", "Good morning.", "This is the fifth sentence."}, result.Lines)
 			},
 		},
 		{
@@ -79,9 +141,272 @@ el cine, la política y los deportes`
 					"parsesrt",
 					srtString)
 			},
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{
+					"A continuación, se muestra una presentación especial de Fox.",
+					"En vivo desde el Teatro DolbyMucinex en Hollywood, California.",
+					"Las mayores estrellas del teatro, el cine,",
+					"la política y los deportes",
+				}, result.Lines)
+			},
+		},
+		{
+			name: "srt time range",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(
+					t,
+					"timerange",
+					`1
+00:00:01,418 --> 00:00:04,170
+A continuación, se muestra una presentación especial de Fox.
+
+2
+00:00:04,170 --> 00:00:09,342
+En vivo desde el Teatro Dolby en Hollywood, California.
+
+3
+00:12:30,000 --> 00:12:33,500
+Las mayores estrellas del teatro y el cine.`)
+			},
+			opts: interfaces.ParseOptions{StartTime: 4 * time.Second, EndTime: 15 * time.Minute},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []interfaces.Phrase{
+					{ID: 0, Text: "En vivo desde el Teatro Dolby en Hollywood, California.", Start: 4170 * time.Millisecond, End: 9342 * time.Millisecond},
+					{ID: 1, Text: "Las mayores estrellas del teatro y el cine.", Start: 12*time.Minute + 30*time.Second, End: 12*time.Minute + 33500*time.Millisecond},
+				}, result.Phrases)
+				require.Equal(t, []string{"En vivo desde el Teatro Dolby en Hollywood, California.", "Las mayores estrellas del teatro y el cine."}, result.Lines)
+			},
+		},
+		{
+			name: "srt time range without subtitles",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(
+					t,
+					"emptyrange",
+					`1
+00:00:01,418 --> 00:00:04,170
+A continuación, se muestra una presentación especial de Fox.
+
+2
+00:00:04,170 --> 00:00:09,342
+En vivo desde el Teatro Dolby en Hollywood, California.
+
+3
+00:12:30,000 --> 00:12:33,500
+Las mayores estrellas del teatro y el cine.`)
+			},
+			opts: interfaces.ParseOptions{StartTime: 20 * time.Minute},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.EqualError(t, err, "no subtitles between start_time and end_time")
+			},
+		},
+		{
+			name: "time range of untimed file",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(
+					t,
+					"untimed",
+					"This is the first sentence.\nThis is the second sentence.\n")
+			},
+			opts: interfaces.ParseOptions{EndTime: time.Minute},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.ErrorContains(t, err, "start_time and end_time can only be used with")
+			},
+		},
+		{
+			name: "srt cleanup",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(
+					t,
+					"cleanup",
+					`1
+00:00:01,000 --> 00:00:04,000
+JOHN: Where are you going tonight?
+
+2
+00:00:05,000 --> 00:00:08,000
+(DOOR SLAMS)
+
+3
+00:00:09,000 --> 00:00:12,000
+♪ Never gonna give you up ♪
+
+4
+00:00:13,000 --> 00:00:16,000
+- MARY: I am going to the movies.
+- JOHN: Can I come with you?`)
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{
+					"Where are you going tonight?",
+					"I am going to the movies.",
+					"Can I come with you?",
+				}, result.Lines)
+				require.Equal(t, []interfaces.CleanupCount{
+					{Rule: "music_lines", Count: 1},
+					{Rule: "sound_cues", Count: 1},
+					{Rule: "speaker_labels", Count: 2},
+					{Rule: "dialogue_dashes", Count: 1},
+				}, result.Cleanup)
+			},
+		},
+		{
+			name: "bilingual tsv",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(
+					t,
+					"bilingual",
+					"Good morning.\tBuenos días.\nHow are you today?\t¿Cómo estás hoy?\nGood morning.\tBuen día.\n")
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"Good morning.", "How are you today?"}, result.Lines)
+				require.Equal(t, []interfaces.Phrase{
+					{ID: 0, Text: "Buenos días."},
+					{ID: 1, Text: "¿Cómo estás hoy?"},
+				}, result.ToPhrases)
+				require.Equal(t, []string{"Good morning.\tBuenos días.", "How are you today?\t¿Cómo estás hoy?"}, result.FileLines())
+			},
+		},
+		{
+			name: "anki package",
+			buildFile: func(t *testing.T) *os.File {
+				f, err := os.Open("testdata/legacy.apkg")
+				require.NoError(t, err)
+				t.Cleanup(func() { f.Close() })
+				return f
+			},
+			opts: interfaces.ParseOptions{Deck: "Spanish", BackField: "Back"},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, "utf-8", result.Encoding)
+				require.Equal(t, []string{"Buenos días", "Yo hablo español."}, result.Lines)
+				require.Equal(t, []interfaces.Phrase{
+					{ID: 0, Text: "Good morning"},
+					{ID: 1, Text: "I speak Spanish."},
+				}, result.ToPhrases)
+			},
+		},
+		{
+			name: "epub",
+			buildFile: func(t *testing.T) *os.File {
+				f, err := os.Open("testdata/book.epub")
+				require.NoError(t, err)
+				t.Cleanup(func() { f.Close() })
+				return f
+			},
+			opts: interfaces.ParseOptions{Chapters: []int{2}},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{
+					"A la mañana siguiente fuimos al mercado con mi hermana.",
+					"漢字 no es español pero está aquí.",
+				}, result.Lines)
+				require.Len(t, result.Chapters, 2)
+			},
+		},
+		{
+			name: "pdf",
+			buildFile: func(t *testing.T) *os.File {
+				f, err := os.Open("testdata/worksheet.pdf")
+				require.NoError(t, err)
+				t.Cleanup(func() { f.Close() })
+				return f
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Len(t, result.Lines, 6)
+				require.Equal(t, "La casa de mi abuela está cerca del río.", result.Lines[0])
+				require.Contains(t, result.Text, "caminamos juntos al mercado")
+				require.NotContains(t, result.Text, "Unidad 3")
+			},
+		},
+		{
+			name: "html",
+			buildFile: func(t *testing.T) *os.File {
+				f, err := os.Open("testdata/article.html")
+				require.NoError(t, err)
+				t.Cleanup(func() { f.Close() })
+				return f
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Contains(t, result.Lines, "El mercado de los sábados vuelve a la plaza mayor después de tres años.")
+				require.NotContains(t, result.Lines, "Compre ahora con un descuento del cincuenta por ciento, solo hoy.")
+			},
+		},
+		{
+			name: "lrc chorus",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(t, "lyrics.lrc", "[ti:Song]\n[00:01.00][00:09.00]This is the chorus of the song.\n[00:05.00]This is the verse of the song.\n")
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				// the chorus is only kept the first time it is sung
+				require.Equal(t, []string{"This is the chorus of the song.", "This is the verse of the song."}, result.Lines)
+				require.Equal(t, 5*time.Second, result.Phrases[0].End)
+			},
+		},
+		{
+			name: "kindle clippings",
+			buildFile: func(t *testing.T) *os.File {
+				f, err := os.Open("testdata/clippings.txt")
+				require.NoError(t, err)
+				t.Cleanup(func() { f.Close() })
+				return f
+			},
+			opts: interfaces.ParseOptions{Book: "principito"},
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.NoError(t, err)
-				require.Equal(t, len(lines), 5)
+				require.Equal(t, []string{"Lo esencial es invisible a los ojos.", "domesticar"}, result.Lines)
+			},
+		},
+		{
+			name: "youtube json3 time range",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(t, "transcript.json", `{"events":[{"tStartMs":1000,"dDurationMs":2000,"segs":[{"utf8":"This is the first sentence."}]},`+
+					`{"tStartMs":5000,"dDurationMs":2000,"segs":[{"utf8":"This is the second"},{"utf8":" sentence."}]}]}`)
+			},
+			opts: interfaces.ParseOptions{StartTime: 4 * time.Second},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"This is the second sentence."}, result.Lines)
+				require.Equal(t, 5*time.Second, result.Phrases[0].Start)
+			},
+		},
+		{
+			name: "markdown sections",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(t, "course.md", "# Greetings\n\n- Good morning to you.\n- How are you today?\n\n# Goodbyes\n\n- See you again tomorrow.\n- Good morning to you.\n")
+			},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.NoError(t, err)
+				require.Equal(t, []string{"Good morning to you.", "How are you today?", "See you again tomorrow."}, result.Lines)
+				// the repeated phrase is only in the section it is first in
+				require.Equal(t, []interfaces.Section{{Name: "Greetings", Phrases: 2}, {Name: "Goodbyes", Phrases: 1}}, result.Sections)
+			},
+		},
+		{
+			name: "book of a text file",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(t, "book", "This is the first sentence of the text.")
+			},
+			opts: interfaces.ParseOptions{Book: "principito"},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.EqualError(t, err, "book, from_date and to_date can only be used with kindle clippings")
+			},
+		},
+		{
+			name: "chapters of a text file",
+			buildFile: func(t *testing.T) *os.File {
+				return createTmpFile(t, "chapters", "This is the first sentence of the text.")
+			},
+			opts: interfaces.ParseOptions{Chapters: []int{1}},
+			checkLines: func(result interfaces.ParseResult, err error) {
+				require.EqualError(t, err, "chapters can only be used with epub files")
 			},
 		},
 		{
@@ -92,9 +417,9 @@ el cine, la política y los deportes`
 					"noerror",
 					"This is the first sentence.\n\n\n\n\n\n\nThis is the second sentence.\nThis is the third sentence.\nThis is the fourth sentence.\nThis is the fifth sentence.\n")
 			},
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.NoError(t, err)
-				require.Equal(t, len(lines), 5)
+				require.Equal(t, len(result.Lines), 5)
 			},
 		},
 		{
@@ -105,9 +430,9 @@ el cine, la política y los deportes`
 					"noerror",
 					"This is the first one. This is the second one. This is the third one. this is the fourth one.\nThis is the fifth")
 			},
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.NoError(t, err)
-				require.Equal(t, len(lines), 5)
+				require.Equal(t, len(result.Lines), 5)
 			},
 		},
 		{
@@ -118,7 +443,7 @@ el cine, la política y los deportes`
 					"noerror",
 					"This is the. This is. This is the. this is the.\nThis is the")
 			},
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.Errorf(t, err, "unable to parsefile file")
 			},
 		},
@@ -130,7 +455,7 @@ el cine, la política y los deportes`
 					"noerror",
 					"")
 			},
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.Errorf(t, err, "unable to parsefile file")
 			},
 		},
@@ -140,8 +465,8 @@ el cine, la política y los deportes`
 		t.Run(tc.name, func(t *testing.T) {
 			file := tc.buildFile(t)
 			audioFile := AudioFile{}
-			stringsSlice, err := audioFile.GetLines(file)
-			tc.checkLines(stringsSlice, err)
+			result, err := audioFile.GetLines(file, tc.opts)
+			tc.checkLines(result, err)
 		})
 	}
 }
@@ -168,9 +493,9 @@ func TestBuildAudioInputFiles(t *testing.T) {
 	testCases := []audioFileTestCase{
 		{
 			name: "No error",
-			checkLines: func(lines []string, err error) {
+			checkLines: func(result interfaces.ParseResult, err error) {
 				require.NoError(t, err)
-				require.Equal(t, len(lines), 2)
+				require.Equal(t, len(result.Lines), 2)
 			},
 		},
 	}
@@ -190,10 +515,58 @@ func TestBuildAudioInputFiles(t *testing.T) {
 			require.NoError(t, err)
 			filePath := tmpDir + title.Name + "-input-01"
 			require.FileExists(t, filePath)
+			// the phrase ids of the pattern start at 1 and the IDs of the phrases at 0
+			input, err := os.ReadFile(filePath)
+			require.NoError(t, err)
+			require.Contains(t, string(input), "file '"+fromPath+"0'\n")
+			require.Contains(t, string(input), "file '"+toPath+"0'\n")
 		})
 	}
 }
 
+func TestBuildAudioInputFilesSections(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	t.Parallel()
+
+	title := testutil.RandomTitle()
+	title.TitlePhrases = []interfaces.Phrase{{ID: 0, Text: "a"}, {ID: 1, Text: "b"}, {ID: 2, Text: "c"}}
+	title.Sections = []interfaces.Section{{Name: "Greetings", Phrases: 2}, {Name: "Goodbyes", Phrases: 1}}
+	tmpDir := testutil.AudioBasePath + "TestBuildAudioInputFilesSections/" + title.Name + "/"
+	require.NoError(t, os.MkdirAll(tmpDir, 0777))
+
+	audioFile := AudioFile{}
+	err := audioFile.BuildAudioInputFiles(title, "pause", "from/", "to/", tmpDir)
+	require.NoError(t, err)
+
+	// each section only repeats its own phrases
+	greetings, err := os.ReadFile(tmpDir + title.Name + "-input-01")
+	require.NoError(t, err)
+	require.Contains(t, string(greetings), "file 'from/0'\n")
+	require.Contains(t, string(greetings), "file 'to/1'\n")
+	require.NotContains(t, string(greetings), "/2'")
+	goodbyes, err := os.ReadFile(tmpDir + title.Name + "-input-02")
+	require.NoError(t, err)
+	require.Contains(t, string(goodbyes), "file 'from/2'\n")
+	require.NotContains(t, string(goodbyes), "/0'")
+	require.NoFileExists(t, tmpDir+title.Name+"-input-03")
+
+	// the numbers of more than 99 sections are padded so their files are sorted in order
+	title.Sections = make([]interfaces.Section, 100)
+	for i := range title.Sections {
+		title.Sections[i] = interfaces.Section{Name: strconv.Itoa(i + 1), Phrases: 1}
+	}
+	require.NoError(t, audioFile.BuildAudioInputFiles(title, "pause", "from/", "to/", tmpDir))
+	require.FileExists(t, tmpDir+title.Name+"-input-001")
+	require.FileExists(t, tmpDir+title.Name+"-input-100")
+
+	// a section with more phrases than the pattern can repeat is rejected
+	title.Sections = []interfaces.Section{{Name: "Everything", Phrases: 1000}}
+	err = audioFile.BuildAudioInputFiles(title, "pause", "from/", "to/", tmpDir)
+	require.ErrorContains(t, err, "section Everything has 1000 phrases but the pattern can only repeat")
+}
+
 func TestSplitBigPhrases(t *testing.T) {
 	if util.Test != "unit" && !testing.Short() {
 		t.Skip("skipping unit test")
@@ -258,8 +631,9 @@ func TestSplitBigPhrases(t *testing.T) {
 
 	for name, tc := range tests {
 		t.Run(name, func(t *testing.T) {
-			got := splitLongPhrases(tc.line)
+			got := splitLongPhrases(tc.line, segmenter{})
 			assert.NotNil(t, got)
+			assert.Len(t, got, len(tc.want))
 			for i := range got {
 				if got[i] != tc.want[i] {
 					t.Fatalf("different result: got %v, expected %v", got, tc.want)
@@ -300,3 +674,56 @@ func createFile(t *testing.T, filename, fileString string) *os.File {
 
 	return file
 }
+
+func TestGetLinesDiscarded(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	srt := `1
+00:00:01,000 --> 00:00:02,000
+[MUSIC PLAYING]
+
+2
+00:00:03,000 --> 00:00:04,000
+(LAUGHS)
+
+3
+00:00:05,000 --> 00:00:06,000
+Where are you going tonight my friend?
+
+4
+00:00:07,000 --> 00:00:08,000
+Where are you going tonight my friend?
+
+5
+00:00:09,000 --> 00:00:10,000
+Okay, see you.
+
+6
+00:00:11,000 --> 00:00:12,000
+We should have left the house much earlier today
+
+7
+00:00:13,000 --> 00:00:14,000
+<font color="#ffff00">Happy birthday to you</font>
+
+8
+00:00:15,000 --> 00:00:16,000
+10 people came to the party.
+`
+	af := AudioFile{}
+	opts := interfaces.ParseOptions{Policy: interfaces.PhrasePolicy{MaxChars: 40}}
+	result, err := af.GetLines(createTmpFile(t, "discarded.srt", srt), opts)
+	require.NoError(t, err)
+	require.Equal(t, "srt", result.Format)
+	require.Equal(t, []string{"Where are you going tonight my friend?", "10 people came to the party."}, result.Lines)
+	require.Equal(t, []interfaces.DiscardedLine{
+		{Text: "[MUSIC PLAYING]", Reason: interfaces.DiscardMarkupOnly},
+		{Text: "(LAUGHS)", Reason: interfaces.DiscardFiltered, Rule: "sound_cues"},
+		{Text: "Okay, see you.", Reason: interfaces.DiscardTooShort},
+		{Text: `<font color="#ffff00">Happy birthday to you</font>`, Reason: interfaces.DiscardFontStyled},
+		{Text: "Where are you going tonight my friend?", Reason: interfaces.DiscardDuplicate},
+		{Text: "We should have left the house much earlier today", Reason: interfaces.DiscardTooLong},
+	}, result.Discarded)
+}
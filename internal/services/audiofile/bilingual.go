@@ -0,0 +1,162 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/csv"
+	"golang.org/x/text/language"
+	"io"
+	"mime/multipart"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "bilingual",
+		format:     Bilingual,
+		mimeTypes:  []string{"text/csv", "text/tab-separated-values"},
+		extensions: []string{".csv", ".tsv"},
+		sniff:      sniffIf(isBilingual, possibleConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, _ segmenter) ([]cue, error) {
+			return parseBilingual(f), nil
+		}),
+	})
+}
+
+// bilingualDelimiters are the column separators of a bilingual file in the order they are
+// tried
+var bilingualDelimiters = []rune{'\t', ';', ','}
+
+// bilingualHeaders are the column names of a bilingual file header that are not language
+// codes like en or spa
+var bilingualHeaders = []string{
+	"source", "target", "text", "translation", "sentence", "phrase", "original", "native",
+	"foreign", "front", "back", "question", "answer", "term", "definition", "word", "meaning",
+	"english", "spanish", "french", "german", "italian", "portuguese", "dutch", "russian",
+	"japanese", "chinese", "korean", "arabic", "hebrew", "greek", "turkish", "polish",
+}
+
+// bilingualTable is a csv or tsv file of sentence pairs like a textbook vocabulary list or
+// a Tatoeba export
+type bilingualTable struct {
+	// records are the rows of the file without the header
+	records [][]string
+	// source and target are the columns of the sentence and its translation
+	source int
+	target int
+}
+
+// readBilingual reads the content as a csv or tsv file where every row has the same number
+// of columns and at least two of them are text. The first two text columns are the source
+// and target and a header row of column names is skipped. A file separated by commas or
+// semicolons needs a header or at least three rows so one phrase per line files with a
+// comma in every line are not mistaken for it. It returns false if the content is not a
+// bilingual file.
+func readBilingual(content []byte) (bilingualTable, bool) {
+	for _, delimiter := range bilingualDelimiters {
+		if !bytes.ContainsRune(content, delimiter) {
+			continue
+		}
+		reader := csv.NewReader(bytes.NewReader(content))
+		reader.Comma = delimiter
+		reader.LazyQuotes = true
+		// a tab at the start of a tsv row is an empty column and not leading space
+		reader.TrimLeadingSpace = delimiter != '\t'
+		records, err := reader.ReadAll()
+		if err != nil || len(records) == 0 || len(records[0]) < 2 {
+			continue
+		}
+
+		header := false
+		columns := textColumns(records)
+		if len(columns) >= 2 && len(records) > 1 && isBilingualHeader(records[0], columns[:2]) {
+			header = true
+			records = records[1:]
+			columns = textColumns(records)
+		}
+		if len(columns) < 2 || (delimiter != '\t' && !header && len(records) < 3) {
+			continue
+		}
+
+		return bilingualTable{records: records, source: columns[0], target: columns[1]}, true
+	}
+
+	return bilingualTable{}, false
+}
+
+// isBilingual checks if the content is a bilingual csv or tsv file
+func isBilingual(content []byte) bool {
+	_, ok := readBilingual(content)
+	return ok
+}
+
+// parseBilingual takes a bilingual csv or tsv file and returns a cue for each row with the
+// source column as the text and the target column as its translation. Rows without a
+// source or target are skipped. The rows are not split into shorter phrases so they stay
+// pairs.
+func parseBilingual(f io.Reader) []cue {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil
+	}
+	table, ok := readBilingual(content)
+	if !ok {
+		return nil
+	}
+
+	var cues []cue
+	for _, record := range table.records {
+		text := strings.Join(strings.Fields(record[table.source]), " ")
+		translation := strings.Join(strings.Fields(record[table.target]), " ")
+		if text == "" || translation == "" {
+			continue
+		}
+		cues = append(cues, cue{text: text, translation: translation})
+	}
+
+	return cues
+}
+
+// textColumns returns the columns that have a letter in at least one row and are not only
+// numbers (sentence ids) or language codes (eng, deu)
+func textColumns(records [][]string) []int {
+	var columns []int
+	for column := range records[0] {
+		text, codes := false, true
+		for _, record := range records {
+			cell := strings.TrimSpace(record[column])
+			if strings.ContainsFunc(cell, unicode.IsLetter) {
+				text = true
+			}
+			if cell != "" && !isLanguageCode(cell) {
+				codes = false
+			}
+		}
+		if text && !codes {
+			columns = append(columns, column)
+		}
+	}
+	return columns
+}
+
+// isBilingualHeader checks if the cells of the row in the columns are column names
+func isBilingualHeader(record []string, columns []int) bool {
+	for _, column := range columns {
+		cell := strings.ToLower(strings.TrimSpace(record[column]))
+		if !slices.Contains(bilingualHeaders, cell) && !isLanguageCode(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// isLanguageCode checks if the cell is a lower case language code like en, spa or pt-br
+func isLanguageCode(cell string) bool {
+	if len(cell) < 2 || len(cell) > 8 || strings.ContainsFunc(cell, unicode.IsUpper) {
+		return false
+	}
+	_, err := language.Parse(cell)
+	return err == nil
+}
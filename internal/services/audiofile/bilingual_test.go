@@ -0,0 +1,111 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadBilingual(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		ok      bool
+		rows    int
+		source  int
+		target  int
+	}{
+		{
+			name:    "tsv",
+			content: "Good morning.\tBuenos días.\nHow are you?\t¿Cómo estás?\n",
+			ok:      true,
+			rows:    2,
+			source:  0,
+			target:  1,
+		},
+		{
+			name:    "csv with header and quotes",
+			content: "english,spanish\n\"Yes, I do.\",\"Sí, lo hago.\"\n",
+			ok:      true,
+			rows:    1,
+			source:  0,
+			target:  1,
+		},
+		{
+			name:    "semicolon csv with language code header",
+			content: "en;fr\nThank you.;Merci.\n",
+			ok:      true,
+			rows:    1,
+			source:  0,
+			target:  1,
+		},
+		{
+			name:    "tatoeba export with ids and languages",
+			content: "1276\teng\tLet's try something.\t2\tdeu\tVersuchen wir etwas.\n1277\teng\tI have to go to sleep.\t3\tdeu\tIch muss schlafen gehen.\n",
+			ok:      true,
+			rows:    2,
+			source:  2,
+			target:  5,
+		},
+		{
+			name:    "csv without header with three rows",
+			content: "cat,gato\ndog,perro\nbird,pájaro\n",
+			ok:      true,
+			rows:    3,
+			source:  0,
+			target:  1,
+		},
+		{
+			name:    "one phrase per line with a comma",
+			content: "Well, I think so.\nYes, of course.\n",
+			ok:      false,
+		},
+		{
+			name:    "different number of columns",
+			content: "Well, I think so.\nYes, of course, I will.\nNo, no, no, no.\n",
+			ok:      false,
+		},
+		{
+			name:    "only one text column",
+			content: "1\tHello there.\n2\tGood night.\n",
+			ok:      false,
+		},
+		{
+			name:    "no delimiter",
+			content: "This is the first sentence.\nThis is the second sentence.\n",
+			ok:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, ok := readBilingual([]byte(tt.content))
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Len(t, table.records, tt.rows)
+				assert.Equal(t, tt.source, table.source)
+				assert.Equal(t, tt.target, table.target)
+			}
+		})
+	}
+}
+
+func TestParseBilingual(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	content := "source\ttarget\nGood  morning.\tBuenos días.\n\tSin fuente.\nHow are you?\t¿Cómo estás?\n"
+	expected := []cue{
+		{text: "Good morning.", translation: "Buenos días."},
+		{text: "How are you?", translation: "¿Cómo estás?"},
+	}
+	assert.Equal(t, expected, parseBilingual(strings.NewReader(content)))
+	assert.Nil(t, parseBilingual(strings.NewReader("This is not a bilingual file.")))
+}
@@ -0,0 +1,133 @@
+package audiofile
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode"
+)
+
+var (
+	// speakerLabelRegex matches an upper case speaker label like JOHN:, DR. SMITH:, MAN 2: or
+	// JOHN (V.O.): at the start of the text or after the dash of a dialogue line
+	speakerLabelRegex = regexp.MustCompile(`(^\s*|(?:^|\s)[-‐–—]\s*)\p{Lu}[\p{Lu}\d'’.&]+(?:[ -][\p{Lu}\d][\p{Lu}\d'’.&]*){0,3}(?:\s*\([^)]*\))?\s*:(?:\s+|$)`)
+	// soundCueRegex matches parenthetical sound cues like (LAUGHS), (door slams) or （笑）
+	soundCueRegex = regexp.MustCompile(`[(（][^()（）]*[)）]`)
+	// musicLineRegex matches the lyrics between music notes or from a note to the end of the text
+	musicLineRegex = regexp.MustCompile(`[♪♫♬][^♪♫♬]*(?:[♪♫♬]|$)`)
+	// dialogueDashRegex matches the dash that starts the line of each speaker in a subtitle
+	dialogueDashRegex = regexp.MustCompile(`^\s*[-‐–—]+|\s[-‐–—]+(?:\s+|$)`)
+)
+
+// cleanupRule removes one kind of annotation from the text of a subtitle or line
+type cleanupRule struct {
+	name  string
+	apply func(string) string
+}
+
+// cleanupRules are applied in this order so sound cues and music are removed before the
+// speaker labels they hide and speaker labels are removed before the dashes in front of them
+var cleanupRules = []cleanupRule{
+	{name: "music_lines", apply: func(text string) string { return musicLineRegex.ReplaceAllString(text, " ") }},
+	{name: "sound_cues", apply: func(text string) string { return soundCueRegex.ReplaceAllString(text, " ") }},
+	{name: "speaker_labels", apply: func(text string) string { return speakerLabelRegex.ReplaceAllString(text, "${1}") }},
+	{name: "dialogue_dashes", apply: func(text string) string { return dialogueDashRegex.ReplaceAllString(text, " ") }},
+	{name: "caps_descriptions", apply: removeCapsDescription},
+}
+
+// defaultCleanup are the rules that are used when a request does not choose any
+var defaultCleanup = []string{"speaker_labels", "sound_cues", "music_lines", "dialogue_dashes"}
+
+// cleanup removes the annotations of the rules chosen for a request, counts how many
+// subtitles or lines each rule changed and records the lines that are dropped while the file
+// is parsed
+type cleanup struct {
+	rules     []cleanupRule
+	counts    []int
+	discarded []interfaces.DiscardedLine
+}
+
+// newCleanup returns a cleanup with the named rules, the default rules if there are no
+// names or no rules if the names are "none"
+func newCleanup(names []string) *cleanup {
+	if len(names) == 0 {
+		names = defaultCleanup
+	}
+	c := &cleanup{}
+	for _, rule := range cleanupRules {
+		if slices.Contains(names, rule.name) {
+			c.rules = append(c.rules, rule)
+		}
+	}
+	c.counts = make([]int, len(c.rules))
+	return c
+}
+
+// clean removes the annotations from the text. A nil cleanup returns the text unchanged.
+func (c *cleanup) clean(text string) string {
+	if c == nil {
+		return text
+	}
+	original := text
+	for i, rule := range c.rules {
+		cleaned := rule.apply(text)
+		if cleaned != text {
+			c.counts[i]++
+			if hasWords(text) && !hasWords(cleaned) {
+				c.discarded = append(c.discarded, interfaces.DiscardedLine{Text: original, Reason: interfaces.DiscardFiltered, Rule: rule.name})
+			}
+			text = strings.Join(strings.Fields(cleaned), " ")
+		}
+	}
+	return text
+}
+
+// discard records a line that was dropped for the reason. A nil cleanup records nothing.
+func (c *cleanup) discard(text, reason string) {
+	if c == nil {
+		return
+	}
+	c.discarded = append(c.discarded, interfaces.DiscardedLine{Text: text, Reason: reason})
+}
+
+// discardDuplicate records a line that was merged into the phrase it is a near duplicate of
+func (c *cleanup) discardDuplicate(text, phrase string) {
+	if c == nil {
+		return
+	}
+	c.discarded = append(c.discarded, interfaces.DiscardedLine{Text: text, Reason: interfaces.DiscardNearDuplicate, DuplicateOf: phrase})
+}
+
+// discards returns the lines that were dropped in the order they were found
+func (c *cleanup) discards() []interfaces.DiscardedLine {
+	if c == nil {
+		return nil
+	}
+	return c.discarded
+}
+
+// report returns how many subtitles or lines each rule changed
+func (c *cleanup) report() []interfaces.CleanupCount {
+	if c == nil {
+		return nil
+	}
+	var counts []interfaces.CleanupCount
+	for i, rule := range c.rules {
+		counts = append(counts, interfaces.CleanupCount{Rule: rule.name, Count: c.counts[i]})
+	}
+	return counts
+}
+
+// hasWords checks if the text has a letter or a number
+func hasWords(text string) bool {
+	return strings.ContainsFunc(text, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) })
+}
+
+// removeCapsDescription removes text that has no lower case letters like PHONE RINGING
+func removeCapsDescription(text string) string {
+	if strings.ContainsFunc(text, unicode.IsLower) || !strings.ContainsFunc(text, unicode.IsUpper) {
+		return text
+	}
+	return ""
+}
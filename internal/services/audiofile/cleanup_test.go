@@ -0,0 +1,70 @@
+package audiofile
+
+import (
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCleanup(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		rules    []string
+		input    string
+		expected string
+	}{
+		{"speaker label", nil, "JOHN: Where are you going?", "Where are you going?"},
+		{"speaker label with title", nil, "DR. SMITH: Take a seat.", "Take a seat."},
+		{"numbered speaker label", nil, "MAN 2: Over here!", "Over here!"},
+		{"speaker label with voice over", nil, "NARRATOR (V.O.): It was a cold night.", "It was a cold night."},
+		{"speaker labels of dialogue", nil, "- MARY: Are you coming? - JOHN: Yes.", "Are you coming? Yes."},
+		{"mixed case label is kept", nil, "Note: this is kept.", "Note: this is kept."},
+		{"upper case word in a sentence is kept", nil, "I said OK: let's go.", "I said OK: let's go."},
+		{"sound cues", nil, "(LAUGHS) That is funny. (door slams)", "That is funny."},
+		{"full width sound cue", nil, "（笑）本当ですか", "本当ですか"},
+		{"music line", nil, "♫ Never gonna give you up ♫", ""},
+		{"music until the end", nil, "Listen. ♪ Never gonna let you down", "Listen."},
+		{"dialogue dashes", nil, "-Are you sure? - Yes, I am.", "Are you sure? Yes, I am."},
+		{"hyphenated word is kept", nil, "A well-known fact.", "A well-known fact."},
+		{"caps description is not a default rule", nil, "PHONE RINGING", "PHONE RINGING"},
+		{"caps description", []string{"caps_descriptions"}, "PHONE RINGING", ""},
+		{"chosen rules only", []string{"sound_cues"}, "JOHN: (sighs) Fine.", "JOHN: Fine."},
+		{"none", []string{"none"}, "JOHN: (sighs) Fine.", "JOHN: (sighs) Fine."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newCleanup(tt.rules).clean(tt.input))
+		})
+	}
+}
+
+func TestCleanupReport(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	c := newCleanup(nil)
+	for _, line := range []string{"JOHN: Hello.", "- MARY: Hi. (LAUGHS)", "How are you?"} {
+		c.clean(line)
+	}
+
+	expected := []interfaces.CleanupCount{
+		{Rule: "sound_cues", Count: 1},
+		{Rule: "speaker_labels", Count: 2},
+		{Rule: "dialogue_dashes", Count: 1},
+	}
+	assert.Equal(t, "music_lines", c.report()[0].Rule)
+	assert.Equal(t, 0, c.report()[0].Count)
+	assert.Equal(t, expected, c.report()[1:])
+
+	var nilCleanup *cleanup
+	assert.Equal(t, "JOHN: Hello.", nilCleanup.clean("JOHN: Hello."))
+	assert.Nil(t, nilCleanup.report())
+}
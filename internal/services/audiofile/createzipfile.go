@@ -12,6 +12,8 @@ import (
 	"path/filepath"
 	"strings"
 	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"unicode"
 )
 
 // CreateMp3Zip generates mp3 files from input text files and zips them into a single file.
@@ -29,6 +31,10 @@ func (af *AudioFile) CreateMp3Zip(t interfaces.Title, tmpDir string) (*os.File,
 
 	for i, f := range files {
 		outputPath := fmt.Sprintf("%s/%s-%d.mp3", outDirPath, t.Name, i)
+		if i < len(t.Sections) {
+			// the input files are in the order of the sections
+			outputPath = fmt.Sprintf("%s/%s-%s%s.mp3", outDirPath, t.Name, sectionNumber(i+1, len(t.Sections)), sectionFileName(t.Sections[i].Name))
+		}
 		cmd := exec.Command("ffmpeg", "-f", "concat", "-safe", "0", "-i", filepath.Join(tmpDir, f.Name()), "-c", "copy", outputPath) // #nosec G204
 		if output, err := af.cmdX.CombinedOutput(cmd); err != nil {
 			log.Printf("error executing ffmpeg: %v", err)
@@ -43,9 +49,33 @@ func (af *AudioFile) CreateMp3Zip(t interfaces.Title, tmpDir string) (*os.File,
 		}
 	}
 
+	if len(t.Unaligned) > 0 {
+		if err := writeUnalignedCues(outDirPath, t.Name, t.Unaligned); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(t.Alignment) > 0 {
+		if err := writeSentenceAlignment(outDirPath, t.Name, t.Alignment); err != nil {
+			return nil, err
+		}
+	}
+
 	return createZipFile(tmpDir, t.Name, outDirPath)
 }
 
+// sectionFileName returns the name of a section to add to the name of its mp3, with each run
+// of characters that are not letters or digits replaced by a dash
+func sectionFileName(name string) string {
+	name = strings.Join(strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	}), "-")
+	if name == "" {
+		return ""
+	}
+	return "-" + name
+}
+
 // writeTranslatedPhrases writes translated phrases to a text file.
 func writeTranslatedPhrases(outDirPath, title string, phrases []interfaces.Phrase) error {
 	file, err := os.Create(fmt.Sprintf("%s/%s-translates.txt", outDirPath, title))
@@ -62,6 +92,67 @@ func writeTranslatedPhrases(outDirPath, title string, phrases []interfaces.Phras
 	return nil
 }
 
+// writeUnalignedCues writes the subtitles or sentences that could not be aligned to a text
+// file with the file they are from and the time of subtitles.
+func writeUnalignedCues(outDirPath, title string, cues []interfaces.UnalignedCue) error {
+	file, err := os.Create(fmt.Sprintf("%s/%s-unaligned.txt", outDirPath, title))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, c := range cues {
+		line := fmt.Sprintf("%s %s\n", c.File, c.Text)
+		// the sentences of text files have no time
+		if c.Start > 0 || c.End > 0 {
+			line = fmt.Sprintf("%s %s --> %s %s\n", c.File, util.FormatTimestamp(c.Start), util.FormatTimestamp(c.End), c.Text)
+		}
+		if _, err := file.WriteString(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeChapterList writes the chapters of an epub to a text file with the number used to
+// choose the chapter, its title and how many phrases it has on each line.
+func writeChapterList(outDirPath, title string, chapters []interfaces.Chapter) error {
+	file, err := os.Create(fmt.Sprintf("%s/%s-chapters.txt", outDirPath, title))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, c := range chapters {
+		if _, err := fmt.Fprintf(file, "%d\t%s\t%d phrases\n", c.Number, c.Title, c.Phrases); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeExtractedText writes the text extracted from a pdf to <title>-text.txt
+func writeExtractedText(outDirPath, title, text string) error {
+	return os.WriteFile(fmt.Sprintf("%s/%s-text.txt", outDirPath, title), []byte(text+"\n"), 0644)
+}
+
+// writeSentenceAlignment writes how the sentences of two aligned text files were matched to
+// a text file with the kind of match, the sentences and their translation on each line.
+func writeSentenceAlignment(outDirPath, title string, alignment []interfaces.SentenceAlignment) error {
+	file, err := os.Create(fmt.Sprintf("%s/%s-alignment.txt", outDirPath, title))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	for _, a := range alignment {
+		if _, err := file.WriteString(a.Kind + "\t" + a.Text + "\t" + a.Translation + "\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // CreatePhrasesZip creates a zipped file of txt files from the file the user uploaded if it contains
 // more phrases than the limit of config.MaxNumPhrases. It takes a iter.Seq of strings and outputs them
 // to files, each chunk containing config.MaxNumPhrases and than zips them up.
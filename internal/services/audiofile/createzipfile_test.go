@@ -6,7 +6,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
+	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -16,6 +18,7 @@ import (
 	"talkliketv.com/tltv/internal/testutil"
 	"talkliketv.com/tltv/internal/util"
 	"testing"
+	"time"
 )
 
 func TestCreateMp3Zip(t *testing.T) {
@@ -29,6 +32,8 @@ func TestCreateMp3Zip(t *testing.T) {
 	require.NoError(t, err)
 	defer os.RemoveAll(baseDir)
 
+	// the mp3's ffmpeg is asked to create for the sections of a title
+	var sectionOutputs, manySectionOutputs []string
 	testCases := []audioFileTestCase{
 		{
 			name: "Success - multiple files",
@@ -56,6 +61,134 @@ func TestCreateMp3Zip(t *testing.T) {
 				require.FileExists(t, file.Name())
 			},
 		},
+		{
+			name: "Unaligned cues",
+			createTitle: func(t *testing.T) (interfaces.Title, string) {
+				title := testutil.RandomTitle()
+				title.Unaligned = []interfaces.UnalignedCue{
+					{File: "translation_file_path", Start: 62500 * time.Millisecond, End: 64 * time.Second, Text: "Nobody says this."},
+				}
+				tmpDir := filepath.Join(baseDir, title.Name)
+				err := os.MkdirAll(tmpDir, 0777)
+				require.NoError(t, err)
+				createFile(t, filepath.Join(tmpDir, "file1.mp3"), "test audio content 1")
+				return title, tmpDir
+			},
+			buildStubs: func(ma *mock.MockcmdRunnerX) {
+				ma.EXPECT().
+					CombinedOutput(gomock.Any()).Times(1).
+					Return([]byte{}, nil)
+			},
+			checkReturn: func(t *testing.T, file *os.File, err error) {
+				require.NoError(t, err)
+				reader, err := zip.OpenReader(file.Name())
+				require.NoError(t, err)
+				defer reader.Close()
+				var content string
+				for _, f := range reader.File {
+					if strings.HasSuffix(f.Name, "-unaligned.txt") {
+						rc, err := f.Open()
+						require.NoError(t, err)
+						b, err := io.ReadAll(rc)
+						require.NoError(t, err)
+						rc.Close()
+						content = string(b)
+					}
+				}
+				require.Equal(t, "translation_file_path 00:01:02.500 --> 00:01:04.000 Nobody says this.\n", content)
+			},
+		},
+		{
+			name: "Sentence alignment",
+			createTitle: func(t *testing.T) (interfaces.Title, string) {
+				title := testutil.RandomTitle()
+				title.Alignment = []interfaces.SentenceAlignment{
+					{Kind: "2:1", Text: "Compré pan. Luego me fui.", Translation: "I bought bread and left."},
+				}
+				title.Unaligned = []interfaces.UnalignedCue{{File: "file_path", Text: "No se tradujo."}}
+				tmpDir := filepath.Join(baseDir, title.Name)
+				err := os.MkdirAll(tmpDir, 0777)
+				require.NoError(t, err)
+				createFile(t, filepath.Join(tmpDir, "file1.mp3"), "test audio content 1")
+				return title, tmpDir
+			},
+			buildStubs: func(ma *mock.MockcmdRunnerX) {
+				ma.EXPECT().
+					CombinedOutput(gomock.Any()).Times(1).
+					Return([]byte{}, nil)
+			},
+			checkReturn: func(t *testing.T, file *os.File, err error) {
+				require.NoError(t, err)
+				reader, err := zip.OpenReader(file.Name())
+				require.NoError(t, err)
+				defer reader.Close()
+				contents := make(map[string]string)
+				for _, f := range reader.File {
+					rc, err := f.Open()
+					require.NoError(t, err)
+					b, err := io.ReadAll(rc)
+					require.NoError(t, err)
+					rc.Close()
+					contents[f.Name[strings.LastIndex(f.Name, "-")+1:]] = string(b)
+				}
+				require.Equal(t, "2:1\tCompré pan. Luego me fui.\tI bought bread and left.\n", contents["alignment.txt"])
+				require.Equal(t, "file_path No se tradujo.\n", contents["unaligned.txt"])
+			},
+		},
+		{
+			name: "Section names",
+			createTitle: func(t *testing.T) (interfaces.Title, string) {
+				title := testutil.RandomTitle()
+				title.Sections = []interfaces.Section{{Name: "Greetings", Phrases: 2}, {Name: "At the café!", Phrases: 3}}
+				tmpDir := filepath.Join(baseDir, title.Name)
+				err := os.MkdirAll(tmpDir, 0777)
+				require.NoError(t, err)
+				createFile(t, filepath.Join(tmpDir, title.Name+"-input-01"), "file 'pause'")
+				createFile(t, filepath.Join(tmpDir, title.Name+"-input-02"), "file 'pause'")
+				return title, tmpDir
+			},
+			buildStubs: func(ma *mock.MockcmdRunnerX) {
+				ma.EXPECT().
+					CombinedOutput(gomock.Any()).Times(2).
+					DoAndReturn(func(cmd *exec.Cmd) ([]byte, error) {
+						sectionOutputs = append(sectionOutputs, filepath.Base(cmd.Args[len(cmd.Args)-1]))
+						return []byte{}, nil
+					})
+			},
+			checkReturn: func(t *testing.T, file *os.File, err error) {
+				require.NoError(t, err)
+				require.Len(t, sectionOutputs, 2)
+				require.True(t, strings.HasSuffix(sectionOutputs[0], "-01-Greetings.mp3"), sectionOutputs[0])
+				require.True(t, strings.HasSuffix(sectionOutputs[1], "-02-At-the-café.mp3"), sectionOutputs[1])
+			},
+		},
+		{
+			name: "More than 99 sections",
+			createTitle: func(t *testing.T) (interfaces.Title, string) {
+				title := testutil.RandomTitle()
+				tmpDir := filepath.Join(baseDir, title.Name)
+				require.NoError(t, os.MkdirAll(tmpDir, 0777))
+				for i := 1; i <= 100; i++ {
+					title.Sections = append(title.Sections, interfaces.Section{Name: fmt.Sprintf("Section %d", i), Phrases: 1})
+					createFile(t, filepath.Join(tmpDir, title.Name+"-input-"+sectionNumber(i, 100)), "file 'pause'")
+				}
+				return title, tmpDir
+			},
+			buildStubs: func(ma *mock.MockcmdRunnerX) {
+				ma.EXPECT().
+					CombinedOutput(gomock.Any()).Times(100).
+					DoAndReturn(func(cmd *exec.Cmd) ([]byte, error) {
+						manySectionOutputs = append(manySectionOutputs, filepath.Base(cmd.Args[len(cmd.Args)-1]))
+						return []byte{}, nil
+					})
+			},
+			checkReturn: func(t *testing.T, file *os.File, err error) {
+				require.NoError(t, err)
+				require.Len(t, manySectionOutputs, 100)
+				require.True(t, strings.HasSuffix(manySectionOutputs[10], "-011-Section-11.mp3"), manySectionOutputs[10])
+				require.True(t, strings.HasSuffix(manySectionOutputs[99], "-100-Section-100.mp3"), manySectionOutputs[99])
+			},
+		},
 		{
 			name: "No files",
 			createTitle: func(t *testing.T) (interfaces.Title, string) {
@@ -273,3 +406,31 @@ func TestCreatePhrasesZip(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteChapterList(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	dir := t.TempDir()
+	chapters := []interfaces.Chapter{
+		{Number: 1, Title: "Capítulo uno", Phrases: 12},
+		{Number: 2, Title: "Capítulo dos", Phrases: 7},
+	}
+	require.NoError(t, writeChapterList(dir, "book", chapters))
+	content, err := os.ReadFile(filepath.Join(dir, "book-chapters.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "1\tCapítulo uno\t12 phrases\n2\tCapítulo dos\t7 phrases\n", string(content))
+}
+
+func TestWriteExtractedText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	dir := t.TempDir()
+	require.NoError(t, writeExtractedText(dir, "worksheet", "Primera frase.\n\nSegunda frase."))
+	content, err := os.ReadFile(filepath.Join(dir, "worksheet-text.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "Primera frase.\n\nSegunda frase.\n", string(content))
+}
@@ -0,0 +1,171 @@
+package audiofile
+
+import (
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"time"
+	"unicode/utf8"
+)
+
+// cue is a piece of text from a file with the time it is spoken at. The start and end are
+// 0 for files that are not timed.
+type cue struct {
+	start time.Duration
+	end   time.Duration
+	text  string
+	// translation is the text in the target language of a bilingual file
+	translation string
+	// section is the heading of a Markdown file the text is under
+	section string
+}
+
+// IsTimed checks if the format has the time each phrase is spoken at
+func IsTimed(fileType TextFormat) bool {
+	switch fileType {
+	case Srt, WebVTT, Ass, Ttml, Lrc, Json3, Srv3, JsonTranscript:
+		return true
+	default:
+		return false
+	}
+}
+
+// IsAlignable checks if the format can be aligned with a translation file, which are the
+// timed subtitle formats and text files
+func IsAlignable(fileType TextFormat) bool {
+	return IsTimed(fileType) || isText(fileType)
+}
+
+// parseTimingLine parses the start and end of a srt or vtt timing line like
+// 00:00:01,000 --> 00:00:04,000 or 00:01.000 --> 00:04.000 align:start
+func parseTimingLine(line string) (time.Duration, time.Duration, bool) {
+	before, after, ok := strings.Cut(line, "-->")
+	if !ok {
+		return 0, 0, false
+	}
+	fields := strings.Fields(after)
+	if len(fields) == 0 {
+		return 0, 0, false
+	}
+	start, err := util.ParseTimestamp(before)
+	if err != nil {
+		return 0, 0, false
+	}
+	end, err := util.ParseTimestamp(fields[0])
+	if err != nil {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// cuePhrases splits the text of the cue into phrases that keep the time of the cue
+func cuePhrases(c cue, seg segmenter) []cue {
+	var phrases []cue
+	for _, text := range splitLongPhrases(c.text, seg) {
+		phrases = append(phrases, cue{start: c.start, end: c.end, text: text})
+	}
+	return phrases
+}
+
+// untimedCues returns a cue without a time for each phrase of a file that is not timed
+func untimedCues(lines []string) []cue {
+	cues := make([]cue, len(lines))
+	for i, line := range lines {
+		cues[i] = cue{text: line}
+	}
+	return cues
+}
+
+// inTimeRange returns the cues that begin at or after start and before end. An end of 0 is
+// the end of the file.
+func inTimeRange(cues []cue, start, end time.Duration) []cue {
+	var selected []cue
+	for _, c := range cues {
+		if c.start >= start && (end == 0 || c.start < end) {
+			selected = append(selected, c)
+		}
+	}
+	return selected
+}
+
+// uniquePhrases numbers the text of the cues as phrases and the translations of the cues of
+// a bilingual file as phrases with the same ID. Only the first cue of a repeated text is kept,
+// like the chorus of a song, cues longer than maxChars are removed and the cues that are near
+// duplicates of an earlier phrase by the dedupe are merged into it. The cues that are not
+// kept are recorded by the cleanup.
+func uniquePhrases(cues []cue, maxChars int, d *dedupe, c *cleanup) ([]interfaces.Phrase, []interfaces.Phrase) {
+	seen := make(map[string]bool)
+	var phrases, translations []interfaces.Phrase
+	for _, cu := range cues {
+		switch {
+		case seen[cu.text]:
+			c.discard(cu.text, interfaces.DiscardDuplicate)
+			continue
+		case utf8.RuneCountInString(cu.text) > maxChars || utf8.RuneCountInString(cu.translation) > maxChars:
+			c.discard(cu.text, interfaces.DiscardTooLong)
+			continue
+		}
+		if kept, ok := d.duplicateOf(cu.text); ok {
+			c.discardDuplicate(cu.text, kept)
+			continue
+		}
+		d.keep(cu.text)
+		seen[cu.text] = true
+		id := len(phrases)
+		phrases = append(phrases, interfaces.Phrase{
+			ID:    id,
+			Text:  cu.text,
+			Start: cu.start,
+			End:   cu.end,
+		})
+		if cu.translation != "" {
+			translations = append(translations, interfaces.Phrase{ID: id, Text: cu.translation})
+		}
+	}
+	return phrases, translations
+}
+
+// phraseSections groups the phrases by the section of the cue each one is from, which is the
+// heading of a Markdown file they are under. It returns nil when the cues have no sections.
+func phraseSections(cues []cue, phrases []interfaces.Phrase) []interfaces.Section {
+	if !slices.ContainsFunc(cues, func(c cue) bool { return c.section != "" }) {
+		return nil
+	}
+	// a repeated text is kept by uniquePhrases where it first appears
+	sectionOf := make(map[string]string)
+	for _, c := range cues {
+		if _, ok := sectionOf[c.text]; !ok {
+			sectionOf[c.text] = c.section
+		}
+	}
+
+	var sections []interfaces.Section
+	for _, phrase := range phrases {
+		name := sectionOf[phrase.Text]
+		if n := len(sections); n > 0 && sections[n-1].Name == name {
+			sections[n-1].Phrases++
+			continue
+		}
+		sections = append(sections, interfaces.Section{Name: name, Phrases: 1})
+	}
+	return sections
+}
+
+// phraseTexts returns the text of each phrase
+func phraseTexts(phrases []interfaces.Phrase) []string {
+	texts := make([]string, len(phrases))
+	for i, phrase := range phrases {
+		texts[i] = phrase.Text
+	}
+	return texts
+}
+
+// cueTexts returns the text of each cue
+func cueTexts(cues []cue) []string {
+	texts := make([]string, len(cues))
+	for i, c := range cues {
+		texts[i] = c.text
+	}
+	return texts
+}
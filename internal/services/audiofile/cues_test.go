@@ -0,0 +1,116 @@
+package audiofile
+
+import (
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTimingLine(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name  string
+		line  string
+		start time.Duration
+		end   time.Duration
+		ok    bool
+	}{
+		{"srt", "00:00:01,418 --> 00:00:04,170", 1418 * time.Millisecond, 4170 * time.Millisecond, true},
+		{"vtt with settings", "01:02.500 --> 01:04.000 align:start position:10%", time.Minute + 2500*time.Millisecond, time.Minute + 4*time.Second, true},
+		{"vtt with hours", "01:00:00.000 --> 01:00:02.250", time.Hour, time.Hour + 2250*time.Millisecond, true},
+		{"not a timing line", "Hello --> world", 0, 0, false},
+		{"no end", "00:00:01,000 -->", 0, 0, false},
+		{"text", "This is a sentence.", 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end, ok := parseTimingLine(tt.line)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.start, start)
+			assert.Equal(t, tt.end, end)
+		})
+	}
+}
+
+func TestInTimeRange(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	cues := []cue{
+		{start: 0, end: 2 * time.Second, text: "first"},
+		{start: 5 * time.Second, end: 8 * time.Second, text: "second"},
+		{start: 10 * time.Second, end: 12 * time.Second, text: "third"},
+	}
+
+	tests := []struct {
+		name     string
+		start    time.Duration
+		end      time.Duration
+		expected []cue
+	}{
+		{"no range", 0, 0, cues},
+		{"from start time", 5 * time.Second, 0, cues[1:]},
+		{"until end time", 0, 10 * time.Second, cues[:2]},
+		{"cue starting before the range", 6 * time.Second, 11 * time.Second, cues[2:]},
+		{"empty range", 20 * time.Second, 0, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, inTimeRange(cues, tt.start, tt.end))
+		})
+	}
+}
+
+func TestUniquePhrases(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	cues := []cue{
+		{start: time.Second, end: 2 * time.Second, text: "We will rock you."},
+		{start: 3 * time.Second, end: 4 * time.Second, text: "Buddy, you're a boy, make a big noise."},
+		{start: 5 * time.Second, end: 6 * time.Second, text: "We will rock you."},
+		{start: 7 * time.Second, end: 8 * time.Second, text: "Playing in the street, gonna be a big man someday."},
+	}
+
+	expected := []interfaces.Phrase{
+		{ID: 0, Text: "We will rock you.", Start: time.Second, End: 2 * time.Second},
+		{ID: 1, Text: "Buddy, you're a boy, make a big noise.", Start: 3 * time.Second, End: 4 * time.Second},
+	}
+	phrases, translations := uniquePhrases(cues, 40, nil, nil)
+	assert.Equal(t, expected, phrases)
+	assert.Nil(t, translations)
+}
+
+func TestPhraseSections(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	cues := []cue{
+		{text: "This is not under a heading."},
+		{text: "Good morning to you.", section: "Greetings"},
+		{text: "This phrase is much too long to be kept as a phrase.", section: "Greetings"},
+		{text: "See you again tomorrow.", section: "Goodbyes"},
+		{text: "Good morning to you.", section: "Goodbyes"},
+		{text: "Have a good night.", section: "Goodbyes"},
+	}
+	phrases, _ := uniquePhrases(cues, 40, nil, nil)
+
+	expected := []interfaces.Section{
+		{Name: "", Phrases: 1},
+		{Name: "Greetings", Phrases: 1},
+		{Name: "Goodbyes", Phrases: 2},
+	}
+	assert.Equal(t, expected, phraseSections(cues, phrases))
+	assert.Nil(t, phraseSections(cues[:1], phrases[:1]))
+}
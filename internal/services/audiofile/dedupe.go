@@ -0,0 +1,162 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// defaultSimilarity is how similar two normalized phrases have to be to be near duplicates
+// when the options do not choose a similarity
+const defaultSimilarity = 0.9
+
+// dedupe finds the phrases that are near duplicates of a phrase that was kept before them. A
+// nil dedupe finds none, which leaves only the phrases that are the same to uniquePhrases.
+type dedupe struct {
+	fuzzy      bool
+	similarity float64
+	// normalized are the phrases that were kept by their normalized text
+	normalized map[string]string
+	// kept are the normalized texts of the phrases that were kept in order
+	kept []string
+}
+
+// newDedupe returns the dedupe of the options or nil when the phrases are only deduplicated
+// when they are the same
+func newDedupe(opts interfaces.ParseOptions) *dedupe {
+	if opts.Dedupe != interfaces.DedupeNormalized && opts.Dedupe != interfaces.DedupeFuzzy {
+		return nil
+	}
+	d := &dedupe{
+		fuzzy:      opts.Dedupe == interfaces.DedupeFuzzy,
+		similarity: opts.Similarity,
+		normalized: make(map[string]string),
+	}
+	if d.similarity == 0 {
+		d.similarity = defaultSimilarity
+	}
+	return d
+}
+
+// duplicateOf returns the phrase that was kept before that the text is a near duplicate of
+func (d *dedupe) duplicateOf(text string) (string, bool) {
+	if d == nil {
+		return "", false
+	}
+	key := normalizePhrase(text)
+	if phrase, ok := d.normalized[key]; ok {
+		return phrase, true
+	}
+	if !d.fuzzy {
+		return "", false
+	}
+	for _, kept := range d.kept {
+		if similarPhrases(key, kept, d.similarity) {
+			return d.normalized[kept], true
+		}
+	}
+	return "", false
+}
+
+// keep adds a phrase that is kept so the phrases after it can be near duplicates of it
+func (d *dedupe) keep(text string) {
+	if d == nil {
+		return
+	}
+	key := normalizePhrase(text)
+	if _, ok := d.normalized[key]; ok {
+		return
+	}
+	d.normalized[key] = text
+	d.kept = append(d.kept, key)
+}
+
+// normalizePhrase returns the text in Unicode NFKC form, case folded and without punctuation
+// or symbols, with its words separated by single spaces, so I don't know! and i dont know
+// are the same
+func normalizePhrase(text string) string {
+	text = cases.Fold().String(norm.NFKC.String(text))
+	text = strings.Map(func(r rune) rune {
+		if unicode.IsPunct(r) || unicode.IsSymbol(r) {
+			return -1
+		}
+		return r
+	}, text)
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// similarPhrases checks if two normalized phrases have a similarity of at least the
+// threshold, which is one minus their edit distance in characters divided by the length of
+// the longer one or the Jaccard similarity of their words. The edit distance is not measured
+// when the difference in length is already too big.
+func similarPhrases(a, b string, threshold float64) bool {
+	if wordJaccard(a, b) >= threshold {
+		return true
+	}
+	lengthA, lengthB := utf8.RuneCountInString(a), utf8.RuneCountInString(b)
+	longest := max(lengthA, lengthB)
+	if longest == 0 {
+		return true
+	}
+	if 1-float64(max(lengthA-lengthB, lengthB-lengthA))/float64(longest) < threshold {
+		return false
+	}
+	return editSimilarity(a, b) >= threshold
+}
+
+// editSimilarity is one minus the Levenshtein distance of the characters of the texts divided
+// by the length of the longer text
+func editSimilarity(a, b string) float64 {
+	longest := max(utf8.RuneCountInString(a), utf8.RuneCountInString(b))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein([]rune(a), []rune(b)))/float64(longest)
+}
+
+// levenshtein returns how many characters have to be inserted, deleted or replaced to change
+// a into b
+func levenshtein(a, b []rune) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
+
+// wordJaccard is how many words the texts share divided by how many different words they have
+func wordJaccard(a, b string) float64 {
+	words := make(map[string]int)
+	for _, w := range strings.Fields(a) {
+		words[w] |= 1
+	}
+	for _, w := range strings.Fields(b) {
+		words[w] |= 2
+	}
+	if len(words) == 0 {
+		return 1
+	}
+	shared := 0
+	for _, in := range words {
+		if in == 3 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(words))
+}
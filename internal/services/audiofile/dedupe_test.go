@@ -0,0 +1,131 @@
+package audiofile
+
+import (
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNormalizePhrase(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{input: "I don't know.", expected: "i dont know"},
+		{input: "  I  DON’T know!! ", expected: "i dont know"},
+		{input: "Ｈｅｌｌｏ， ｗｏｒｌｄ", expected: "hello world"},
+		{input: "Straße — ¿qué tal?", expected: "strasse qué tal"},
+		{input: "こんにちは。", expected: "こんにちは"},
+	}
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, normalizePhrase(tt.input), tt.input)
+	}
+}
+
+func TestSimilarPhrases(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name      string
+		a, b      string
+		threshold float64
+		expected  bool
+	}{
+		{name: "one letter apart", a: "where is the train station", b: "where is the train stations", threshold: 0.9, expected: true},
+		{name: "same words in another order", a: "i know you dont", b: "you dont i know", threshold: 0.9, expected: true},
+		{name: "a negation", a: "i am going home", b: "i am not going home", threshold: 0.9, expected: false},
+		{name: "a negation with a low threshold", a: "i am going home", b: "i am not going home", threshold: 0.75, expected: true},
+		{name: "different phrases", a: "where is the train station", b: "how much does this cost", threshold: 0.5, expected: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, similarPhrases(tt.a, tt.b, tt.threshold))
+			require.Equal(t, tt.expected, similarPhrases(tt.b, tt.a, tt.threshold))
+		})
+	}
+	require.Equal(t, 2, levenshtein([]rune("kitten"), []rune("kitchen")))
+}
+
+func TestUniquePhrasesDedupe(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	cues := untimedCues([]string{
+		"I don't know.",
+		"Where is the train station?",
+		"I don't know!",
+		"i dont know",
+		"Where is the train stations?",
+		"I don't know.",
+	})
+
+	tests := []struct {
+		name      string
+		opts      interfaces.ParseOptions
+		texts     []string
+		discarded []interfaces.DiscardedLine
+	}{
+		{
+			name:  "exact",
+			opts:  interfaces.ParseOptions{Dedupe: interfaces.DedupeExact},
+			texts: []string{"I don't know.", "Where is the train station?", "I don't know!", "i dont know", "Where is the train stations?"},
+			discarded: []interfaces.DiscardedLine{
+				{Text: "I don't know.", Reason: interfaces.DiscardDuplicate},
+			},
+		},
+		{
+			name:  "normalized",
+			opts:  interfaces.ParseOptions{Dedupe: interfaces.DedupeNormalized},
+			texts: []string{"I don't know.", "Where is the train station?", "Where is the train stations?"},
+			discarded: []interfaces.DiscardedLine{
+				{Text: "I don't know!", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "I don't know."},
+				{Text: "i dont know", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "I don't know."},
+				{Text: "I don't know.", Reason: interfaces.DiscardDuplicate},
+			},
+		},
+		{
+			name:  "fuzzy",
+			opts:  interfaces.ParseOptions{Dedupe: interfaces.DedupeFuzzy},
+			texts: []string{"I don't know.", "Where is the train station?"},
+			discarded: []interfaces.DiscardedLine{
+				{Text: "I don't know!", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "I don't know."},
+				{Text: "i dont know", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "I don't know."},
+				{Text: "Where is the train stations?", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "Where is the train station?"},
+				{Text: "I don't know.", Reason: interfaces.DiscardDuplicate},
+			},
+		},
+		{
+			name:  "fuzzy with a high similarity",
+			opts:  interfaces.ParseOptions{Dedupe: interfaces.DedupeFuzzy, Similarity: 0.99},
+			texts: []string{"I don't know.", "Where is the train station?", "Where is the train stations?"},
+			discarded: []interfaces.DiscardedLine{
+				{Text: "I don't know!", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "I don't know."},
+				{Text: "i dont know", Reason: interfaces.DiscardNearDuplicate, DuplicateOf: "I don't know."},
+				{Text: "I don't know.", Reason: interfaces.DiscardDuplicate},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCleanup([]string{"none"})
+			phrases, _ := uniquePhrases(cues, 150, newDedupe(tt.opts), c)
+			var texts []string
+			for i, phrase := range phrases {
+				// the phrases are numbered in the order they first appear
+				require.Equal(t, i, phrase.ID)
+				texts = append(texts, phrase.Text)
+			}
+			require.Equal(t, tt.texts, texts)
+			require.Equal(t, tt.discarded, c.discards())
+		})
+	}
+}
@@ -0,0 +1,367 @@
+package audiofile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"maps"
+	"mime/multipart"
+	"slices"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "docx",
+		format:     Docx,
+		binary:     true,
+		mimeTypes:  []string{"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		extensions: []string{".docx"},
+		sniff:      sniffIf(isDocx, certainConfidence),
+		parse: parsedCues(func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseDocument(f, Docx, opts, seg)
+		}),
+	})
+	registerParser(formatParser{
+		name:       "odt",
+		format:     Odt,
+		binary:     true,
+		mimeTypes:  []string{"application/vnd.oasis.opendocument.text"},
+		extensions: []string{".odt"},
+		sniff:      sniffIf(isOdt, certainConfidence),
+		parse: parsedCues(func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseDocument(f, Odt, opts, seg)
+		}),
+	})
+}
+
+// odtMimetype is the content of the mimetype file of an OpenDocument text document
+const odtMimetype = "application/vnd.oasis.opendocument.text"
+
+// odtSkippedElements have text that is not read, like comments, deleted text and the
+// placeholder page numbers of headers and footers
+var odtSkippedElements = []string{"annotation", "tracked-changes", "page-number", "page-count"}
+
+// documentPart is the text of a part of a document as its paragraphs and list items
+type documentPart []string
+
+// isDocx checks if the content is a Word document
+func isDocx(content []byte) bool {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	return slices.ContainsFunc(reader.File, func(f *zip.File) bool { return f.Name == "word/document.xml" })
+}
+
+// isOdt checks if the content is an OpenDocument text document like the files of LibreOffice
+// Writer
+func isOdt(content []byte) bool {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	for _, f := range reader.File {
+		if f.Name == "mimetype" {
+			mimetype, err := readZipFile(f, len(odtMimetype)+2)
+			return err == nil && strings.TrimSpace(string(mimetype)) == odtMimetype
+		}
+	}
+	return false
+}
+
+// parseDocument takes a docx or odt file and returns the phrases of its paragraphs and list
+// items. A document of short lines like a vocabulary list is parsed like a file of one
+// phrase per line and other documents like a file in paragraph form, so each paragraph
+// still ends a phrase. The headers, footers, footnotes and endnotes are added before and
+// after the body unless opts.SkipNotes is set.
+func parseDocument(f io.Reader, fileType TextFormat, opts interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+
+	var paragraphs []string
+	if fileType == Docx {
+		paragraphs, err = docxParagraphs(reader, opts.SkipNotes)
+	} else {
+		paragraphs, err = odtParagraphs(reader, opts.SkipNotes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if isOnePhrasePerLine(paragraphs) {
+		return untimedCues(parseSingle(strings.NewReader(strings.Join(paragraphs, "\n")), seg)), nil
+	}
+	return untimedCues(parseParagraph(strings.NewReader(strings.Join(paragraphs, "\n\n")), seg)), nil
+}
+
+// docxParagraphs returns the paragraphs of word/document.xml with the paragraphs of the
+// headers before them and the footnotes, endnotes and footers after them
+func docxParagraphs(reader *zip.Reader, skipNotes bool) ([]string, error) {
+	parts := make(map[string]*zip.File)
+	for _, f := range reader.File {
+		parts[f.Name] = f
+	}
+	body, err := readDocxPart(parts["word/document.xml"])
+	if err != nil {
+		return nil, err
+	}
+	if skipNotes {
+		return body, nil
+	}
+
+	var headers, footers []string
+	for _, name := range slices.Sorted(maps.Keys(parts)) {
+		var part documentPart
+		switch {
+		case strings.HasPrefix(name, "word/header") && strings.HasSuffix(name, ".xml"):
+			part, err = readDocxPart(parts[name])
+			headers = append(headers, part...)
+		case strings.HasPrefix(name, "word/footer") && strings.HasSuffix(name, ".xml"):
+			part, err = readDocxPart(parts[name])
+			footers = append(footers, part...)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	paragraphs := append(headers, body...)
+	for _, name := range []string{"word/footnotes.xml", "word/endnotes.xml"} {
+		if parts[name] == nil {
+			continue
+		}
+		notes, err := readDocxPart(parts[name])
+		if err != nil {
+			return nil, err
+		}
+		paragraphs = append(paragraphs, notes...)
+	}
+	return append(paragraphs, footers...), nil
+}
+
+// odtParagraphs returns the paragraphs, headings and list items of content.xml with the
+// headers of styles.xml before them and the notes and footers after them
+func odtParagraphs(reader *zip.Reader, skipNotes bool) ([]string, error) {
+	var content, styles *zip.File
+	for _, f := range reader.File {
+		switch f.Name {
+		case "content.xml":
+			content = f
+		case "styles.xml":
+			styles = f
+		}
+	}
+	data, err := readDocumentPart(content)
+	if err != nil {
+		return nil, err
+	}
+	body, notes := odtText(data)
+	if skipNotes {
+		return body, nil
+	}
+
+	var headers, footers documentPart
+	if styles != nil {
+		data, err := readDocumentPart(styles)
+		if err != nil {
+			return nil, err
+		}
+		headers, footers = odtHeadersAndFooters(data)
+	}
+	paragraphs := append(headers, body...)
+	paragraphs = append(paragraphs, notes...)
+	return append(paragraphs, footers...), nil
+}
+
+// readDocumentPart returns the content of the part of the document
+func readDocumentPart(f *zip.File) ([]byte, error) {
+	if f == nil {
+		return nil, errors.New("document has no text")
+	}
+	return readZipFile(f, maxEntrySize)
+}
+
+// readDocxPart returns the paragraphs of the part of a Word document
+func readDocxPart(f *zip.File) (documentPart, error) {
+	data, err := readDocumentPart(f)
+	if err != nil {
+		return nil, err
+	}
+	return docxText(data)
+}
+
+// docxText returns the text of the <w:p> paragraphs of a part of a Word document. Tabs and
+// line breaks become spaces, and deleted text, field codes and the fallback copies of
+// drawings are not read.
+func docxText(data []byte) (documentPart, error) {
+	decoder := xmlDecoder(bytes.NewReader(data))
+	var paragraphs documentPart
+	var paragraph strings.Builder
+	inText := false
+	fallback := 0
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				paragraphs = paragraphs.add(paragraph.String())
+				paragraph.Reset()
+			case "t":
+				inText = fallback == 0
+			case "tab", "br", "cr":
+				paragraph.WriteString(" ")
+			case "Fallback":
+				fallback++
+			}
+		case xml.CharData:
+			if inText {
+				paragraph.Write(t)
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "p":
+				paragraphs = paragraphs.add(paragraph.String())
+				paragraph.Reset()
+			case "t":
+				inText = false
+			case "Fallback":
+				fallback--
+			}
+		}
+	}
+	return paragraphs.add(paragraph.String()), nil
+}
+
+// odtText returns the text of the paragraphs, headings and list items of the content.xml of
+// an OpenDocument text document and, separately, the text of its footnotes and endnotes.
+// Comments and tracked changes are not read.
+func odtText(data []byte) (documentPart, documentPart) {
+	var body, notes documentPart
+	walkOdt(data, func(name string) bool {
+		return name == "text"
+	}, &body, &notes)
+	return body, notes
+}
+
+// odtHeadersAndFooters returns the text of the headers and footers of the master pages of
+// the styles.xml of an OpenDocument text document
+func odtHeadersAndFooters(data []byte) (documentPart, documentPart) {
+	var headers, footers, notes documentPart
+	walkOdt(data, func(name string) bool {
+		return name == "header" || name == "header-left" || name == "header-first"
+	}, &headers, &notes)
+	walkOdt(data, func(name string) bool {
+		return name == "footer" || name == "footer-left" || name == "footer-first"
+	}, &footers, &notes)
+	return headers, footers
+}
+
+// walkOdt adds the text of the <text:p> and <text:h> elements inside the elements chosen by
+// inside to paragraphs and the text of the notes inside them to notes. A note is in the
+// middle of the paragraph it belongs to so the paragraph continues after it.
+func walkOdt(data []byte, inside func(string) bool, paragraphs, notes *documentPart) {
+	decoder := xmlDecoder(bytes.NewReader(data))
+	var paragraph, note strings.Builder
+	depth := 0
+	inNote, inCitation := false, false
+	skipped := 0
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			switch {
+			case inside(name):
+				depth++
+			case depth == 0:
+			case name == "note":
+				inNote = true
+			case name == "note-citation":
+				inCitation = true
+			case slices.Contains(odtSkippedElements, name):
+				skipped++
+			case skipped > 0:
+			case name == "p" || name == "h":
+				if inNote {
+					*notes = notes.add(note.String())
+					note.Reset()
+				} else {
+					*paragraphs = paragraphs.add(paragraph.String())
+					paragraph.Reset()
+				}
+			case name == "s":
+				count, err := strconv.Atoi(xmlAttr(t, "c"))
+				if err != nil || count < 1 {
+					count = 1
+				}
+				writeOdt(&paragraph, &note, inNote, strings.Repeat(" ", count))
+			case name == "tab" || name == "line-break":
+				writeOdt(&paragraph, &note, inNote, " ")
+			}
+		case xml.CharData:
+			if depth > 0 && skipped == 0 && !inCitation {
+				writeOdt(&paragraph, &note, inNote, string(t))
+			}
+		case xml.EndElement:
+			name := t.Name.Local
+			switch {
+			case inside(name):
+				depth--
+			case depth == 0:
+			case name == "note":
+				*notes = notes.add(note.String())
+				note.Reset()
+				inNote = false
+			case name == "note-citation":
+				inCitation = false
+			case slices.Contains(odtSkippedElements, name):
+				skipped--
+			case skipped > 0:
+			case (name == "p" || name == "h") && inNote:
+				*notes = notes.add(note.String())
+				note.Reset()
+			case name == "p" || name == "h":
+				*paragraphs = paragraphs.add(paragraph.String())
+				paragraph.Reset()
+			}
+		}
+	}
+	*paragraphs = paragraphs.add(paragraph.String())
+}
+
+// writeOdt writes the text to the note if it is in a note or else to the paragraph
+func writeOdt(paragraph, note *strings.Builder, inNote bool, text string) {
+	if inNote {
+		note.WriteString(text)
+		return
+	}
+	paragraph.WriteString(text)
+}
+
+// add adds the paragraph with its spaces collapsed if it has text
+func (d documentPart) add(paragraph string) documentPart {
+	if text := strings.Join(strings.Fields(paragraph), " "); text != "" {
+		return append(d, text)
+	}
+	return d
+}
@@ -0,0 +1,142 @@
+package audiofile
+
+import (
+	"os"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDocument(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		fileType TextFormat
+		opts     interfaces.ParseOptions
+		expected []string
+	}{
+		{
+			name:     "docx in paragraph form",
+			file:     "testdata/lesson.docx",
+			fileType: Docx,
+			expected: []string{
+				"Curso de español para principiantes",
+				"Mi familia vive en una casa grande cerca del río.",
+				"Los domingos comemos todos juntos en el jardín.",
+				"Mi hermano toca la guitarra y mi hermana canta muy bien.",
+				// each list item is its own phrase
+				"Ir al mercado los sábados",
+				"Limpiar la cocina después de comer",
+				// the text box is only read once
+				"Una nota en una caja de texto.",
+				"El río se llama Guadalquivir y es muy largo.",
+				"Página de la escuela de idiomas",
+			},
+		},
+		{
+			name:     "docx without notes",
+			file:     "testdata/lesson.docx",
+			fileType: Docx,
+			opts:     interfaces.ParseOptions{SkipNotes: true},
+			expected: []string{
+				"Mi familia vive en una casa grande cerca del río.",
+				"Los domingos comemos todos juntos en el jardín.",
+				"Mi hermano toca la guitarra y mi hermana canta muy bien.",
+				"Ir al mercado los sábados",
+				"Limpiar la cocina después de comer",
+				"Una nota en una caja de texto.",
+			},
+		},
+		{
+			name:     "odt list of one phrase per line",
+			file:     "testdata/vocabulary.odt",
+			fileType: Odt,
+			expected: []string{
+				"Unidad tres de la clase",
+				"Vocabulario de la cocina",
+				"el cuchillo the knife",
+				"la cuchara de madera",
+				"el tenedor de plata",
+				"También se dice trinche en México.",
+			},
+		},
+		{
+			name:     "odt without notes",
+			file:     "testdata/vocabulary.odt",
+			fileType: Odt,
+			opts:     interfaces.ParseOptions{SkipNotes: true},
+			expected: []string{
+				"Vocabulario de la cocina",
+				"el cuchillo the knife",
+				"la cuchara de madera",
+				"el tenedor de plata",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			cues, err := parseDocument(f, tt.fileType, tt.opts, segmenter{})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cueTexts(cues))
+		})
+	}
+}
+
+func TestDocxText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		xml      string
+		expected documentPart
+	}{
+		{
+			name:     "runs and tabs",
+			xml:      `<w:body><w:p><w:r><w:t>Hola</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">mundo </w:t></w:r></w:p><w:p><w:r><w:t>Adiós</w:t></w:r></w:p></w:body>`,
+			expected: documentPart{"Hola mundo", "Adiós"},
+		},
+		{
+			name:     "deleted text and field codes",
+			xml:      `<w:p><w:r><w:instrText>PAGE</w:instrText></w:r><w:del><w:r><w:delText>no</w:delText></w:r></w:del><w:r><w:t>sí</w:t></w:r></w:p>`,
+			expected: documentPart{"sí"},
+		},
+		{
+			name: "empty paragraphs",
+			xml:  `<w:p/><w:p><w:r><w:t> </w:t></w:r></w:p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paragraphs, err := docxText([]byte(tt.xml))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, paragraphs)
+		})
+	}
+}
+
+func TestOdtText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	content := `<office:body><office:text><text:p>Uno<text:s text:c="3"/>dos<text:line-break/>tres</text:p>` +
+		`<text:p>La nota<text:note><text:note-citation>1</text:note-citation><text:note-body><text:p>Es una nota.</text:p></text:note-body></text:note> sigue aquí.</text:p>` +
+		`<text:p>Sin <office:annotation><text:p>comentario</text:p></office:annotation>comentarios</text:p></office:text></office:body>`
+	body, notes := odtText([]byte(content))
+	require.Equal(t, documentPart{"Uno dos tres", "La nota sigue aquí.", "Sin comentarios"}, body)
+	require.Equal(t, documentPart{"Es una nota."}, notes)
+}
@@ -0,0 +1,279 @@
+package audiofile
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"io"
+	"mime/multipart"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// EncodingUTF8 is the name reported for files that are already UTF-8 (or plain ascii)
+	EncodingUTF8 = "utf-8"
+	// legacyPunctuation are the quotes, dashes and ellipsis in the 0x80 to 0x9f range of
+	// the windows code pages
+	legacyPunctuation = "“”‘’„–—…«»€"
+)
+
+// legacyEncoding is an encoding that files are tried in when they are not valid UTF-8, with
+// the frequent characters and the scripts of the languages that use it
+type legacyEncoding struct {
+	name    string
+	enc     encoding.Encoding
+	common  string
+	scripts []*unicode.RangeTable
+}
+
+// legacyEncodings are tried in order and the first one with the best score is used
+var legacyEncodings = []legacyEncoding{
+	{
+		name: "shift_jis", enc: japanese.ShiftJIS,
+		common:  japaneseCommon,
+		scripts: []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana},
+	},
+	{
+		name: "euc-jp", enc: japanese.EUCJP,
+		common:  japaneseCommon,
+		scripts: []*unicode.RangeTable{unicode.Hiragana, unicode.Katakana},
+	},
+	{
+		name: "gbk", enc: simplifiedchinese.GBK,
+		common:  simplifiedChineseCommon,
+		scripts: []*unicode.RangeTable{unicode.Han},
+	},
+	{
+		name: "big5", enc: traditionalchinese.Big5,
+		common:  traditionalChineseCommon,
+		scripts: []*unicode.RangeTable{unicode.Han},
+	},
+	{
+		name: "euc-kr", enc: korean.EUCKR,
+		common:  koreanCommon,
+		scripts: []*unicode.RangeTable{unicode.Hangul},
+	},
+	{
+		name: "windows-1252", enc: charmap.Windows1252,
+		common:  legacyPunctuation + "éèàùâêîôûçëïüœæÉÈÀÇáíóúñÁÍÓÚÑ¿¡ãõÃÕäößÄÖÜåøÅØÆ°",
+		scripts: []*unicode.RangeTable{unicode.Latin},
+	},
+	{
+		name: "windows-1250", enc: charmap.Windows1250,
+		common:  legacyPunctuation + "ąćęłńóśźżĄĆĘŁŃÓŚŹŻčďěňřšťůžČĎĚŇŘŠŤŮŽáéíóúýőűÁÉÍÓÚÝŐŰäöüß",
+		scripts: []*unicode.RangeTable{unicode.Latin},
+	},
+	{
+		// Hebrew is tried before Cyrillic because its letters decode to lower case Cyrillic
+		name: "windows-1255", enc: charmap.Windows1255,
+		common:  legacyPunctuation + "יוהלארתבמנשעםכדקןפצסגךחטזףץ",
+		scripts: []*unicode.RangeTable{unicode.Hebrew},
+	},
+	{
+		name: "windows-1251", enc: charmap.Windows1251,
+		common:  legacyPunctuation + "оеаинтсрвлкмдпуяыьгзбчйхжшюцщэфъёієїґ",
+		scripts: []*unicode.RangeTable{unicode.Cyrillic},
+	},
+	{
+		name: "iso-8859-7", enc: charmap.ISO8859_7,
+		common:  "αοιετσνηυρπκμλωγδχθφβξζψςάέήίόύώ«»",
+		scripts: []*unicode.RangeTable{unicode.Greek},
+	},
+	{
+		name: "windows-1256", enc: charmap.Windows1256,
+		common:  legacyPunctuation + "الميونرتبةعدسفهكقحجشصطخذىئءأإآؤزثضظغ،؛؟",
+		scripts: []*unicode.RangeTable{unicode.Arabic},
+	},
+}
+
+// japaneseCommon, simplifiedChineseCommon, traditionalChineseCommon and koreanCommon are
+// the punctuation, kana and most frequent characters of the languages
+var (
+	//go:embed encodingdata/japanese.txt
+	japaneseCommon string
+	//go:embed encodingdata/simplified_chinese.txt
+	simplifiedChineseCommon string
+	//go:embed encodingdata/traditional_chinese.txt
+	traditionalChineseCommon string
+	//go:embed encodingdata/korean.txt
+	koreanCommon string
+)
+
+// transcodedFile is an uploaded file that has been decoded to UTF-8 in memory
+type transcodedFile struct {
+	*bytes.Reader
+}
+
+// Close does nothing because the content is in memory
+func (transcodedFile) Close() error {
+	return nil
+}
+
+// DecodeFile reads the uploaded file and returns it decoded to UTF-8 along with the name
+// of the encoding it was decoded from. When name is empty the encoding is detected from
+// the byte order mark or the content, otherwise name is a WHATWG encoding label like
+// shift_jis, gbk, latin1 or utf-16le.
+func DecodeFile(f multipart.File, name string) (multipart.File, string, error) {
+	if f == nil {
+		return nil, "", errors.New("file is nil")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, "", err
+	}
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var enc encoding.Encoding
+	if name != "" {
+		if enc, err = htmlindex.Get(name); err != nil {
+			return nil, "", err
+		}
+		if name, err = htmlindex.Name(enc); err != nil {
+			return nil, "", err
+		}
+		// a byte order mark overrides the label like it does in browsers
+		if bomName, bomEnc := detectBom(content); bomEnc != nil {
+			name, enc = bomName, bomEnc
+		}
+	} else {
+		name, enc = detectEncoding(content)
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(content)
+	if err != nil {
+		return nil, "", err
+	}
+	decoded = bytes.TrimPrefix(decoded, []byte("\ufeff"))
+
+	return transcodedFile{bytes.NewReader(decoded)}, name, nil
+}
+
+// detectEncoding returns the name and encoding of the content from its byte order mark, as
+// UTF-8 if it is valid or as the legacy encoding whose decoded text scores the best
+func detectEncoding(content []byte) (string, encoding.Encoding) {
+	if name, enc := detectBom(content); enc != nil {
+		return name, enc
+	}
+	if name, enc := detectUTF16(content); enc != nil {
+		return name, enc
+	}
+	if utf8.Valid(content) {
+		return EncodingUTF8, encoding.Nop
+	}
+
+	best, bestScore := legacyEncodings[0], -1.0
+	for _, candidate := range legacyEncodings {
+		decoded, err := candidate.enc.NewDecoder().Bytes(content)
+		if err != nil {
+			continue
+		}
+		if score := candidate.score(string(decoded)); score > bestScore {
+			best, bestScore = candidate, score
+		}
+	}
+	if bestScore <= 0 {
+		// windows-1252 can decode any byte so it is used when nothing else fits
+		return "windows-1252", charmap.Windows1252
+	}
+
+	return best.name, best.enc
+}
+
+// detectBom returns the encoding of the byte order mark at the start of the content or
+// a nil encoding if there is none
+func detectBom(content []byte) (string, encoding.Encoding) {
+	switch {
+	case bytes.HasPrefix(content, []byte{0xef, 0xbb, 0xbf}):
+		return EncodingUTF8, encoding.Nop
+	case bytes.HasPrefix(content, []byte{0xff, 0xfe}):
+		return "utf-16le", xunicode.UTF16(xunicode.LittleEndian, xunicode.ExpectBOM)
+	case bytes.HasPrefix(content, []byte{0xfe, 0xff}):
+		return "utf-16be", xunicode.UTF16(xunicode.BigEndian, xunicode.ExpectBOM)
+	}
+	return "", nil
+}
+
+// detectUTF16 checks for UTF-16 without a byte order mark, where most of the ascii text
+// has a zero byte before (big endian) or after (little endian) every character
+func detectUTF16(content []byte) (string, encoding.Encoding) {
+	if len(content) < 4 || len(content)%2 != 0 {
+		return "", nil
+	}
+	var evenZeros, oddZeros int
+	for i := 0; i < len(content); i += 2 {
+		if content[i] == 0 {
+			evenZeros++
+		}
+		if content[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	half := len(content) / 2
+	switch {
+	case oddZeros*10 > half*6 && evenZeros*10 < half:
+		return "utf-16le", xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM)
+	case evenZeros*10 > half*6 && oddZeros*10 < half:
+		return "utf-16be", xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM)
+	}
+	return "", nil
+}
+
+// score rates from 0 to 1 how much the decoded text looks like text in the encoding. Text
+// with replacement or control characters scores -1.
+func (le legacyEncoding) score(text string) float64 {
+	var total, points float64
+	for _, word := range strings.FieldsFunc(text, isWordBreak) {
+		var ascii, other int
+		for _, r := range word {
+			if r < utf8.RuneSelf {
+				ascii++
+			} else {
+				other++
+			}
+		}
+		var previous rune
+		for _, r := range word {
+			if r < utf8.RuneSelf {
+				previous = r
+				continue
+			}
+			if r == utf8.RuneError || unicode.IsControl(r) {
+				return -1
+			}
+			total++
+			switch {
+			// text decoded with the wrong code page has capitals after lower case letters
+			// and latin letters in words that are mostly not ascii
+			case unicode.IsUpper(r) && unicode.IsLower(previous):
+			case unicode.In(r, unicode.Latin) && other > ascii+1:
+			case strings.ContainsRune(le.common, r):
+				points++
+			case unicode.IsLetter(r) && unicode.In(r, le.scripts...):
+				points += 0.5
+			}
+			previous = r
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	return points / total
+}
+
+// isWordBreak splits text into words for scoring
+func isWordBreak(r rune) bool {
+	return r < utf8.RuneSelf && !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
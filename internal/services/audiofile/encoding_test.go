@@ -0,0 +1,137 @@
+package audiofile
+
+import (
+	"bytes"
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/encoding/traditionalchinese"
+	xunicode "golang.org/x/text/encoding/unicode"
+	"io"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestDetectEncoding tests detecting the encoding of text in the legacy encodings that
+// subtitle files are commonly saved in
+func TestDetectEncoding(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		enc      encoding.Encoding
+		text     string
+		expected string
+	}{
+		{name: "ascii", enc: encoding.Nop, text: "This is the first sentence.", expected: "utf-8"},
+		{name: "utf-8", enc: encoding.Nop, text: "今日はいい天気ですね。", expected: "utf-8"},
+		{name: "shift_jis", enc: japanese.ShiftJIS, text: "私は昨日友達と一緒に東京へ行きました。", expected: "shift_jis"},
+		{name: "euc-jp", enc: japanese.EUCJP, text: "私は昨日友達と一緒に東京へ行きました。", expected: "euc-jp"},
+		{name: "gbk", enc: simplifiedchinese.GBK, text: "我们今天去上海看朋友，他们都很高兴。", expected: "gbk"},
+		{name: "big5", enc: traditionalchinese.Big5, text: "我們今天去台北看朋友，他們都很高興。", expected: "big5"},
+		{name: "euc-kr", enc: korean.EUCKR, text: "오늘은 날씨가 정말 좋네요. 친구를 만나러 갑니다.", expected: "euc-kr"},
+		{name: "windows-1252 french", enc: charmap.Windows1252, text: "Je suis allé au marché, c'était très agréable.", expected: "windows-1252"},
+		{name: "windows-1252 german", enc: charmap.Windows1252, text: "Über den Wolken muss die Freiheit wohl grenzenlos sein.", expected: "windows-1252"},
+		{name: "windows-1250", enc: charmap.Windows1250, text: "Dziękuję bardzo, to było świetne spotkanie.", expected: "windows-1250"},
+		{name: "windows-1251", enc: charmap.Windows1251, text: "Привет, как дела? Сегодня хорошая погода.", expected: "windows-1251"},
+		{name: "iso-8859-7", enc: charmap.ISO8859_7, text: "Καλημέρα, πώς είσαι; Σήμερα έχει ωραίο καιρό.", expected: "iso-8859-7"},
+		{name: "windows-1255", enc: charmap.Windows1255, text: "שלום, מה שלומך? היום מזג האוויר יפה.", expected: "windows-1255"},
+		{name: "windows-1256", enc: charmap.Windows1256, text: "مرحبا، كيف حالك؟ الطقس جميل اليوم.", expected: "windows-1256"},
+		{name: "utf-16le bom", enc: xunicode.UTF16(xunicode.LittleEndian, xunicode.UseBOM), text: "Hello there", expected: "utf-16le"},
+		{name: "utf-16be bom", enc: xunicode.UTF16(xunicode.BigEndian, xunicode.UseBOM), text: "Hello there", expected: "utf-16be"},
+		{name: "utf-16le without bom", enc: xunicode.UTF16(xunicode.LittleEndian, xunicode.IgnoreBOM), text: "Hello there", expected: "utf-16le"},
+		{name: "utf-16be without bom", enc: xunicode.UTF16(xunicode.BigEndian, xunicode.IgnoreBOM), text: "Hello there", expected: "utf-16be"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := tt.enc.NewEncoder().Bytes([]byte(tt.text))
+			require.NoError(t, err)
+
+			name, enc := detectEncoding(content)
+			assert.Equal(t, tt.expected, name)
+
+			decoded, err := enc.NewDecoder().Bytes(content)
+			require.NoError(t, err)
+			assert.Contains(t, string(decoded), tt.text)
+		})
+	}
+}
+
+// TestDecodeFile tests decoding an uploaded file with a detected or declared encoding
+func TestDecodeFile(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name        string
+		content     []byte
+		label       string
+		expected    string
+		expectedEnc string
+		expectError bool
+	}{
+		{
+			name:        "utf-8 bom is removed",
+			content:     []byte("\ufeffThis is the first sentence."),
+			expected:    "This is the first sentence.",
+			expectedEnc: "utf-8",
+		},
+		{
+			name:        "windows-1252 is detected",
+			content:     []byte("caf\xe9 cr\xe8me"),
+			expected:    "café crème",
+			expectedEnc: "windows-1252",
+		},
+		{
+			name:        "label is used",
+			content:     []byte("\x8d\xa1\x93\xfa"),
+			label:       "Shift_JIS",
+			expected:    "今日",
+			expectedEnc: "shift_jis",
+		},
+		{
+			name:        "label alias",
+			content:     []byte("caf\xe9"),
+			label:       "latin1",
+			expected:    "café",
+			expectedEnc: "windows-1252",
+		},
+		{
+			name:        "byte order mark overrides the label",
+			content:     []byte("\xff\xfeH\x00i\x00"),
+			label:       "windows-1252",
+			expected:    "Hi",
+			expectedEnc: "utf-16le",
+		},
+		{
+			name:        "unknown label",
+			content:     []byte("Hello"),
+			label:       "klingon",
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := transcodedFile{Reader: bytes.NewReader(tt.content)}
+			decoded, enc, err := DecodeFile(f, tt.label)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedEnc, enc)
+
+			content, err := io.ReadAll(decoded)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, string(content))
+		})
+	}
+}
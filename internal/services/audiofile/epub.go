@@ -0,0 +1,458 @@
+package audiofile
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+	"path"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "epub",
+		format:     Epub,
+		binary:     true,
+		mimeTypes:  []string{"application/epub+zip"},
+		extensions: []string{".epub"},
+		sniff:      sniffIf(isEpub, certainConfidence),
+		parse: func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) (parsed, error) {
+			cues, chapters, err := parseEpub(f, opts, seg)
+			return parsed{cues: cues, chapters: chapters}, err
+		},
+	})
+}
+
+// epubMimetype is the content of the mimetype file of an EPUB
+const epubMimetype = "application/epub+zip"
+
+var (
+	// epubBlockElements end a paragraph of the text of a chapter
+	epubBlockElements = []string{
+		"p", "div", "section", "article", "blockquote", "li", "dt", "dd", "tr", "pre",
+		"h1", "h2", "h3", "h4", "h5", "h6", "hr", "table", "figcaption",
+	}
+	// epubSkippedElements have text that is not read, like the readings of ruby annotations
+	epubSkippedElements = []string{"head", "script", "style", "rt", "rp", "svg", "math"}
+	// epubHeadingElements are used as the title of a chapter that is not in the table of
+	// contents
+	epubHeadingElements = []string{"h1", "h2", "h3"}
+)
+
+// epubContainer is META-INF/container.xml, which has the path of the package document
+type epubContainer struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// epubPackage is the manifest and spine of the package document (OPF)
+type epubPackage struct {
+	Manifest []epubItem `xml:"manifest>item"`
+	Spine    struct {
+		Toc      string `xml:"toc,attr"`
+		ItemRefs []struct {
+			IDRef  string `xml:"idref,attr"`
+			Linear string `xml:"linear,attr"`
+		} `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// epubItem is a file of the book in the manifest
+type epubItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+// epubChapter is the text of a chapter of a book with its paragraphs separated by blank lines
+type epubChapter struct {
+	title string
+	text  string
+}
+
+// epubBook is the files of an EPUB by their path
+type epubBook map[string]*zip.File
+
+// isEpub checks if the content is an EPUB book
+func isEpub(content []byte) bool {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return false
+	}
+	for _, f := range reader.File {
+		if f.Name == "mimetype" {
+			mimetype, err := readZipFile(f, len(epubMimetype)+2)
+			return err == nil && strings.TrimSpace(string(mimetype)) == epubMimetype
+		}
+		if f.Name == "META-INF/container.xml" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseEpub takes an EPUB book and returns the phrases of the chosen chapters, or of every
+// chapter, and the list of the chapters of the book with how many phrases each has. The
+// text of each chapter is split into phrases like a file in paragraph form.
+func parseEpub(f io.Reader, opts interfaces.ParseOptions, seg segmenter) ([]cue, []interfaces.Chapter, error) {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, nil, err
+	}
+	chapters, err := readEpubChapters(content, maxTotalSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(chapters) == 0 {
+		return nil, nil, errors.New("epub has no chapters with text")
+	}
+	for _, n := range opts.Chapters {
+		if n > len(chapters) {
+			return nil, nil, fmt.Errorf("chapter %d not found, the book has %d chapters", n, len(chapters))
+		}
+	}
+
+	var cues []cue
+	list := make([]interfaces.Chapter, len(chapters))
+	for i, chapter := range chapters {
+		phrases := parseParagraph(strings.NewReader(chapter.text), seg)
+		list[i] = interfaces.Chapter{Number: i + 1, Title: chapter.title, Phrases: len(phrases)}
+		if len(opts.Chapters) == 0 || slices.Contains(opts.Chapters, i+1) {
+			cues = append(cues, untimedCues(phrases)...)
+		}
+	}
+	return cues, list, nil
+}
+
+// readEpubChapters reads the files of the spine of the book in order and names them with
+// the titles of the table of contents. A file that is not in the table of contents is part
+// of the chapter before it, because long chapters are often split into several files, and
+// files without text like the cover are skipped. It fails when the files of the spine are
+// larger than the limit together.
+func readEpubChapters(content []byte, limit int) ([]epubChapter, error) {
+	reader, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, err
+	}
+	book := make(epubBook)
+	for _, f := range reader.File {
+		book[f.Name] = f
+	}
+
+	packagePath, err := book.packagePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := book.read(packagePath)
+	if err != nil {
+		return nil, err
+	}
+	var pkg epubPackage
+	if err := xmlDecoder(bytes.NewReader(data)).Decode(&pkg); err != nil {
+		return nil, fmt.Errorf("invalid epub package document: %w", err)
+	}
+
+	base := path.Dir(packagePath)
+	items := make(map[string]epubItem)
+	for _, item := range pkg.Manifest {
+		items[item.ID] = item
+	}
+	titles := book.tableOfContents(pkg, items, base)
+
+	var chapters []epubChapter
+	// texts are the texts of the files of each chapter
+	var texts []*strings.Builder
+	for _, ref := range pkg.Spine.ItemRefs {
+		item, ok := items[ref.IDRef]
+		// the navigation document is often in the spine but is not part of the story
+		if !ok || ref.Linear == "no" || !strings.Contains(item.MediaType, "html") ||
+			slices.Contains(strings.Fields(item.Properties), "nav") {
+			continue
+		}
+		name := resolveEpubHref(base, item.Href)
+		data, err := book.read(name)
+		if err != nil {
+			return nil, err
+		}
+		if limit -= len(data); limit < 0 {
+			return nil, errors.New("epub is too large")
+		}
+		text, heading := epubText(data)
+		title, inContents := titles[name]
+		if text == "" {
+			continue
+		}
+		if !inContents && len(titles) > 0 && len(chapters) > 0 {
+			texts[len(texts)-1].WriteString("\n\n" + text)
+			continue
+		}
+		if title == "" {
+			title = heading
+		}
+		if title == "" {
+			title = strings.TrimSuffix(path.Base(name), path.Ext(name))
+		}
+		chapters = append(chapters, epubChapter{title: title})
+		texts = append(texts, &strings.Builder{})
+		texts[len(texts)-1].WriteString(text)
+	}
+	for i, text := range texts {
+		chapters[i].text = text.String()
+	}
+	return chapters, nil
+}
+
+// packagePath returns the path of the package document from META-INF/container.xml
+func (b epubBook) packagePath() (string, error) {
+	data, err := b.read("META-INF/container.xml")
+	if err != nil {
+		return "", err
+	}
+	var container epubContainer
+	if err := xmlDecoder(bytes.NewReader(data)).Decode(&container); err != nil {
+		return "", fmt.Errorf("invalid epub container: %w", err)
+	}
+	if len(container.Rootfiles) == 0 || container.Rootfiles[0].FullPath == "" {
+		return "", errors.New("epub container has no package document")
+	}
+	return container.Rootfiles[0].FullPath, nil
+}
+
+// read returns the content of the file of the book
+func (b epubBook) read(name string) ([]byte, error) {
+	f, ok := b[name]
+	if !ok {
+		return nil, fmt.Errorf("epub is missing %s", name)
+	}
+	return readZipFile(f, maxEntrySize)
+}
+
+// tableOfContents returns the titles of the chapters by the path of their file from the
+// navigation document of EPUB 3 or the toc.ncx of EPUB 2. It returns an empty map if the
+// book has neither.
+func (b epubBook) tableOfContents(pkg epubPackage, items map[string]epubItem, base string) map[string]string {
+	for _, item := range pkg.Manifest {
+		if slices.Contains(strings.Fields(item.Properties), "nav") {
+			navPath := resolveEpubHref(base, item.Href)
+			if data, err := b.read(navPath); err == nil {
+				if titles := epubNavTitles(data, path.Dir(navPath)); len(titles) > 0 {
+					return titles
+				}
+			}
+		}
+	}
+	ncx, ok := items[pkg.Spine.Toc]
+	if !ok {
+		for _, item := range pkg.Manifest {
+			if item.MediaType == "application/x-dtbncx+xml" {
+				ncx, ok = item, true
+			}
+		}
+	}
+	if ok {
+		ncxPath := resolveEpubHref(base, ncx.Href)
+		if data, err := b.read(ncxPath); err == nil {
+			return epubNcxTitles(data, path.Dir(ncxPath))
+		}
+	}
+	return map[string]string{}
+}
+
+// epubNavTitles returns the text of the links of the toc <nav> of an EPUB 3 navigation
+// document by the path of the file they link to. The first link to a file is its title.
+func epubNavTitles(data []byte, base string) map[string]string {
+	titles := make(map[string]string)
+	decoder := xmlDecoder(bytes.NewReader(data))
+	inToc := 0
+	href := ""
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return titles
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "nav" && (inToc > 0 || strings.Contains(xmlAttr(t, "type"), "toc")) {
+				inToc++
+			}
+			if t.Name.Local == "a" && inToc > 0 {
+				href = xmlAttr(t, "href")
+				text.Reset()
+			}
+		case xml.CharData:
+			if href != "" {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "nav" && inToc > 0 {
+				inToc--
+			}
+			if t.Name.Local == "a" && href != "" {
+				addEpubTitle(titles, base, href, text.String())
+				href = ""
+			}
+		}
+	}
+}
+
+// epubNcxTitles returns the labels of the navPoints of an EPUB 2 toc.ncx by the path of the
+// file they link to
+func epubNcxTitles(data []byte, base string) map[string]string {
+	titles := make(map[string]string)
+	decoder := xmlDecoder(bytes.NewReader(data))
+	inLabel := false
+	var label strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return titles
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "navLabel":
+				inLabel = true
+				label.Reset()
+			case "content":
+				addEpubTitle(titles, base, xmlAttr(t, "src"), label.String())
+			}
+		case xml.CharData:
+			if inLabel {
+				label.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "navLabel" {
+				inLabel = false
+			}
+		}
+	}
+}
+
+// addEpubTitle adds the title of the file of the link if it does not have one
+func addEpubTitle(titles map[string]string, base, href, title string) {
+	if href == "" {
+		return
+	}
+	name := resolveEpubHref(base, href)
+	if _, ok := titles[name]; !ok {
+		titles[name] = strings.Join(strings.Fields(title), " ")
+	}
+}
+
+// epubText returns the text of an XHTML file with its paragraphs separated by blank lines
+// and the text of its first heading
+func epubText(data []byte) (string, string) {
+	decoder := xmlDecoder(bytes.NewReader(data))
+	var paragraphs []string
+	var paragraph, heading strings.Builder
+	inHeading := false
+	headingDone := false
+	skipped := 0
+	endParagraph := func() {
+		if text := strings.Join(strings.Fields(paragraph.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		paragraph.Reset()
+	}
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			break
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case slices.Contains(epubSkippedElements, name):
+				skipped++
+			case name == "br":
+				paragraph.WriteString(" ")
+			case slices.Contains(epubBlockElements, name):
+				endParagraph()
+			}
+			if !headingDone && slices.Contains(epubHeadingElements, name) {
+				inHeading = true
+			}
+		case xml.CharData:
+			if skipped > 0 {
+				continue
+			}
+			paragraph.Write(t)
+			if inHeading {
+				heading.Write(t)
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			switch {
+			case slices.Contains(epubSkippedElements, name) && skipped > 0:
+				skipped--
+			case slices.Contains(epubBlockElements, name):
+				endParagraph()
+			}
+			if inHeading && slices.Contains(epubHeadingElements, name) {
+				inHeading = false
+				headingDone = heading.Len() > 0
+			}
+		}
+	}
+	endParagraph()
+	return strings.Join(paragraphs, "\n\n"), strings.Join(strings.Fields(heading.String()), " ")
+}
+
+// resolveEpubHref returns the path in the book of a link relative to the directory base
+// without its fragment
+func resolveEpubHref(base, href string) string {
+	href, _, _ = strings.Cut(href, "#")
+	if unescaped, err := url.PathUnescape(href); err == nil {
+		href = unescaped
+	}
+	return strings.TrimPrefix(path.Join(base, href), "./")
+}
+
+// xmlDecoder returns a decoder for the XML and XHTML of a book that accepts the entities
+// and unclosed tags of HTML
+func xmlDecoder(r io.Reader) *xml.Decoder {
+	decoder := xml.NewDecoder(r)
+	decoder.Strict = false
+	decoder.AutoClose = xml.HTMLAutoClose
+	decoder.Entity = xml.HTMLEntity
+	decoder.CharsetReader = utf8CharsetReader
+	return decoder
+}
+
+// xmlAttr returns the value of the attribute of the element with the local name
+func xmlAttr(t xml.StartElement, name string) string {
+	for _, attr := range t.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// readZipFile returns the content of a file of a zip that is at most limit bytes
+func readZipFile(f *zip.File, limit int) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, int64(limit)+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > limit {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return data, nil
+}
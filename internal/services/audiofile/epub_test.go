@@ -0,0 +1,161 @@
+package audiofile
+
+import (
+	"os"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEpub(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	bookChapters := []interfaces.Chapter{
+		{Number: 1, Title: "Capítulo uno: La llegada", Phrases: 3},
+		{Number: 2, Title: "Capítulo dos", Phrases: 2},
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		opts     interfaces.ParseOptions
+		expected []string
+		chapters []interfaces.Chapter
+		err      string
+	}{
+		{
+			name: "every chapter",
+			file: "testdata/book.epub",
+			expected: []string{
+				"Llegamos a la ciudad muy tarde por la noche.",
+				"El hotel estaba cerrado y no había nadie en la calle.",
+				// the second file of the chapter is not in the table of contents
+				"Por fin encontramos una pensión cerca de la estación de tren.",
+				"A la mañana siguiente fuimos al mercado con mi hermana.",
+				// the reading of the ruby annotation is not read
+				"漢字 no es español pero está aquí.",
+			},
+			chapters: bookChapters,
+		},
+		{
+			name: "chosen chapter",
+			file: "testdata/book.epub",
+			opts: interfaces.ParseOptions{Chapters: []int{2}},
+			expected: []string{
+				"A la mañana siguiente fuimos al mercado con mi hermana.",
+				"漢字 no es español pero está aquí.",
+			},
+			chapters: bookChapters,
+		},
+		{
+			name: "toc.ncx of epub 2",
+			file: "testdata/legacy.epub",
+			expected: []string{
+				"Il était une fois un petit garçon qui vivait à Paris.",
+				"Il aimait beaucoup les livres de son grand-père.",
+				"Un jour il est parti en voyage avec sa famille.",
+			},
+			chapters: []interfaces.Chapter{
+				{Number: 1, Title: "Première partie", Phrases: 2},
+				{Number: 2, Title: "Deuxième partie", Phrases: 1},
+			},
+		},
+		{
+			name: "chapter not found",
+			file: "testdata/book.epub",
+			opts: interfaces.ParseOptions{Chapters: []int{1, 3}},
+			err:  "chapter 3 not found, the book has 2 chapters",
+		},
+		{
+			name: "not an epub",
+			file: "testdata/legacy.apkg",
+			err:  "epub is missing META-INF/container.xml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			require.NoError(t, err)
+			defer f.Close()
+
+			cues, chapters, err := parseEpub(f, tt.opts, segmenter{})
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cueTexts(cues))
+			require.Equal(t, tt.chapters, chapters)
+		})
+	}
+}
+
+func TestReadEpubChapters(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	content, err := os.ReadFile("testdata/book.epub")
+	require.NoError(t, err)
+
+	chapters, err := readEpubChapters(content, maxTotalSize)
+	require.NoError(t, err)
+	require.Len(t, chapters, 2)
+
+	// the cover and chapters are more than 1000 bytes together
+	_, err = readEpubChapters(content, 1000)
+	require.EqualError(t, err, "epub is too large")
+}
+
+func TestEpubText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name    string
+		xhtml   string
+		text    string
+		heading string
+	}{
+		{
+			name:    "paragraphs and heading",
+			xhtml:   `<html><head><title>Uno</title></head><body><h1>Capítulo <em>uno</em></h1><p>Primera   línea.</p><div><p>Segunda línea.</p></div></body></html>`,
+			text:    "Capítulo uno\n\nPrimera línea.\n\nSegunda línea.",
+			heading: "Capítulo uno",
+		},
+		{
+			name:  "line breaks and entities",
+			xhtml: `<html><body><p>Verso uno,<br/>verso&nbsp;dos &amp; tres</p></body></html>`,
+			text:  "Verso uno, verso dos & tres",
+		},
+		{
+			name:  "scripts and ruby",
+			xhtml: `<html><body><script>alert("x")</script><p><ruby>東京<rp>(</rp><rt>とうきょう</rt><rp>)</rp></ruby>に行く</p></body></html>`,
+			text:  "東京に行く",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, heading := epubText([]byte(tt.xhtml))
+			require.Equal(t, tt.text, text)
+			require.Equal(t, tt.heading, heading)
+		})
+	}
+}
+
+func TestResolveEpubHref(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	require.Equal(t, "OEBPS/text/chapter 1.xhtml", resolveEpubHref("OEBPS", "text/chapter%201.xhtml#start"))
+	require.Equal(t, "OEBPS/images/a.jpg", resolveEpubHref("OEBPS/text", "../images/a.jpg"))
+	require.Equal(t, "a.html", resolveEpubHref(".", "a.html"))
+}
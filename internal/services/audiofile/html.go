@@ -0,0 +1,295 @@
+package audiofile
+
+import (
+	"bytes"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "html",
+		format:     Html,
+		mimeTypes:  []string{"text/html", "application/xhtml+xml"},
+		extensions: []string{".html", ".htm", ".xhtml"},
+		sniff:      sniffIf(isHtml, strongConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseHtml(f, seg)
+		}),
+	})
+}
+
+var (
+	// htmlBlockElements end a paragraph of the text of a page
+	htmlBlockElements = []string{
+		"p", "div", "section", "article", "main", "blockquote", "li", "ul", "ol", "dl", "dt", "dd",
+		"tr", "td", "th", "table", "caption", "pre", "h1", "h2", "h3", "h4", "h5", "h6", "hr",
+		"figcaption", "address", "details", "summary", "br",
+	}
+	// htmlRemovedElements are never part of the text of an article, like scripts, menus,
+	// forms and embedded media
+	htmlRemovedElements = []string{
+		"head", "script", "style", "noscript", "template", "iframe", "object", "embed", "svg",
+		"math", "canvas", "video", "audio", "form", "button", "input", "select", "textarea",
+		"nav", "aside", "footer", "figure", "dialog", "rt", "rp",
+	}
+	// htmlRemovedRoles are the aria roles of the parts of a page around its article
+	htmlRemovedRoles = []string{
+		"navigation", "banner", "contentinfo", "complementary", "search", "dialog", "alert",
+		"menu", "menubar",
+	}
+	// htmlUnlikelyRegex matches the class or id of the boilerplate of a page like ads,
+	// comments, share buttons and related links
+	htmlUnlikelyRegex = regexp.MustCompile(`(?i)\b(ads?|advert\w*|banner|breadcrumbs?|combx|comments?|community|cookies?|consent|disqus|foot|footer|header|masthead|menu|modal|nav|navbar|newsletter|outbrain|pager|pagination|popup|promo\w*|related|remark|rss|share|sharing|shoutbox|sidebar|skyscraper|social|sponsor\w*|subscribe|subscription|taboola|tags|toolbar|widget)\b`)
+	// htmlLikelyRegex matches the class or id of the element that has the text of an article
+	htmlLikelyRegex = regexp.MustCompile(`(?i)article|body|content|entry|main|page|post|story|text|blog`)
+)
+
+// htmlMinParagraph is the least number of characters of a paragraph that adds to the score
+// of the element it is in
+const htmlMinParagraph = 25
+
+// isHtml checks if the content is an html page like a saved web article
+func isHtml(content []byte) bool {
+	head := bytes.TrimSpace(bytes.TrimPrefix(content, []byte("\xef\xbb\xbf")))
+	if !bytes.HasPrefix(head, []byte("<")) {
+		return false
+	}
+	head = bytes.ToLower(head[:min(len(head), 1024)])
+	return bytes.Contains(head, []byte("<!doctype html")) || bytes.Contains(head, []byte("<html")) ||
+		bytes.Contains(head, []byte("<body"))
+}
+
+// parseHtml takes an html page and returns the phrases of its main content. The menus,
+// scripts, ads, comments and footers around the article are removed, and each paragraph,
+// heading and list item ends a phrase.
+func parseHtml(f io.Reader, seg segmenter) ([]cue, error) {
+	doc, err := html.Parse(f)
+	if err != nil {
+		return nil, err
+	}
+	paragraphs := htmlParagraphs(mainContent(doc))
+	return untimedCues(parseParagraph(strings.NewReader(strings.Join(paragraphs, "\n\n")), seg)), nil
+}
+
+// mainContent removes the boilerplate of the page and returns the element with its article.
+// An <article> or <main> element is used when the page has one, and otherwise the element
+// whose paragraphs have the most text, like readability does.
+func mainContent(doc *html.Node) *html.Node {
+	removeBoilerplate(doc)
+
+	var articles []*html.Node
+	var body *html.Node
+	walkHtml(doc, func(n *html.Node) {
+		switch {
+		case n.Data == "body":
+			body = n
+		case n.Data == "article" || n.Data == "main" || htmlAttr(n, "role") == "main":
+			articles = append(articles, n)
+		}
+	})
+	if len(articles) > 0 {
+		// the article with the most text, which is not a short teaser of another article
+		return slices.MaxFunc(articles, func(a, b *html.Node) int {
+			return utf8.RuneCountInString(htmlText(a)) - utf8.RuneCountInString(htmlText(b))
+		})
+	}
+
+	if best := bestCandidate(doc); best != nil {
+		return best
+	}
+	if body != nil {
+		return body
+	}
+	return doc
+}
+
+// removeBoilerplate removes the elements that are not part of the article, like scripts,
+// menus, hidden elements and the elements whose class or id is unlikely to be the article
+func removeBoilerplate(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isBoilerplate(c)) {
+			n.RemoveChild(c)
+		} else {
+			removeBoilerplate(c)
+		}
+		c = next
+	}
+}
+
+// isBoilerplate checks if the element is not part of the article
+func isBoilerplate(n *html.Node) bool {
+	if slices.Contains(htmlRemovedElements, n.Data) || slices.Contains(htmlRemovedRoles, htmlAttr(n, "role")) {
+		return true
+	}
+	if n.Data == "header" && !hasAncestor(n, "article", "main") {
+		return true
+	}
+	style := strings.ReplaceAll(strings.ToLower(htmlAttr(n, "style")), " ", "")
+	if htmlHasAttr(n, "hidden") || htmlAttr(n, "aria-hidden") == "true" ||
+		strings.Contains(style, "display:none") || strings.Contains(style, "visibility:hidden") {
+		return true
+	}
+	if n.Data == "body" || n.Data == "html" || n.Data == "article" || n.Data == "main" {
+		return false
+	}
+	match := htmlAttr(n, "class") + " " + htmlAttr(n, "id")
+	if htmlUnlikelyRegex.MatchString(match) && !htmlLikelyRegex.MatchString(match) {
+		return true
+	}
+	// a list that is mostly links is a menu or a list of related articles
+	if (n.Data == "ul" || n.Data == "ol") && linkDensity(n) > 0.5 {
+		return true
+	}
+	return false
+}
+
+// bestCandidate scores the parents of the paragraphs of the page by how much text they have
+// and returns the element with the highest score. Each paragraph adds to the score of its
+// parent and half as much to its grandparent, and an element that is mostly links scores
+// less.
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+	add := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+	walkHtml(doc, func(n *html.Node) {
+		if n.Data != "p" && n.Data != "pre" && n.Data != "blockquote" {
+			return
+		}
+		text := htmlText(n)
+		length := utf8.RuneCountInString(text)
+		if length < htmlMinParagraph {
+			return
+		}
+		score := 1 + float64(strings.Count(text, ",")+strings.Count(text, "、")) + min(float64(length)/100, 3)
+		add(n.Parent, score)
+		if n.Parent != nil {
+			add(n.Parent.Parent, score/2)
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, n := range order {
+		score := scores[n] * (1 - linkDensity(n))
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	return best
+}
+
+// htmlParagraphs returns the text of each paragraph, heading and list item of the element
+// with its spaces collapsed
+func htmlParagraphs(n *html.Node) []string {
+	var paragraphs []string
+	var paragraph strings.Builder
+	endParagraph := func() {
+		if text := strings.Join(strings.Fields(paragraph.String()), " "); text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+		paragraph.Reset()
+	}
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch n.Type {
+		case html.TextNode:
+			paragraph.WriteString(n.Data)
+			return
+		case html.ElementNode:
+			if slices.Contains(htmlBlockElements, n.Data) {
+				endParagraph()
+				defer endParagraph()
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	endParagraph()
+	return paragraphs
+}
+
+// walkHtml calls fn with each element of the tree in document order
+func walkHtml(n *html.Node, fn func(*html.Node)) {
+	if n.Type == html.ElementNode {
+		fn(n)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		walkHtml(c, fn)
+	}
+}
+
+// htmlText returns the text of the element with its spaces collapsed
+func htmlText(n *html.Node) string {
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			b.WriteString(n.Data)
+			b.WriteString(" ")
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// linkDensity returns how much of the text of the element is the text of links
+func linkDensity(n *html.Node) float64 {
+	total := utf8.RuneCountInString(htmlText(n))
+	if total == 0 {
+		return 0
+	}
+	links := 0
+	walkHtml(n, func(c *html.Node) {
+		if c.Data == "a" {
+			links += utf8.RuneCountInString(htmlText(c))
+		}
+	})
+	return min(float64(links)/float64(total), 1)
+}
+
+// hasAncestor checks if the element is inside an element with one of the names
+func hasAncestor(n *html.Node, names ...string) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && slices.Contains(names, p.Data) {
+			return true
+		}
+	}
+	return false
+}
+
+// htmlAttr returns the value of the attribute of the element
+func htmlAttr(n *html.Node, name string) string {
+	for _, a := range n.Attr {
+		if a.Key == name {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// htmlHasAttr checks if the element has the attribute even when it has no value
+func htmlHasAttr(n *html.Node, name string) bool {
+	return slices.ContainsFunc(n.Attr, func(a html.Attribute) bool { return a.Key == name })
+}
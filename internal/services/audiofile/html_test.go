@@ -0,0 +1,95 @@
+package audiofile
+
+import (
+	"os"
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/html"
+)
+
+func TestMainContent(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	article, err := os.ReadFile("testdata/article.html")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{
+			// the menus, scripts, ads, share buttons, comments, related links and footer are
+			// removed and the entities are decoded
+			name:  "article",
+			input: string(article),
+			expected: []string{
+				"El mercado vuelve al centro",
+				"Por Ana García",
+				"El mercado de los sábados vuelve a la plaza mayor después de tres años.",
+				"Los vendedores ofrecen fruta, verdura & pan",
+				"de la región.",
+				"Horario",
+				"Abre a las ocho de la mañana y cierra a las dos de la tarde",
+			},
+		},
+		{
+			name: "no article element",
+			input: `<html><body>
+				<div id="menu"><a href="/">Inicio</a> <a href="/blog">Blog</a></div>
+				<div class="links"><p><a href="/uno">Un enlace muy largo a otra noticia del día de hoy</a></p></div>
+				<div class="story">
+					<p>Esta es la primera frase de la noticia, que es bastante larga.</p>
+					<p>Esta es la segunda frase de la noticia, que también es larga.</p>
+				</div>
+				<div class="copyright"><p>Todos los derechos reservados por el periódico.</p></div>
+			</body></html>`,
+			expected: []string{
+				"Esta es la primera frase de la noticia, que es bastante larga.",
+				"Esta es la segunda frase de la noticia, que también es larga.",
+			},
+		},
+		{
+			name:  "hidden text",
+			input: `<html><body><p>Se ve esta frase.</p><p hidden>No se ve esta.</p><p style="display: none">Ni esta.</p></body></html>`,
+			expected: []string{
+				"Se ve esta frase.",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := html.Parse(strings.NewReader(tt.input))
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, htmlParagraphs(mainContent(doc)))
+		})
+	}
+}
+
+func TestIsHtml(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{name: "doctype", input: "\ufeff  <!DOCTYPE html>\n<html><body></body></html>", expected: true},
+		{name: "fragment with a body", input: "<body><p>Hola.</p></body>", expected: true},
+		{name: "text about html", input: "The <html> element is the root of a page.", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, isHtml([]byte(tt.input)))
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package audiofile
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"time"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "kindle",
+		format:     Kindle,
+		extensions: []string{".txt"},
+		sniff:      sniffIf(isKindleClippings, strongConfidence),
+		parse: parsedCues(func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseKindle(f, opts, seg)
+		}),
+	})
+}
+
+// kindleSeparator is the line that ends each clipping of a My Clippings.txt file
+const kindleSeparator = "=========="
+
+var (
+	// kindleNoteWords and kindleBookmarkWords are the words of the metadata line of a note or
+	// a bookmark in the languages of the Kindle. Other clippings are highlights.
+	kindleNoteWords     = []string{"note", "nota", "notiz", "notitie", "メモ", "笔记", "筆記"}
+	kindleBookmarkWords = []string{"bookmark", "marcador", "lesezeichen", "signet", "segnalibro", "bladwijzer", "ブックマーク", "书签", "書籤"}
+	// kindleLocationWords are the words before the location of a clipping
+	kindleLocationWords = []string{"location", "loc.", "posición", "position", "posizione", "posição", "locatie", "位置"}
+	// kindleLocationRegex matches a location or a range of locations like 123-125 or 1023-25
+	kindleLocationRegex = regexp.MustCompile(`(\d+)(?:\s*-\s*(\d+))?`)
+	// kindleClockRegex matches the time a clipping was added like 10:15:32 PM or 22:15
+	kindleClockRegex = regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?(?:\s*([AaPp])\.?\s*[Mm]\.?)?`)
+	// kindleYearRegex matches the year a clipping was added
+	kindleYearRegex = regexp.MustCompile(`\b(\d{4})\b`)
+	// kindleDayRegex matches the day of the month a clipping was added
+	kindleDayRegex = regexp.MustCompile(`\b(\d{1,2})\b`)
+	// kindleCJKDateRegex matches a Chinese or Japanese date like 2024年3月4日
+	kindleCJKDateRegex = regexp.MustCompile(`(\d{4})年(\d{1,2})月(\d{1,2})日`)
+	// kindleMonths are the names of the months in the languages of the Kindle
+	kindleMonths = map[string]time.Month{
+		"january": 1, "february": 2, "march": 3, "april": 4, "may": 5, "june": 6, "july": 7,
+		"august": 8, "september": 9, "october": 10, "november": 11, "december": 12,
+		"enero": 1, "febrero": 2, "marzo": 3, "abril": 4, "mayo": 5, "junio": 6, "julio": 7,
+		"agosto": 8, "septiembre": 9, "setiembre": 9, "octubre": 10, "noviembre": 11, "diciembre": 12,
+		"janvier": 1, "février": 2, "mars": 3, "avril": 4, "mai": 5, "juin": 6, "juillet": 7,
+		"août": 8, "septembre": 9, "octobre": 10, "novembre": 11, "décembre": 12,
+		"januar": 1, "februar": 2, "märz": 3, "juni": 6, "juli": 7, "oktober": 10, "dezember": 12,
+		"gennaio": 1, "febbraio": 2, "aprile": 4, "maggio": 5, "giugno": 6, "luglio": 7,
+		"settembre": 9, "ottobre": 10, "dicembre": 12,
+		"janeiro": 1, "fevereiro": 2, "março": 3, "junho": 6, "julho": 7, "setembro": 9,
+		"outubro": 10, "novembro": 11, "dezembro": 12,
+		"januari": 1, "februari": 2, "maart": 3, "mei": 5, "augustus": 8,
+	}
+)
+
+// kindleClipping is a highlight, note or bookmark of a My Clippings.txt file
+type kindleClipping struct {
+	book string
+	note bool
+	// start and end are the locations of the clipping, which are 0 when it has none
+	start, end int
+	added      time.Time
+	hasDate    bool
+	text       string
+}
+
+// isKindleClippings checks if the content is the My Clippings.txt file of a Kindle, where
+// each clipping is the title of the book, a metadata line like - Your Highlight on page 3 |
+// Added on ..., a blank line and the text, followed by a line of ==========
+func isKindleClippings(content []byte) bool {
+	first, _, found := bytes.Cut(content, []byte(kindleSeparator))
+	if !found {
+		return false
+	}
+	lines := strings.FieldsFunc(string(first), func(r rune) bool { return r == '\r' || r == '\n' })
+	return len(lines) >= 2 && strings.HasPrefix(strings.TrimSpace(lines[1]), "- ") && strings.Contains(lines[1], "|")
+}
+
+// parseKindle takes a My Clippings.txt file and returns the sentences of its highlights in
+// the order they were added. The highlights can be limited to the books whose title and
+// author contain opts.Book and to the ones added between opts.FromDate and opts.ToDate.
+// Notes and bookmarks are skipped, and a highlight that was extended is only kept once.
+func parseKindle(f io.Reader, opts interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+	clippings := readKindleClippings(f)
+
+	var books []string
+	var highlights []kindleClipping
+	for _, c := range clippings {
+		if !slices.Contains(books, c.book) {
+			books = append(books, c.book)
+		}
+		if c.note || c.text == "" {
+			continue
+		}
+		if opts.Book != "" && !strings.Contains(strings.ToLower(c.book), strings.ToLower(opts.Book)) {
+			continue
+		}
+		highlights = append(highlights, c)
+	}
+	if opts.Book != "" && len(highlights) == 0 {
+		return nil, fmt.Errorf("book %s not found, the books are: %s", opts.Book, strings.Join(books, ", "))
+	}
+
+	if !opts.FromDate.IsZero() || !opts.ToDate.IsZero() {
+		highlights = slices.DeleteFunc(highlights, func(c kindleClipping) bool {
+			return !c.hasDate || c.added.Before(opts.FromDate) ||
+				(!opts.ToDate.IsZero() && !c.added.Before(opts.ToDate.AddDate(0, 0, 1)))
+		})
+		if len(highlights) == 0 {
+			return nil, errors.New("no highlights were added between from_date and to_date")
+		}
+	}
+
+	var cues []cue
+	for _, c := range mergeExtendedHighlights(highlights) {
+		// a highlight is kept whole when it is one sentence, even if it is a single word
+		cues = append(cues, untimedCues(splitOnEndingPunctuation(c.text, seg))...)
+	}
+	return cues, nil
+}
+
+// readKindleClippings returns the clippings of the file in order
+func readKindleClippings(f io.Reader) []kindleClipping {
+	var clippings []kindleClipping
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// each clipping of the file can start with a byte order mark
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if line != kindleSeparator {
+			lines = append(lines, line)
+			continue
+		}
+		if c, ok := kindleClippingOf(lines); ok {
+			clippings = append(clippings, c)
+		}
+		lines = nil
+	}
+	if c, ok := kindleClippingOf(lines); ok {
+		clippings = append(clippings, c)
+	}
+	return clippings
+}
+
+// kindleClippingOf reads the lines of a clipping between two separators
+func kindleClippingOf(lines []string) (kindleClipping, bool) {
+	for len(lines) > 0 && lines[0] == "" {
+		lines = lines[1:]
+	}
+	if len(lines) < 2 || !strings.HasPrefix(lines[1], "- ") {
+		return kindleClipping{}, false
+	}
+	c := kindleClipping{book: lines[0]}
+
+	segments := strings.Split(strings.TrimPrefix(lines[1], "- "), "|")
+	kind := strings.ToLower(segments[0])
+	c.note = slices.ContainsFunc(kindleNoteWords, func(w string) bool { return strings.Contains(kind, w) })
+	if slices.ContainsFunc(kindleBookmarkWords, func(w string) bool { return strings.Contains(kind, w) }) {
+		return c, true
+	}
+	for _, segment := range segments {
+		lower := strings.ToLower(segment)
+		if slices.ContainsFunc(kindleLocationWords, func(w string) bool { return strings.Contains(lower, w) }) {
+			c.start, c.end = kindleLocation(lower)
+		}
+	}
+	if len(segments) > 1 {
+		c.added, c.hasDate = kindleDate(segments[len(segments)-1])
+	}
+
+	text := strings.Join(strings.Fields(strings.Join(lines[2:], " ")), " ")
+	// the Kindle writes this instead of the text when a book limits how much can be clipped
+	if !strings.HasPrefix(text, "<You have reached the clipping limit") {
+		c.text = text
+	}
+	return c, true
+}
+
+// kindleLocation returns the first and last location of a clipping. The last location of an
+// older Kindle only has the digits that changed, like 1023-25 for 1023 to 1025.
+func kindleLocation(segment string) (int, int) {
+	match := kindleLocationRegex.FindStringSubmatch(segment)
+	if match == nil {
+		return 0, 0
+	}
+	start, _ := strconv.Atoi(match[1])
+	if match[2] == "" {
+		return start, start
+	}
+	end, _ := strconv.Atoi(match[2])
+	if end < start && len(match[2]) < len(match[1]) {
+		end, _ = strconv.Atoi(match[1][:len(match[1])-len(match[2])] + match[2])
+	}
+	return start, max(start, end)
+}
+
+// kindleDate reads the date a clipping was added like Added on Monday, March 4, 2024
+// 10:15:32 PM in English, Añadido el lunes, 4 de marzo de 2024 22:15:32 in Spanish or
+// 2024年3月4日 in Chinese and Japanese
+func kindleDate(segment string) (time.Time, bool) {
+	segment = strings.ToLower(segment)
+	hour, minute, second := 0, 0, 0
+	if clock := kindleClockRegex.FindStringSubmatch(segment); clock != nil {
+		hour, _ = strconv.Atoi(clock[1])
+		minute, _ = strconv.Atoi(clock[2])
+		second, _ = strconv.Atoi(clock[3])
+		switch {
+		case clock[4] == "p" && hour < 12:
+			hour += 12
+		case clock[4] == "a" && hour == 12:
+			hour = 0
+		}
+		segment = strings.Replace(segment, clock[0], " ", 1)
+	}
+	if strings.Contains(segment, "下午") && hour < 12 {
+		hour += 12
+	}
+
+	var year, day int
+	var month time.Month
+	if cjk := kindleCJKDateRegex.FindStringSubmatch(segment); cjk != nil {
+		year, _ = strconv.Atoi(cjk[1])
+		m, _ := strconv.Atoi(cjk[2])
+		month = time.Month(m)
+		day, _ = strconv.Atoi(cjk[3])
+	} else {
+		y := kindleYearRegex.FindStringSubmatch(segment)
+		if y == nil {
+			return time.Time{}, false
+		}
+		year, _ = strconv.Atoi(y[1])
+		segment = strings.Replace(segment, y[0], " ", 1)
+		for _, word := range strings.FieldsFunc(segment, func(r rune) bool {
+			return r == ' ' || r == ',' || r == '.'
+		}) {
+			if m, ok := kindleMonths[word]; ok {
+				month = m
+				break
+			}
+		}
+		if d := kindleDayRegex.FindStringSubmatch(segment); d != nil {
+			day, _ = strconv.Atoi(d[1])
+		}
+	}
+	if month < 1 || month > 12 || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	return time.Date(year, month, day, hour, minute, second, 0, time.UTC), true
+}
+
+// mergeExtendedHighlights keeps one highlight of a book that was highlighted again over the
+// same locations, like a highlight that was made longer, with the longer text where the
+// first one was
+func mergeExtendedHighlights(highlights []kindleClipping) []kindleClipping {
+	var merged []kindleClipping
+	for _, h := range highlights {
+		i := slices.IndexFunc(merged, func(m kindleClipping) bool {
+			return m.book == h.book && m.start > 0 && h.start > 0 && m.start <= h.end && h.start <= m.end &&
+				(strings.Contains(m.text, h.text) || strings.Contains(h.text, m.text))
+		})
+		switch {
+		case i < 0:
+			merged = append(merged, h)
+		case len(h.text) > len(merged[i].text):
+			merged[i].text = h.text
+		}
+	}
+	return merged
+}
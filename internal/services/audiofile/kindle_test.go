@@ -0,0 +1,162 @@
+package audiofile
+
+import (
+	"os"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKindle(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	date := func(s string) time.Time {
+		d, err := time.Parse(time.DateOnly, s)
+		require.NoError(t, err)
+		return d
+	}
+
+	tests := []struct {
+		name     string
+		opts     interfaces.ParseOptions
+		expected []string
+		err      string
+	}{
+		{
+			// the notes and bookmarks are skipped and the highlight that was made longer is
+			// only kept once
+			name: "every book",
+			expected: []string{
+				"Muchos años después, frente al pelotón de fusilamiento, el coronel Aureliano Buendía había de recordar aquella tarde remota.",
+				"El mundo era tan reciente, que muchas cosas carecían de nombre.",
+				"Lo esencial es invisible a los ojos.",
+				"domesticar",
+			},
+		},
+		{
+			name: "book",
+			opts: interfaces.ParseOptions{Book: "saint-exupéry"},
+			expected: []string{
+				"Lo esencial es invisible a los ojos.",
+				"domesticar",
+			},
+		},
+		{
+			name:     "date range",
+			opts:     interfaces.ParseOptions{FromDate: date("2024-03-05"), ToDate: date("2024-04-06")},
+			expected: []string{"El mundo era tan reciente, que muchas cosas carecían de nombre.", "Lo esencial es invisible a los ojos."},
+		},
+		{
+			name: "book not found",
+			opts: interfaces.ParseOptions{Book: "Don Quijote"},
+			err:  "book Don Quijote not found, the books are: Cien años de soledad (Gabriel García Márquez), El principito (Antoine de Saint-Exupéry)",
+		},
+		{
+			name: "no highlights in the date range",
+			opts: interfaces.ParseOptions{FromDate: date("2025-01-01")},
+			err:  "no highlights were added between from_date and to_date",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open("testdata/clippings.txt")
+			require.NoError(t, err)
+			defer f.Close()
+			cues, err := parseKindle(f, tt.opts, segmenter{})
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cueTexts(cues))
+		})
+	}
+}
+
+func TestKindleDate(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		segment  string
+		expected time.Time
+		ok       bool
+	}{
+		{
+			name:     "english",
+			segment:  " Added on Monday, March 4, 2024 10:15:32 PM",
+			expected: time.Date(2024, 3, 4, 22, 15, 32, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "english at midnight",
+			segment:  " Added on Friday, 1 November 2024 12:05:00 AM",
+			expected: time.Date(2024, 11, 1, 0, 5, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "spanish",
+			segment:  " Añadido el sábado, 6 de abril de 2024 22:05:00",
+			expected: time.Date(2024, 4, 6, 22, 5, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "german",
+			segment:  " Hinzugefügt am Donnerstag, 3. Oktober 2024 07:30:00",
+			expected: time.Date(2024, 10, 3, 7, 30, 0, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:     "japanese",
+			segment:  " 作成日: 2024年3月4日月曜日 22:15:32",
+			expected: time.Date(2024, 3, 4, 22, 15, 32, 0, time.UTC),
+			ok:       true,
+		},
+		{
+			name:    "no date",
+			segment: " Location 120-121",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			added, ok := kindleDate(tt.segment)
+			require.Equal(t, tt.ok, ok)
+			require.Equal(t, tt.expected, added)
+		})
+	}
+}
+
+func TestKindleLocation(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name    string
+		segment string
+		start   int
+		end     int
+	}{
+		{name: "range", segment: "location 120-125", start: 120, end: 125},
+		{name: "single", segment: "posición 310", start: 310, end: 310},
+		{name: "short end of an older kindle", segment: "highlight loc. 1023-25", start: 1023, end: 1025},
+		{name: "none", segment: "location", start: 0, end: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := kindleLocation(tt.segment)
+			require.Equal(t, tt.start, start)
+			require.Equal(t, tt.end, end)
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package audiofile
+
+import (
+	"bufio"
+	"cmp"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"time"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "lrc",
+		format:     Lrc,
+		extensions: []string{".lrc"},
+		sniff:      sniffFirstLines(lrcFormatCheck, likelyConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseLrc(f, seg), nil
+		}),
+	})
+}
+
+// lrcLastLineDuration is how long the last line of an lrc file without a length tag is sung
+const lrcLastLineDuration = 4 * time.Second
+
+var (
+	// lrcTimeTagRegex matches the time tag at the start of a line like [01:23.45], [01:23:45]
+	// or [1:23]
+	lrcTimeTagRegex = regexp.MustCompile(`^\[(\d{1,3}):(\d{1,2})(?:[.:](\d{1,3}))?\]`)
+	// lrcWordTimeRegex matches the word time tags of enhanced lrc like <01:23.45>
+	lrcWordTimeRegex = regexp.MustCompile(`<\d{1,3}:\d{1,2}(?:[.:]\d{1,3})?>`)
+	// lrcIDTagRegex matches an ID tag like [ar:Artist], [ti:Title] or [offset:+500]
+	lrcIDTagRegex = regexp.MustCompile(`^\[(ar|al|ti|au|by|length|offset|re|tool|ve|#):(.*)\]$`)
+	// lrcRepeatRegex matches a note that the line is repeated like (x2), [2x] or x3 at the end
+	// of a line of a chorus
+	lrcRepeatRegex = regexp.MustCompile(`(?i)(\s*[(\[]\s*(?:[x×]\s*\d+|\d+\s*[x×])\s*[)\]]|\s+[x×]\d+)$`)
+)
+
+// lrcLine is a line of lyrics with the time it is sung at
+type lrcLine struct {
+	start time.Duration
+	text  string
+}
+
+// lrcFormatCheck checks if a line is a timed line or an ID tag of an lrc lyrics file
+func lrcFormatCheck(line string) bool {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	return lrcTimeTagRegex.MatchString(line) || lrcIDTagRegex.MatchString(line)
+}
+
+// parseLrc takes an lrc lyrics file and parses each line into phrases that are sung from its
+// time tag until the next line. A line of a chorus with several time tags is repeated at each
+// of the times, and the ID tags, word time tags of enhanced lrc and repeat notes like (x2)
+// are removed. The repeated lines are only kept once by uniquePhrases.
+func parseLrc(f io.Reader, seg segmenter) []cue {
+	lines, length := lrcLines(f)
+
+	var phrases []cue
+	for i, line := range lines {
+		if line.text == "" {
+			continue
+		}
+		end := line.start + lrcLastLineDuration
+		if i+1 < len(lines) {
+			end = lines[i+1].start
+		} else if length > line.start {
+			end = length
+		}
+		text := lrcRepeatRegex.ReplaceAllString(replaceFmt(line.text, seg.cleanup), "")
+		phrases = append(phrases, cuePhrases(cue{start: line.start, end: end, text: text}, seg)...)
+	}
+	return phrases
+}
+
+// lrcLines returns the timed lines of the file in the order they are sung, including the
+// lines without text that end the line before them, and the length of the song from its
+// length tag. The times are moved by the offset tag.
+func lrcLines(f io.Reader) ([]lrcLine, time.Duration) {
+	var lines []lrcLine
+	var offset, length time.Duration
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if tag := lrcIDTagRegex.FindStringSubmatch(line); tag != nil {
+			value := strings.TrimSpace(tag[2])
+			switch tag[1] {
+			case "offset":
+				// a positive offset makes the lyrics appear sooner
+				if ms, err := strconv.Atoi(strings.TrimPrefix(value, "+")); err == nil {
+					offset = time.Duration(ms) * time.Millisecond
+				}
+			case "length":
+				if d, ok := lrcTime(lrcTimeTagRegex.FindStringSubmatch("[" + value + "]")); ok {
+					length = d
+				}
+			}
+			continue
+		}
+
+		var starts []time.Duration
+		for {
+			match := lrcTimeTagRegex.FindStringSubmatch(line)
+			if match == nil {
+				break
+			}
+			if start, ok := lrcTime(match); ok {
+				starts = append(starts, start)
+			}
+			line = strings.TrimSpace(line[len(match[0]):])
+		}
+		text := strings.TrimSpace(lrcWordTimeRegex.ReplaceAllString(line, ""))
+		for _, start := range starts {
+			lines = append(lines, lrcLine{start: start, text: text})
+		}
+	}
+
+	for i := range lines {
+		lines[i].start = max(lines[i].start-offset, 0)
+	}
+	slices.SortStableFunc(lines, func(a, b lrcLine) int {
+		return cmp.Compare(a.start, b.start)
+	})
+	return lines, length
+}
+
+// lrcTime returns the time of a match of lrcTimeTagRegex. The fraction is in hundredths of
+// a second like [01:23.45] but can also have one or three digits.
+func lrcTime(match []string) (time.Duration, bool) {
+	if match == nil {
+		return 0, false
+	}
+	minutes, err := strconv.Atoi(match[1])
+	if err != nil {
+		return 0, false
+	}
+	seconds, err := strconv.Atoi(match[2])
+	if err != nil || seconds >= 60 {
+		return 0, false
+	}
+	d := time.Duration(minutes)*time.Minute + time.Duration(seconds)*time.Second
+	if match[3] != "" {
+		fraction := match[3] + strings.Repeat("0", 3-len(match[3]))
+		ms, err := strconv.Atoi(fraction)
+		if err != nil {
+			return 0, false
+		}
+		d += time.Duration(ms) * time.Millisecond
+	}
+	return d, true
+}
@@ -0,0 +1,92 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLrc(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		expected []cue
+	}{
+		{
+			name: "ID tags and offset",
+			content: "\ufeff[ti:La canción]\n[ar:El grupo]\n[offset:+500]\n" +
+				"[00:12.50]Cuando llegues a casa\n" +
+				"[00:16.00]llámame por teléfono otra vez\n" +
+				"[00:20.00]\n",
+			expected: []cue{
+				{start: 12 * time.Second, end: 15500 * time.Millisecond, text: "Cuando llegues a casa"},
+				{start: 15500 * time.Millisecond, end: 19500 * time.Millisecond, text: "llámame por teléfono otra vez"},
+			},
+		},
+		{
+			// the chorus has the time of each time it is sung and the note that it is repeated
+			// is removed
+			name: "chorus with several time tags",
+			content: "[00:05.00]Primera línea de la canción\n" +
+				"[00:10.00][00:30.00]Baila conmigo esta noche (x2)\n" +
+				"[00:20.00]Segunda línea de la canción\n" +
+				"[length: 00:40]\n",
+			expected: []cue{
+				{start: 5 * time.Second, end: 10 * time.Second, text: "Primera línea de la canción"},
+				{start: 10 * time.Second, end: 20 * time.Second, text: "Baila conmigo esta noche"},
+				{start: 20 * time.Second, end: 30 * time.Second, text: "Segunda línea de la canción"},
+				{start: 30 * time.Second, end: 40 * time.Second, text: "Baila conmigo esta noche"},
+			},
+		},
+		{
+			name:    "enhanced word times",
+			content: "[01:02.3]<01:02.30>Sube <01:02.90>la <01:03.10>música <01:03.50>otra <01:04.00>vez\n",
+			expected: []cue{
+				{start: 62300 * time.Millisecond, end: 66300 * time.Millisecond, text: "Sube la música otra vez"},
+			},
+		},
+		{
+			name:     "not lrc",
+			content:  "This is not an lrc file.",
+			expected: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, parseLrc(strings.NewReader(tt.content), segmenter{}))
+		})
+	}
+}
+
+func TestLrcFormatCheck(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		line     string
+		expected bool
+	}{
+		{name: "time tag", line: "[00:12.34]Hola", expected: true},
+		{name: "time tag without a fraction", line: "[1:05]Hola", expected: true},
+		{name: "ID tag", line: "[ar:El grupo]", expected: true},
+		{name: "ass section", line: "[Script Info]", expected: false},
+		{name: "section of a song", line: "[Chorus]", expected: false},
+		{name: "srt timing", line: "00:00:01,000 --> 00:00:04,000", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, lrcFormatCheck(tt.line))
+		})
+	}
+}
@@ -0,0 +1,213 @@
+package audiofile
+
+import (
+	"bufio"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "markdown",
+		format:     Markdown,
+		mimeTypes:  []string{"text/markdown", "text/x-markdown"},
+		extensions: []string{".md", ".markdown"},
+		sniff:      sniffMarkdown,
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseMarkdown(f, seg)
+		}),
+	})
+}
+
+var (
+	// markdownHeadingRegex matches an atx heading like ## Ordering food with its optional
+	// closing hashes
+	markdownHeadingRegex = regexp.MustCompile(`^ {0,3}#{1,6}[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+	// markdownSetextRegex matches the line of === or --- under the text of a heading
+	markdownSetextRegex = regexp.MustCompile(`^ {0,3}(?:=+|-+)[ \t]*$`)
+	// markdownBreakRegex matches a thematic break like --- or * * *
+	markdownBreakRegex = regexp.MustCompile(`^ {0,3}(?:(?:\*[ \t]*){3,}|(?:-[ \t]*){3,}|(?:_[ \t]*){3,})$`)
+	// markdownFenceRegex matches the line that starts or ends a block of code
+	markdownFenceRegex = regexp.MustCompile("^ {0,3}(```+|~~~+)")
+	// markdownQuoteRegex matches the markers of a blockquote at the start of a line
+	markdownQuoteRegex = regexp.MustCompile(`^(?:[ \t]*>[ \t]?)+`)
+	// markdownListRegex matches a list item like - hola, * hola, 1. hola or - [x] hola
+	markdownListRegex = regexp.MustCompile(`^[ \t]*(?:[-*+]|\d{1,9}[.)])[ \t]+(?:\[[ xX]\][ \t]+)?(.*)$`)
+	// markdownTableSeparatorRegex matches the line under the header of a table like |---|:--:|
+	markdownTableSeparatorRegex = regexp.MustCompile(`^[ \t]*\|?[ \t]*:?-+:?[ \t]*(?:\|[ \t]*:?-+:?[ \t]*)+\|?[ \t]*$`)
+	// markdownReferenceRegex matches the definition of a reference link like [1]: https://...
+	markdownReferenceRegex = regexp.MustCompile(`^ {0,3}\[[^\]]+\]:[ \t]*\S+`)
+
+	// markdownCommentRegex matches an html comment
+	markdownCommentRegex = regexp.MustCompile(`(?s)<!--.*?-->`)
+	// markdownImageRegex matches an image like ![alt](picture.png)
+	markdownImageRegex = regexp.MustCompile(`!\[[^\]]*\](?:\([^)]*\)|\[[^\]]*\])`)
+	// markdownLinkRegex matches a link like [text](url) or [text][ref] and keeps its text
+	markdownLinkRegex = regexp.MustCompile(`\[([^\]]*)\](?:\([^)]*\)|\[[^\]]*\])`)
+	// markdownAutolinkRegex matches a link written as <https://...>
+	markdownAutolinkRegex = regexp.MustCompile(`<(?:https?|ftp|mailto):[^>\s]*>`)
+	// markdownCodeRegex matches inline code like `hola` and keeps its text
+	markdownCodeRegex = regexp.MustCompile("`+([^`]*)`+")
+	// markdownTagRegex matches an html tag like <br> or <span class="x">
+	markdownTagRegex = regexp.MustCompile(`</?[A-Za-z][^>]*>`)
+	// markdownEmphasisRegex matches the markers of emphasis and strikethrough that are not
+	// escaped. An underscore inside a word like snake_case is not emphasis.
+	markdownEmphasisRegex = regexp.MustCompile(`(^|[^\\])(?:\*+|~~|\b_+|_+\b)`)
+	// markdownEscapeRegex matches a character escaped with a backslash like \*
+	markdownEscapeRegex = regexp.MustCompile("\\\\([\\\\`*_{}\\[\\]()#+\\-.!|>~])")
+)
+
+// markdownBlock is a paragraph, list item or table cell of a Markdown file with the heading
+// it is under
+type markdownBlock struct {
+	section string
+	text    string
+	// item is a list item or table cell, which is not joined with the text around it
+	item bool
+}
+
+// sniffMarkdown is the confidence that the content is a Markdown file. A heading or a block
+// of code is likely Markdown, but a table or a link can be written in other text.
+func sniffMarkdown(content []byte) int {
+	confidence := 0
+	for _, line := range strings.Split(string(content), "\n") {
+		line = strings.TrimRight(line, "\r")
+		if markdownHeadingRegex.MatchString(line) || markdownFenceRegex.MatchString(line) {
+			return likelyConfidence
+		}
+		if markdownTableSeparatorRegex.MatchString(line) || markdownLinkRegex.MatchString(line) {
+			confidence = possibleConfidence
+		}
+	}
+	return confidence
+}
+
+// parseMarkdown takes a Markdown file and returns its phrases with the heading they are
+// under as their section. The emphasis, links, inline code and html are removed, blocks of
+// code, images and the header row of tables are skipped, and each list item and table cell
+// is parsed as its own phrase.
+func parseMarkdown(f io.Reader, seg segmenter) ([]cue, error) {
+	blocks, err := markdownBlocks(f)
+	if err != nil {
+		return nil, err
+	}
+
+	var cues []cue
+	for _, b := range blocks {
+		text := markdownInline(b.text)
+		var phrases []string
+		if b.item {
+			phrases = splitLongPhrases(seg.cleanup.clean(text), seg)
+		} else {
+			phrases = parseParagraph(strings.NewReader(text), seg)
+		}
+		for _, phrase := range phrases {
+			cues = append(cues, cue{text: phrase, section: b.section})
+		}
+	}
+	return cues, nil
+}
+
+// markdownBlocks returns the paragraphs, list items and table cells of the file in order
+// with the text of the last heading before them
+func markdownBlocks(f io.Reader) ([]markdownBlock, error) {
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff")
+	}
+
+	var blocks []markdownBlock
+	var section string
+	var text []string
+	item, table := false, false
+	var fence string
+	endBlock := func() {
+		if len(text) > 0 {
+			blocks = append(blocks, markdownBlock{section: section, text: strings.Join(text, " "), item: item})
+		}
+		text, item = nil, false
+	}
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fence != "" {
+			// the code of a block of code is not spoken
+			if strings.HasPrefix(strings.TrimSpace(line), fence) {
+				fence = ""
+			}
+			continue
+		}
+		if i == 0 && strings.TrimSpace(line) == "---" {
+			// skip the front matter of a static site generator
+			for i++; i < len(lines) && strings.TrimSpace(lines[i]) != "---" && strings.TrimSpace(lines[i]) != "..."; i++ {
+			}
+			continue
+		}
+		line = markdownQuoteRegex.ReplaceAllString(line, "")
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case trimmed == "":
+			endBlock()
+			table = false
+		case markdownFenceRegex.MatchString(line):
+			endBlock()
+			fence = markdownFenceRegex.FindStringSubmatch(line)[1][:3]
+		case markdownHeadingRegex.MatchString(line):
+			endBlock()
+			section = markdownInline(markdownHeadingRegex.FindStringSubmatch(line)[1])
+		case len(text) > 0 && !item && markdownSetextRegex.MatchString(line):
+			// the paragraph over a line of === or --- is a heading
+			section = markdownInline(strings.Join(text, " "))
+			text = nil
+		case markdownBreakRegex.MatchString(line):
+			endBlock()
+		case markdownReferenceRegex.MatchString(line):
+			// the url of a reference link is not shown
+		case strings.Contains(line, "|") && i+1 < len(lines) && markdownTableSeparatorRegex.MatchString(lines[i+1]):
+			// the header row of a table names its columns, so it is skipped with its separator
+			endBlock()
+			table = true
+			i++
+		case (table && strings.Contains(line, "|")) || (strings.HasPrefix(trimmed, "|") && strings.HasSuffix(trimmed, "|")):
+			endBlock()
+			for _, cell := range strings.Split(strings.Trim(trimmed, "|"), "|") {
+				if cell = strings.TrimSpace(cell); cell != "" {
+					blocks = append(blocks, markdownBlock{section: section, text: cell, item: true})
+				}
+			}
+		case markdownListRegex.MatchString(line):
+			endBlock()
+			text, item = []string{markdownListRegex.FindStringSubmatch(line)[1]}, true
+		default:
+			// the text of a paragraph, or a line that continues a list item
+			text = append(text, trimmed)
+		}
+	}
+	endBlock()
+	return blocks, nil
+}
+
+// markdownInline removes the formatting of the text of a block and collapses its spaces
+func markdownInline(text string) string {
+	text = markdownCommentRegex.ReplaceAllString(text, " ")
+	text = markdownImageRegex.ReplaceAllString(text, "")
+	text = markdownLinkRegex.ReplaceAllString(text, "$1")
+	text = markdownAutolinkRegex.ReplaceAllString(text, "")
+	text = markdownCodeRegex.ReplaceAllString(text, "$1")
+	text = markdownTagRegex.ReplaceAllString(text, "")
+	text = markdownEmphasisRegex.ReplaceAllString(text, "$1")
+	text = markdownEscapeRegex.ReplaceAllString(text, "$1")
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,105 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMarkdown(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		texts    []string
+		sections []string
+	}{
+		{
+			name: "headings are sections",
+			content: "---\ntitle: Lesson one\n---\n# Greetings\n\nThis is how you **say hello** to a friend. " +
+				"And this is how you *say goodbye* to them.\n\n## At the market\n\n" +
+				"- How much does this cost?\n- I would like [two kilos](https://example.com) of apples\n" +
+				"  and a bag to carry them.\n",
+			texts: []string{
+				"This is how you say hello to a friend.",
+				"And this is how you say goodbye to them.",
+				"How much does this cost?",
+				"I would like two kilos of apples and a bag to carry them.",
+			},
+			sections: []string{"Greetings", "Greetings", "At the market", "At the market"},
+		},
+		{
+			name: "setext headings and text before the first heading",
+			content: "This phrase is not under a heading.\n\nOrdering food\n=============\n\n" +
+				"1. I would like the soup please.\n2. Could we have the bill?\n",
+			texts:    []string{"This phrase is not under a heading.", "I would like the soup please.", "Could we have the bill?"},
+			sections: []string{"", "Ordering food", "Ordering food"},
+		},
+		{
+			name: "code and tables",
+			content: "# Verbs\n\n```go\nfmt.Println(\"this is not spoken\")\n```\n\n" +
+				"| Spanish | English |\n|---|:---:|\n| Yo tengo un perro | I have a dog |\n| Ella come `mucha` fruta | She eats a lot of fruit |\n\n" +
+				"> Use the snake_case name of the verb.\n",
+			texts: []string{
+				"Yo tengo un perro",
+				"I have a dog",
+				"Ella come mucha fruta",
+				"She eats a lot of fruit",
+				"Use the snake_case name of the verb.",
+			},
+			sections: []string{"Verbs", "Verbs", "Verbs", "Verbs", "Verbs"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, err := parseMarkdown(strings.NewReader(tt.content), segmenter{})
+			require.NoError(t, err)
+			require.Equal(t, tt.texts, cueTexts(cues))
+			var sections []string
+			for _, c := range cues {
+				sections = append(sections, c.section)
+			}
+			require.Equal(t, tt.sections, sections)
+		})
+	}
+}
+
+func TestMarkdownInline(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := map[string]struct {
+		text     string
+		expected string
+	}{
+		"emphasis":      {text: "This is **bold**, _italic_ and ~~struck~~ text", expected: "This is bold, italic and struck text"},
+		"links":         {text: "Read [the lesson][1] or <https://example.com> ![a picture](cat.png)", expected: "Read the lesson or"},
+		"code and html": {text: "Say `buenos días` <br>in the morning<!-- a note -->", expected: "Say buenos días in the morning"},
+		"escaped":       {text: `This is 5 \* 3 and a snake_case word`, expected: "This is 5 * 3 and a snake_case word"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tt.expected, markdownInline(tt.text))
+		})
+	}
+}
+
+func TestSniffMarkdown(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	require.Equal(t, likelyConfidence, sniffMarkdown([]byte("# Lesson one\nThis is the first line.\n")))
+	require.Equal(t, likelyConfidence, sniffMarkdown([]byte("```\ncode\n```\n")))
+	require.Equal(t, possibleConfidence, sniffMarkdown([]byte("This is a [link](https://example.com).\n")))
+	require.Equal(t, possibleConfidence, sniffMarkdown([]byte("| a | b |\n|---|---|\n| c | d |\n")))
+	require.Zero(t, sniffMarkdown([]byte("This is the first line.\n#hashtag is not a heading.\n- This is a dash.\n")))
+}
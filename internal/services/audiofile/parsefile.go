@@ -13,32 +13,161 @@ import (
 	"talkliketv.com/tltv/internal/interfaces"
 	"talkliketv.com/tltv/internal/services"
 	"unicode"
+	"unicode/utf8"
 )
 
+func init() {
+	registerParser(formatParser{
+		name:       "srt",
+		format:     Srt,
+		mimeTypes:  []string{"application/x-subrip", "text/srt"},
+		extensions: []string{".srt"},
+		sniff:      sniffFirstLines(srtFormatCheck, likelyConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseSrt(f, seg), nil
+		}),
+	})
+	registerParser(formatParser{
+		name:       "lines",
+		format:     OnePhrasePerLine,
+		mimeTypes:  []string{"text/plain"},
+		extensions: []string{".txt"},
+		sniff:      sniffOnePhrasePerLine,
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return untimedCues(parseSingle(f, seg)), nil
+		}),
+	})
+	registerParser(formatParser{
+		name:       "paragraph",
+		format:     Paragraph,
+		mimeTypes:  []string{"text/plain"},
+		extensions: []string{".txt"},
+		sniff:      sniffParagraph,
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return untimedCues(parseParagraph(f, seg)), nil
+		}),
+	})
+}
+
 const (
-	minimumPhraseLength = 4
-	maximumPhraseLength = 10
+	// minimumPhraseLength, maximumPhraseLength and maximumPhraseCharacters are the defaults
+	// of the phrase policy
+	minimumPhraseLength     = 4
+	maximumPhraseLength     = 10
+	maximumPhraseCharacters = 150
 )
 
-func parseFileContent(f multipart.File, fileType TextFormat) ([]string, error) {
-	switch fileType {
-	case Srt:
-		return parseSrt(f), nil
-	case Paragraph:
-		return parseParagraph(f), nil
-	case OnePhrasePerLine:
-		return parseSingle(f), nil
-	default:
-		return nil, errors.New("file must be srt, paragraph or one phrase per line")
+// punctuationKind is what a punctuation mark ends
+type punctuationKind int
+
+const (
+	notPunctuation punctuationKind = iota
+	clausePunctuation
+	terminalPunctuation
+)
+
+// scriptPunctuation holds the marks that end a sentence (terminal) or a clause in the
+// scripts
+type scriptPunctuation struct {
+	scripts  []*unicode.RangeTable
+	terminal []rune
+	clause   []rune
+}
+
+// commonPunctuation is the punctuation shared by most scripts
+var commonPunctuation = scriptPunctuation{
+	terminal: []rune{'.', '!', '?'},
+	clause:   []rune{',', ';', ':'},
+}
+
+// punctuationTables are the marks of each script. The table of the script of the text is
+// checked before commonPunctuation so a script can give an ascii mark a different meaning,
+// like the Greek question mark ; or the Armenian full stop : typed in place of ։
+var punctuationTables = []scriptPunctuation{
+	{
+		scripts:  []*unicode.RangeTable{unicode.Greek},
+		terminal: []rune{';', '\u037e'},
+		clause:   []rune{'\u0387'},
+	},
+	{
+		// the Armenian question ՞ and exclamation ՜ marks are written over a vowel inside
+		// the word and do not end it
+		scripts:  []*unicode.RangeTable{unicode.Armenian},
+		terminal: []rune{'\u0589', ':'},
+		clause:   []rune{'\u055d'},
+	},
+	{
+		// Arabic, Persian and Urdu
+		scripts:  []*unicode.RangeTable{unicode.Arabic},
+		terminal: []rune{'\u061f', '\u06d4'},
+		clause:   []rune{'\u060c', '\u061b'},
+	},
+	{
+		scripts:  []*unicode.RangeTable{unicode.Hebrew},
+		terminal: []rune{'\u05c3'},
+	},
+	{
+		// the danda । and double danda ॥
+		scripts:  []*unicode.RangeTable{unicode.Devanagari, unicode.Bengali, unicode.Gurmukhi, unicode.Oriya},
+		terminal: []rune{'\u0964', '\u0965'},
+	},
+	{
+		scripts:  []*unicode.RangeTable{unicode.Ethiopic},
+		terminal: []rune{'\u1362', '\u1367', '\u1368'},
+		clause:   []rune{'\u1363', '\u1364', '\u1365', '\u1366'},
+	},
+	{
+		scripts:  []*unicode.RangeTable{unicode.Myanmar},
+		terminal: []rune{'\u104b'},
+		clause:   []rune{'\u104a'},
+	},
+	{
+		scripts:  []*unicode.RangeTable{unicode.Khmer},
+		terminal: []rune{'\u17d4', '\u17d5'},
+		clause:   []rune{'\u17d6'},
+	},
+	{
+		scripts:  []*unicode.RangeTable{unicode.Tibetan},
+		terminal: []rune{'\u0f0d', '\u0f0e'},
+	},
+	{
+		scripts:  []*unicode.RangeTable{unicode.Han, unicode.Hiragana, unicode.Katakana},
+		terminal: []rune{'。', '！', '？', '｡'},
+		clause:   []rune{'、', '，', '；', '：', '､'},
+	},
+}
+
+// checkOptions checks that the options that only select the phrases of one format are not
+// used with a file of another format
+func checkOptions(fileType TextFormat, opts interfaces.ParseOptions) error {
+	if opts.HasTimeRange() && !IsTimed(fileType) {
+		return errors.New("start_time and end_time can only be used with srt, vtt, ass, ttml, lrc or youtube transcript files")
 	}
+	if len(opts.Chapters) > 0 && fileType != Epub {
+		return errors.New("chapters can only be used with epub files")
+	}
+	if opts.HasClippingFilter() && fileType != Kindle {
+		return errors.New("book, from_date and to_date can only be used with kindle clippings")
+	}
+	return nil
 }
 
-func ProcessFile(fh *multipart.FileHeader, af AudioFileX, cfg config.Config, titleName string) ([]interfaces.Phrase, *os.File, error) {
-	// Update FileParse to accept context
-	stringsSlice, err := FileParse(fh, af, cfg.FileUploadLimit)
+// ProcessFile parses the uploaded file, or aligns it with its translation file when
+// translationFh is not nil, and returns the result with the phrases to create audio for. If
+// there are more than MaxNumPhrases it returns a zip of files of phrases that can be uploaded
+// instead and ErrTooManyPhrases.
+func ProcessFile(fh, translationFh *multipart.FileHeader, af AudioFileX, cfg config.Config, titleName string, opts interfaces.ParseOptions) (interfaces.ParseResult, *os.File, error) {
+	var result interfaces.ParseResult
+	var err error
+	if translationFh != nil {
+		result, err = DualFileParse(fh, translationFh, af, cfg.FileUploadLimit, opts)
+	} else {
+		result, err = FileParse(fh, af, cfg.FileUploadLimit, opts)
+	}
 	if err != nil {
-		return nil, nil, err
+		return interfaces.ParseResult{}, nil, err
 	}
+	stringsSlice := result.FileLines()
 
 	// send back zip of split files of phrase that requester can use if too big
 	if len(stringsSlice) > cfg.MaxNumPhrases {
@@ -49,46 +178,82 @@ func ProcessFile(fh *multipart.FileHeader, af AudioFileX, cfg config.Config, tit
 		// Pass context to CreatePhrasesZip
 		zipFile, err := af.CreatePhrasesZip(chunkedPhrases, phrasesBasePath, titleName)
 		if err != nil {
-			return nil, nil, err
-		}
-		return nil, zipFile, interfaces.ErrTooManyPhrases
-	}
-
-	// This operation is fast and CPU-bound, so no need for context check
-	phrases := make([]interfaces.Phrase, len(stringsSlice))
-	for i := range stringsSlice {
-		phrases[i] = interfaces.Phrase{
-			ID:   i,
-			Text: stringsSlice[i],
+			return interfaces.ParseResult{}, nil, err
 		}
+		return interfaces.ParseResult{}, zipFile, interfaces.ErrTooManyPhrases
 	}
 
-	return phrases, nil, nil
+	return result, nil, nil
 }
 
-func FileParse(fh *multipart.FileHeader, af AudioFileX, fileUploadLimit int64) ([]string, error) {
+func FileParse(fh *multipart.FileHeader, af AudioFileX, fileUploadLimit int64, opts interfaces.ParseOptions) (interfaces.ParseResult, error) {
 	// Check if file size is too large 64000 == 8KB ~ approximately 4 pages of text
 	if fh.Size > fileUploadLimit {
-		return nil, services.ErrFileTooLarge(fh.Size, fileUploadLimit)
+		return interfaces.ParseResult{}, services.ErrFileTooLarge(fh.Size, fileUploadLimit)
 	}
 	src, err := fh.Open()
 	if err != nil {
-		return nil, err
+		return interfaces.ParseResult{}, err
 	}
 	defer src.Close()
 
 	// get an array of all the phrases from the uploaded file
-	stringsSlice, err := af.GetLines(src)
+	result, err := af.GetLines(src, uploadHints(opts, fh))
 	if err != nil {
-		return nil, services.ErrUnableToParseFile(err)
+		return interfaces.ParseResult{}, services.ErrUnableToParseFile(err)
 	}
 
-	return stringsSlice, nil
+	return result, nil
 }
 
-func ZipStringsSlice(af AudioFileX, slice []string, max int, path, name string) (*os.File, error) {
-	chunkedPhrases := slices.Chunk(slice, max)
+// DualFileParse aligns the subtitles or sentences of the uploaded file with the subtitles or
+// sentences of its translation file.
+func DualFileParse(fh, translationFh *multipart.FileHeader, af AudioFileX, fileUploadLimit int64, opts interfaces.ParseOptions) (interfaces.ParseResult, error) {
+	for _, h := range []*multipart.FileHeader{fh, translationFh} {
+		if h.Size > fileUploadLimit {
+			return interfaces.ParseResult{}, services.ErrFileTooLarge(h.Size, fileUploadLimit)
+		}
+	}
+	src, err := fh.Open()
+	if err != nil {
+		return interfaces.ParseResult{}, err
+	}
+	defer src.Close()
+	translationSrc, err := translationFh.Open()
+	if err != nil {
+		return interfaces.ParseResult{}, err
+	}
+	defer translationSrc.Close()
+
+	result, err := af.GetAlignedLines(src, translationSrc, uploadHints(opts, fh))
+	if err != nil {
+		return interfaces.ParseResult{}, services.ErrUnableToParseFile(err)
+	}
+
+	return result, nil
+}
+
+// ZipParseResult zips files of at most max phrases of the parsed file and, for an epub, a
+// list of its chapters so the phrases of one chapter can be created and, for a pdf, the
+// text that was extracted so it can be checked
+func ZipParseResult(af AudioFileX, result interfaces.ParseResult, max int, path, name string) (*os.File, error) {
+	chunkedPhrases := slices.Chunk(result.FileLines(), max)
 	phrasesBasePath := path + name + "/"
+	if len(result.Chapters) > 0 || result.Text != "" {
+		if err := os.MkdirAll(phrasesBasePath, 0777); err != nil {
+			return nil, err
+		}
+	}
+	if len(result.Chapters) > 0 {
+		if err := writeChapterList(phrasesBasePath, name, result.Chapters); err != nil {
+			return nil, err
+		}
+	}
+	if result.Text != "" {
+		if err := writeExtractedText(phrasesBasePath, name, result.Text); err != nil {
+			return nil, err
+		}
+	}
 	// create zip of phrases files of maxNumPhrases for user to use instead of uploaded file
 	zipFile, err := af.CreatePhrasesZip(chunkedPhrases, phrasesBasePath, name)
 	if err != nil {
@@ -97,53 +262,87 @@ func ZipStringsSlice(af AudioFileX, slice []string, max int, path, name string)
 	return zipFile, nil
 }
 
-// parseSrt takes a srt multipart file and parses it into a slice of strings
-func parseSrt(f multipart.File) []string {
-	var stringsSlice []string
+// parseSrt takes a srt multipart file and parses it into phrases with the time of the
+// subtitle they are from
+func parseSrt(f multipart.File, seg segmenter) []cue {
+	var lines []string
 	scanner := bufio.NewScanner(f)
-	scanner.Scan()
-	var line string
 	for scanner.Scan() {
-		line = strings.TrimSpace(scanner.Text())
-		if line == "" ||
-			(line[0] >= '0' && line[0] <= '9') ||
-			(line[0] == '[' && line[len(line)-1] == ']') ||
-			strings.Contains(line, "<font") ||
-			strings.Contains(line, "font>") {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if len(lines) > 0 {
+		lines[0] = strings.TrimPrefix(lines[0], "\ufeff")
+	}
+
+	var phrases []cue
+	var timing cue
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if start, end, ok := parseTimingLine(line); ok {
+			timing = cue{start: start, end: end}
+			continue
+		}
+		if line == "" || isSrtIndex(lines, i) {
+			continue
+		}
+		if line[0] == '[' && line[len(line)-1] == ']' {
+			seg.cleanup.discard(line, interfaces.DiscardMarkupOnly)
+			continue
+		}
+		if strings.Contains(line, "<font") || strings.Contains(line, "font>") {
+			seg.cleanup.discard(line, interfaces.DiscardFontStyled)
 			continue
 		}
-		scanner.Scan()
-		nextLine := scanner.Text()
-		if nextLine != "" {
-			line = strings.ReplaceAll(line, "\n", "") + " " + nextLine
-			line = strings.ReplaceAll(line, "\t", "")
+		// the second line of the subtitle is part of the same phrase
+		if i+1 < len(lines) && lines[i+1] != "" && !isSrtIndex(lines, i+1) {
+			if _, _, ok := parseTimingLine(lines[i+1]); !ok {
+				i++
+				line = strings.ReplaceAll(line+" "+lines[i], "\t", "")
+			}
 		}
-		line = replaceFmt(line)
+		timing.text = replaceFmt(line, seg.cleanup)
 
-		phrases := splitLongPhrases(line)
-		stringsSlice = append(stringsSlice, phrases...)
+		phrases = append(phrases, cuePhrases(timing, seg)...)
 	}
 
-	return stringsSlice
+	return phrases
 }
 
-// splitLongPhrases splits a long phrase into smaller phrases based on punctuation
-func splitLongPhrases(line string) []string {
+// isSrtIndex checks if the line at i is the number of a subtitle, which is the line of digits
+// before its timing
+func isSrtIndex(lines []string, i int) bool {
+	if i+1 >= len(lines) || lines[i] == "" || strings.Trim(lines[i], "0123456789") != "" {
+		return false
+	}
+	_, _, ok := parseTimingLine(lines[i+1])
+	return ok
+}
+
+// splitLongPhrases splits a long phrase into smaller phrases based on punctuation. Phrases in
+// languages that do not separate words with spaces are measured in characters instead of words.
+func splitLongPhrases(line string, seg segmenter) []string {
+	if seg.unspaced(line) {
+		return splitLongUnspacedPhrases(line, seg)
+	}
 	var splitString []string
+	minimum, maximum := seg.wordLimits()
 
 	words := strings.Fields(line)
 	// if phrase is too short don't keep it
-	if len(words) < minimumPhraseLength {
+	if len(words) < minimum {
+		if hasWords(line) {
+			seg.cleanup.discard(strings.Join(words, " "), interfaces.DiscardTooShort)
+		}
 		return []string{}
 	}
-	if len(words) < maximumPhraseLength {
+	if len(words) < maximum {
 		// if phrase isn't too long don't split it
 		return []string{line}
 	}
 	// split into an array of strings along punctuation
 	last := 0
 	for i, word := range words {
-		if unicode.IsPunct(rune(word[len(word)-1])) {
+		if seg.splitsAfter(wordEnding(word)) {
 			nextString := ""
 			for j := last; j <= i; j++ {
 				nextString = nextString + words[j] + " "
@@ -159,38 +358,7 @@ func splitLongPhrases(line string) []string {
 	// if long phrase has punctuation split on punctuation
 	if len(splitString) > 1 {
 		// combine any strings that are less than the minimumPhraseLength with the string after it
-		i := 0
-		for i < len(splitString)-1 {
-			// if phrase is small combine it with the next one
-			wordsInString := strings.Fields(splitString[i])
-			if len(wordsInString) < minimumPhraseLength {
-				splitString[i] = splitString[i] + " " + splitString[i+1]
-				// remove the next index of split string
-				splitString = append(splitString[:i+1], splitString[i+2:]...)
-			} else {
-				// if both combined are less than maximum than concat
-				next := splitString[i] + " " + splitString[i+1]
-				nextWordCount := strings.Fields(next)
-				if len(nextWordCount) <= maximumPhraseLength {
-					splitString[i] = next
-					splitString = append(splitString[:i+1], splitString[i+2:]...)
-				}
-			}
-			// else continue
-			i++
-		}
-
-		// now check the last index and pen ultimate of the split string and combine if shorter than minimumPhraseLength
-		if len(splitString) > 1 {
-			lastElem := len(splitString) - 1
-			lastElemCount := len(strings.Fields(splitString[lastElem]))
-			penUltimateElemCount := len(strings.Fields(splitString[lastElem-1]))
-			if lastElemCount < minimumPhraseLength || penUltimateElemCount < minimumPhraseLength {
-				lastString := splitString[lastElem-1] + " " + splitString[lastElem]
-				splitString[lastElem] = lastString
-				splitString = splitString[:lastElem-1]
-			}
-		}
+		splitString = combinePhrases(splitString, wordCount, " ", minimum, maximum)
 	} else {
 		return []string{line}
 	}
@@ -202,8 +370,95 @@ func splitLongPhrases(line string) []string {
 	return splitString
 }
 
-// parseParagraph takes a txt multipart file in paragraph form and returns a slice of strings
-func parseParagraph(f multipart.File) []string {
+// wordEnding returns the punctuation the word ends with. Closing quotes, brackets and
+// invisible marks like the right-to-left mark after the punctuation are skipped, and a word
+// that ends with a dash or a closing bracket ends a clause.
+func wordEnding(word string) punctuationKind {
+	runes := []rune(strings.TrimRightFunc(word, isTrailingMark))
+	if len(runes) > 0 {
+		if kind := punctuationOf(runes[len(runes)-1], lastLetter(runes)); kind != notPunctuation {
+			return kind
+		}
+	}
+	last, _ := utf8.DecodeLastRuneInString(word)
+	if unicode.In(last, unicode.Pd, unicode.Pe) {
+		return clausePunctuation
+	}
+	return notPunctuation
+}
+
+// isTrailingMark checks if the rune can follow the punctuation that ends a clause
+func isTrailingMark(r rune) bool {
+	return r == '\'' || r == '"' || unicode.In(r, unicode.Pe, unicode.Pf, unicode.Cf)
+}
+
+// lastLetter returns the last letter of the runes or 0 if there is none
+func lastLetter(runes []rune) rune {
+	for i := len(runes) - 1; i >= 0; i-- {
+		if unicode.IsLetter(runes[i]) {
+			return runes[i]
+		}
+	}
+	return 0
+}
+
+// punctuationOf returns what the rune ends in text whose last letter is letter. The table
+// of the script of the letter is checked first, then the common punctuation and then the
+// marks of all other scripts, so a ؟ still ends a sentence after a latin word.
+func punctuationOf(r, letter rune) punctuationKind {
+	script := scriptPunctuationOf(letter)
+	if kind := script.kind(r); kind != notPunctuation {
+		return kind
+	}
+	if kind := commonPunctuation.kind(r); kind != notPunctuation {
+		return kind
+	}
+	for _, table := range punctuationTables {
+		if kind := table.kind(r); kind != notPunctuation {
+			return kind
+		}
+	}
+	return notPunctuation
+}
+
+// scriptPunctuationOf returns the punctuation table for the script of the letter
+func scriptPunctuationOf(letter rune) scriptPunctuation {
+	for _, table := range punctuationTables {
+		if unicode.In(letter, table.scripts...) {
+			return table
+		}
+	}
+	return commonPunctuation
+}
+
+// kind returns if the rune is terminal or clause punctuation in the table
+func (sp scriptPunctuation) kind(r rune) punctuationKind {
+	switch {
+	case slices.Contains(sp.terminal, r):
+		return terminalPunctuation
+	case slices.Contains(sp.clause, r):
+		return clausePunctuation
+	default:
+		return notPunctuation
+	}
+}
+
+// maxPhraseCharacters returns the most characters a phrase can have from the policy or the
+// default
+func maxPhraseCharacters(policy interfaces.PhrasePolicy) int {
+	if policy.MaxChars > 0 {
+		return policy.MaxChars
+	}
+	return maximumPhraseCharacters
+}
+
+// wordCount counts the words in a phrase
+func wordCount(phrase string) int {
+	return len(strings.Fields(phrase))
+}
+
+// parseParagraph takes a txt file in paragraph form and returns a slice of strings
+func parseParagraph(f io.Reader, seg segmenter) []string {
 	if f == nil {
 		return nil
 	}
@@ -214,11 +469,11 @@ func parseParagraph(f multipart.File) []string {
 	}
 
 	allText := string(content)
-	allLines := splitOnEndingPunctuation(allText)
+	allLines := splitOnEndingPunctuation(allText, seg)
 
 	var stringsSlice []string
 	for _, line := range allLines {
-		phrases := splitLongPhrases(line)
+		phrases := splitLongPhrases(line, seg)
 		if len(phrases) > 0 {
 			stringsSlice = append(stringsSlice, phrases...)
 		}
@@ -227,34 +482,14 @@ func parseParagraph(f multipart.File) []string {
 	return stringsSlice
 }
 
-// splitOnEndingPunctuation splits the text on sentence-ending punctuation
-func splitOnEndingPunctuation(text string) []string {
-	// Regular expression to split the text at sentence-ending punctuation
-	re := regexp.MustCompile(`[!.?]`)
-
-	// Split the text using the regular expression
-	sentences := re.Split(text, -1)
-
-	// Filter out empty strings
-	var filtered []string
-	for _, s := range sentences {
-		trimmed := strings.TrimSpace(s)
-		if trimmed != "" {
-			filtered = append(filtered, trimmed)
-		}
-	}
-
-	return filtered
-}
-
-// parseSingle takes a txt multipart file with one phrase per line and parses it
-// into a slice of strings
-func parseSingle(f multipart.File) []string {
+// parseSingle takes a txt file with one phrase per line, like a script, and parses
+// it into a slice of strings
+func parseSingle(f io.Reader, seg segmenter) []string {
 	var stringsSlice []string
 	scanner := bufio.NewScanner(f)
 	for scanner.Scan() {
-		line := scanner.Text()
-		phrases := splitLongPhrases(line)
+		line := seg.cleanup.clean(scanner.Text())
+		phrases := splitLongPhrases(line, seg)
 		stringsSlice = append(stringsSlice, phrases...)
 	}
 
@@ -262,8 +497,10 @@ func parseSingle(f multipart.File) []string {
 }
 
 // replaceFmt is a helper function for parseSrt that replaces characters that are not part
-// of the phrase like descriptions or tags
-func replaceFmt(line string) string {
+// of the phrase like descriptions or tags. The annotations of the cleanup rules are removed
+// after the tags and before the dashes and music notes they are found by.
+func replaceFmt(line string, c *cleanup) string {
+	original := line
 	// remove any characters between brackets and brackets [...] or {...} or <...>
 	re := regexp.MustCompile("\\[.*?]") //nolint:gosimple
 	line = re.ReplaceAllString(line, "")
@@ -271,6 +508,10 @@ func replaceFmt(line string) string {
 	line = re.ReplaceAllString(line, "")
 	re = regexp.MustCompile("<.*?>")
 	line = re.ReplaceAllString(line, "")
+	if hasWords(original) && !hasWords(line) {
+		c.discard(strings.TrimSpace(original), interfaces.DiscardMarkupOnly)
+	}
+	line = c.clean(line)
 	line = strings.ReplaceAll(line, "-", "")
 	line = strings.ReplaceAll(line, "♪", "")
 	line = strings.ReplaceAll(line, "\"", "")
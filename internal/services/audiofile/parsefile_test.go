@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
 	"talkliketv.com/tltv/internal/util"
 	"testing"
 
@@ -12,8 +13,8 @@ import (
 	"mime/multipart"
 )
 
-// TestParseFileContent tests the parseFileContent function using real files
-func TestParseFileContent(t *testing.T) {
+// TestParseFile tests the parseFile function using real files
+func TestParseFile(t *testing.T) {
 	if util.Test != "unit" && !testing.Short() {
 		t.Skip("skipping unit test")
 	}
@@ -42,6 +43,43 @@ Another subtitle line for testing.`,
 			expected:    []string{"Hello world this is a test subtitle.", "Another subtitle line for testing."},
 			expectError: false,
 		},
+		{
+			name: "Parse WebVTT file",
+			content: `WEBVTT
+
+1
+00:00:01.000 --> 00:00:05.000
+Hello world this is a test subtitle.
+
+00:00:06.000 --> 00:00:10.000
+Another subtitle line for testing.`,
+			fileType:    WebVTT,
+			expected:    []string{"Hello world this is a test subtitle.", "Another subtitle line for testing."},
+			expectError: false,
+		},
+		{
+			name: "Parse ASS file",
+			content: `[Script Info]
+ScriptType: v4.00+
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:05.00,Default,,0,0,0,,Hello world this is a test subtitle.
+Dialogue: 0,0:00:06.00,0:00:10.00,Default,,0,0,0,,Another subtitle line for testing.`,
+			fileType:    Ass,
+			expected:    []string{"Hello world this is a test subtitle.", "Another subtitle line for testing."},
+			expectError: false,
+		},
+		{
+			name: "Parse TTML file",
+			content: `<tt xmlns="http://www.w3.org/ns/ttml"><body><div>
+<p begin="00:00:01.000" end="00:00:05.000">Hello world this is a test subtitle.</p>
+<p begin="00:00:06.000" end="00:00:10.000">Another subtitle line<br/>for testing.</p>
+</div></body></tt>`,
+			fileType:    Ttml,
+			expected:    []string{"Hello world this is a test subtitle.", "Another subtitle line for testing."},
+			expectError: false,
+		},
 		{
 			name:        "Parse paragraph file",
 			content:     "This is the first paragraph. It has multiple sentences. And some bad punctuation!\n\nThis is the second paragraph. With even more text.",
@@ -71,7 +109,8 @@ Another subtitle line for testing.`,
 			defer file.Close()
 
 			// Call the function being tested - os.File satisfies multipart.File
-			result, err := parseFileContent(file, tt.fileType)
+			parsed, err := parseFile(file, tt.fileType, interfaces.ParseOptions{}, nil)
+			result := parsed.cues
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -80,7 +119,7 @@ Another subtitle line for testing.`,
 				assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
 				for i, phrase := range tt.expected {
 					if i < len(result) {
-						assert.Contains(t, result[i], phrase, "Result should contain expected phrase")
+						assert.Contains(t, result[i].text, phrase, "Result should contain expected phrase")
 					}
 				}
 			}
@@ -114,6 +153,17 @@ Hello world this is a test subtitle.
 Another subtitle line for testing.`,
 			expected: []string{"Hello world this is a test subtitle.", "Another subtitle line for testing."},
 		},
+		{
+			name: "Japanese SRT file",
+			content: `1
+00:00:01,000 --> 00:00:05,000
+今日はいい天気ですね。
+
+2
+00:00:06,000 --> 00:00:10,000
+はい。`,
+			expected: []string{"今日はいい天気ですね。"},
+		},
 		{
 			name: "SRT with formatting tags",
 			content: `1
@@ -129,6 +179,18 @@ Another subtitle line for testing.`,
 This has <i>italic</i> formatting.`,
 			expected: []string{"This has some brackets.", "This has italic formatting."},
 		},
+		{
+			name: "SRT with dialogue starting with a number",
+			content: `1
+00:00:01,000 --> 00:00:05,000
+10 people came to the party tonight.
+
+2
+00:00:06,000 --> 00:00:10,000
+We only expected five of them
+3 of them brought their dogs.`,
+			expected: []string{"10 people came to the party tonight.", "We only expected five of them 3 of them brought their dogs."},
+		},
 	}
 
 	for _, tt := range tests {
@@ -144,12 +206,12 @@ This has <i>italic</i> formatting.`,
 			defer file.Close()
 
 			// Test the parseSrt function
-			result := parseSrt(file)
+			result := parseSrt(file, segmenter{})
 
 			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
 			for i, phrase := range tt.expected {
 				if i < len(result) {
-					assert.Equal(t, strings.TrimSpace(phrase), strings.TrimSpace(result[i]), "Result should match expected phrase")
+					assert.Equal(t, strings.TrimSpace(phrase), strings.TrimSpace(result[i].text), "Result should match expected phrase")
 				}
 			}
 		})
@@ -181,6 +243,11 @@ func TestParseParagraph(t *testing.T) {
 			content:  "First sentence with exclamation! Second sentence with question? Third sentence with period.",
 			expected: []string{"First sentence with exclamation", "Second sentence with question", "Third sentence with period"},
 		},
+		{
+			name:     "Terminal punctuation and abbreviations are kept",
+			content:  "Mr. Smith paid $3.50 at 5 p.m. Did he really pay that much?",
+			expected: []string{"Mr. Smith paid $3.50 at 5 p.m.", "Did he really pay that much?"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -196,7 +263,7 @@ func TestParseParagraph(t *testing.T) {
 			defer file.Close()
 
 			// Test the parseParagraph function
-			result := parseParagraph(file)
+			result := parseParagraph(file, segmenter{})
 
 			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
 			for i, phrase := range tt.expected {
@@ -248,7 +315,7 @@ func TestParseSingle(t *testing.T) {
 			defer file.Close()
 
 			// Test the parseSingle function
-			result := parseSingle(file)
+			result := parseSingle(file, segmenter{})
 
 			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
 			for i, phrase := range tt.expected {
@@ -268,6 +335,7 @@ func TestSplitLongPhrases(t *testing.T) {
 	tests := []struct {
 		name     string
 		input    string
+		policy   interfaces.PhrasePolicy
 		expected []string
 	}{
 		{
@@ -275,6 +343,30 @@ func TestSplitLongPhrases(t *testing.T) {
 			input:    "Hi there",
 			expected: []string{},
 		},
+		{
+			name:     "Short phrase with a lower minimum",
+			input:    "Hi there",
+			policy:   interfaces.PhrasePolicy{MinWords: 2},
+			expected: []string{"Hi there"},
+		},
+		{
+			name:     "Long phrase with a higher maximum",
+			input:    "This is a very long sentence that should be split, because it has more than ten words.",
+			policy:   interfaces.PhrasePolicy{MaxWords: 20},
+			expected: []string{"This is a very long sentence that should be split, because it has more than ten words."},
+		},
+		{
+			name:     "Beginner chunks",
+			input:    "When I was young, we lived by the sea, and every morning we walked to school.",
+			policy:   interfaces.PhrasePolicy{MinWords: 2, MaxWords: 5},
+			expected: []string{"When I was young,", "we lived by the sea,", "and every morning we walked to school."},
+		},
+		{
+			name:     "Sentences only",
+			input:    "This is a very long sentence that should be split, because it has more than ten words. This is the second sentence.",
+			policy:   interfaces.PhrasePolicy{SentencesOnly: true},
+			expected: []string{"This is a very long sentence that should be split, because it has more than ten words.", "This is the second sentence."},
+		},
 		{
 			name:     "Medium phrase (between min and max)",
 			input:    "This is a test phrase with good length.",
@@ -285,11 +377,31 @@ func TestSplitLongPhrases(t *testing.T) {
 			input:    "This is a very long sentence that should be split, because it has more than ten words and it helps with text processing. This is the second sentence.",
 			expected: []string{"This is a very long sentence that should be split, ", "because it has more than ten words and it helps with text processing. ", "This is the second sentence."},
 		},
+		{
+			name:     "Arabic comma and question mark",
+			input:    "ذهبت إلى السوق مع أخي الصغير صباح اليوم، واشترينا الكثير من الفواكه والخضروات الطازجة للعائلة الكبيرة؟",
+			expected: []string{"ذهبت إلى السوق مع أخي الصغير صباح اليوم،", "واشترينا الكثير من الفواكه والخضروات الطازجة للعائلة الكبيرة؟"},
+		},
+		{
+			name:     "Arabic comma followed by a right-to-left mark",
+			input:    "ذهبت إلى السوق مع أخي الصغير صباح اليوم،\u200f واشترينا الكثير من الفواكه والخضروات الطازجة للعائلة",
+			expected: []string{"ذهبت إلى السوق مع أخي الصغير صباح اليوم،\u200f", "واشترينا الكثير من الفواكه والخضروات الطازجة للعائلة"},
+		},
+		{
+			name:     "Hindi danda",
+			input:    "मैं कल अपने दोस्तों के साथ बाज़ार गया था। वहाँ हमने बहुत सारे फल और सब्ज़ियाँ खरीदीं।",
+			expected: []string{"मैं कल अपने दोस्तों के साथ बाज़ार गया था।", "वहाँ हमने बहुत सारे फल और सब्ज़ियाँ खरीदीं।"},
+		},
+		{
+			name:     "Greek question mark",
+			input:    "Πού πήγες χθες το βράδυ με τους φίλους σου; Εμείς μείναμε στο σπίτι και διαβάσαμε βιβλία.",
+			expected: []string{"Πού πήγες χθες το βράδυ με τους φίλους σου;", "Εμείς μείναμε στο σπίτι και διαβάσαμε βιβλία."},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := splitLongPhrases(tt.input)
+			result := splitLongPhrases(tt.input, segmenter{policy: tt.policy})
 			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
 			for i, phrase := range tt.expected {
 				if i < len(result) {
@@ -300,6 +412,43 @@ func TestSplitLongPhrases(t *testing.T) {
 	}
 }
 
+// TestWordEnding tests finding the words that end a sentence or clause in any script
+func TestWordEnding(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		word     string
+		expected punctuationKind
+	}{
+		{name: "ascii comma", word: "hello,", expected: clausePunctuation},
+		{name: "ascii period in closing quote", word: "hello.\"", expected: terminalPunctuation},
+		{name: "closing bracket", word: "(hello)", expected: clausePunctuation},
+		{name: "dash", word: "-", expected: clausePunctuation},
+		{name: "exclamation in closing bracket", word: "daylight!)", expected: terminalPunctuation},
+		{name: "no punctuation", word: "hello", expected: notPunctuation},
+		{name: "inner hyphen", word: "roller-skated", expected: notPunctuation},
+		{name: "arabic comma", word: "اليوم،", expected: clausePunctuation},
+		{name: "arabic question mark", word: "حالك؟", expected: terminalPunctuation},
+		{name: "arabic comma and right-to-left mark", word: "اليوم،\u200f", expected: clausePunctuation},
+		{name: "urdu full stop", word: "ہوں۔", expected: terminalPunctuation},
+		{name: "devanagari danda", word: "था।", expected: terminalPunctuation},
+		{name: "greek question mark", word: "κάνεις;", expected: terminalPunctuation},
+		{name: "greek ano teleia", word: "κάνεις\u0387", expected: clausePunctuation},
+		{name: "armenian question mark inside word", word: "Ո՞ւր", expected: notPunctuation},
+		{name: "armenian comma", word: "գնում՝", expected: clausePunctuation},
+		{name: "ethiopic comma", word: "ሰላም፣", expected: clausePunctuation},
+		{name: "opening inverted question mark", word: "¿Qué", expected: notPunctuation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, wordEnding(tt.word))
+		})
+	}
+}
+
 // TestReplaceFmt tests the replaceFmt helper function
 func TestReplaceFmt(t *testing.T) {
 	if util.Test != "unit" && !testing.Short() {
@@ -329,51 +478,18 @@ func TestReplaceFmt(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := replaceFmt(tt.input)
+			result := replaceFmt(tt.input, nil)
 			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
 
-// TestSplitOnEndingPunctuation tests the splitOnEndingPunctuation helper function
-func TestSplitOnEndingPunctuation(t *testing.T) {
-	if util.Test != "unit" && !testing.Short() {
-		t.Skip("skipping unit test")
-	}
-	tests := []struct {
-		name     string
-		input    string
-		expected []string
-	}{
-		{
-			name:     "Simple sentences with periods",
-			input:    "This is the first sentence. This is the second sentence.",
-			expected: []string{"This is the first sentence", "This is the second sentence"},
-		},
-		{
-			name:     "Mixed punctuation",
-			input:    "First sentence! Second sentence? Third sentence.",
-			expected: []string{"First sentence", "Second sentence", "Third sentence"},
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			result := splitOnEndingPunctuation(tt.input)
-			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
-			for i, sentence := range tt.expected {
-				assert.Equal(t, sentence, result[i])
-			}
-		})
-	}
-}
-
 // TestParseParagraphNilFile tests the parseParagraph function with a nil file
 func TestParseParagraphNilFile(t *testing.T) {
 	if util.Test != "unit" && !testing.Short() {
 		t.Skip("skipping unit test")
 	}
 	var file multipart.File = nil
-	result := parseParagraph(file)
+	result := parseParagraph(file, segmenter{})
 	assert.Nil(t, result, "Result should be nil for nil input")
 }
@@ -0,0 +1,278 @@
+package audiofile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"path/filepath"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+)
+
+// The confidence a Parser sniffs the content of a file with. The format with the highest
+// confidence is used, so a format that is certain wins over the plain text formats any
+// file can be read as.
+const (
+	// certainConfidence is a format recognized by its signature or root element
+	certainConfidence = 100
+	// strongConfidence is a format recognized by markup that other formats can contain,
+	// like the <body> of an html page that a srv3 transcript also has
+	strongConfidence = 90
+	// likelyConfidence is a format recognized by the lines of its text, like subtitle timings
+	likelyConfidence = 75
+	// possibleConfidence is a format recognized by markers that ordinary text can have
+	possibleConfidence = 50
+	// proseConfidence is text whose lines hold several sentences or are wrapped in the middle
+	// of one
+	proseConfidence = 30
+	// shortLineConfidence is text whose lines are short enough to be a phrase each
+	shortLineConfidence = 20
+	// fallbackConfidence is any text
+	fallbackConfidence = 10
+	// hintConfidence is added to a format the file could be when the extension or MIME type
+	// of the uploaded file is one of the hints of the format
+	hintConfidence = 5
+)
+
+// sniffLines is how many lines at the start of a file are checked for subtitle timings
+const sniffLines = 15
+
+// The limits of what is read from a compressed upload like an Anki deck, an EPUB, a document
+// or a pdf, so a small compressed file cannot fill the memory of the server.
+const (
+	// maxEntrySize is the largest file or stream that is read from the upload
+	maxEntrySize = 64 << 20
+	// maxTotalSize is how much is read from all the files of the upload together
+	maxTotalSize = 256 << 20
+)
+
+// Parser detects and parses one format of uploaded file into phrases. Each format
+// registers its Parser with registerParser.
+type Parser interface {
+	// Name is the name of the format that can be sent in the format form field
+	Name() string
+	// Format is the TextFormat the parser parses
+	Format() TextFormat
+	// Binary checks if the file is parsed without decoding it as text first
+	Binary() bool
+	// MIMETypes and Extensions are the MIME types and file extensions of the format that
+	// are hints for detecting the format of an uploaded file
+	MIMETypes() []string
+	Extensions() []string
+	// Sniff returns how confident the parser is that the content is in its format, from 0
+	// when it is not to certainConfidence
+	Sniff(content []byte) int
+	// Parse parses the file into phrases
+	Parse(f multipart.File, opts interfaces.ParseOptions, seg segmenter) (parsed, error)
+}
+
+// parsed is what a Parser returns for a file
+type parsed struct {
+	cues []cue
+	// chapters are the chapters of an epub
+	chapters []interfaces.Chapter
+	// text is the text extracted from a pdf
+	text string
+}
+
+// formatParser is a Parser made of the functions of a format
+type formatParser struct {
+	name       string
+	format     TextFormat
+	binary     bool
+	mimeTypes  []string
+	extensions []string
+	sniff      func(content []byte) int
+	parse      func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) (parsed, error)
+}
+
+func (p formatParser) Name() string         { return p.name }
+func (p formatParser) Format() TextFormat   { return p.format }
+func (p formatParser) Binary() bool         { return p.binary }
+func (p formatParser) MIMETypes() []string  { return p.mimeTypes }
+func (p formatParser) Extensions() []string { return p.extensions }
+func (p formatParser) Sniff(content []byte) int {
+	return p.sniff(content)
+}
+func (p formatParser) Parse(f multipart.File, opts interfaces.ParseOptions, seg segmenter) (parsed, error) {
+	return p.parse(f, opts, seg)
+}
+
+// parsers are the registered parsers in the order they were registered, which is the order
+// formats with the same confidence are chosen in
+var parsers []Parser
+
+// registerParser adds the parser of a format to the parsers. It panics if the name or the
+// format is already registered.
+func registerParser(p Parser) {
+	if slices.ContainsFunc(parsers, func(r Parser) bool { return r.Name() == p.Name() || r.Format() == p.Format() }) {
+		panic("audiofile: parser registered twice for " + p.Name())
+	}
+	parsers = append(parsers, p)
+}
+
+// ParserNames returns the names of the registered formats in alphabetical order
+func ParserNames() []string {
+	names := make([]string, len(parsers))
+	for i, p := range parsers {
+		names[i] = p.Name()
+	}
+	slices.Sort(names)
+	return names
+}
+
+// ParserNamed returns the parser of the format with the name, like the value of the format
+// form field
+func ParserNamed(name string) (Parser, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, p := range parsers {
+		if p.Name() == name {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("format must be one of %s", strings.Join(ParserNames(), ", "))
+}
+
+// parserOf returns the parser of the format
+func parserOf(fileType TextFormat) (Parser, bool) {
+	i := slices.IndexFunc(parsers, func(p Parser) bool { return p.Format() == fileType })
+	if i < 0 {
+		return nil, false
+	}
+	return parsers[i], true
+}
+
+// sniffFormat returns the binary or text format the content has the highest confidence for,
+// and false when no format recognizes it. The fileName and contentType of the upload add
+// hintConfidence to the formats they are hints for.
+func sniffFormat(content []byte, binary bool, fileName, contentType string) (TextFormat, bool) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	var best Parser
+	bestScore := 0
+	for _, p := range parsers {
+		if p.Binary() != binary {
+			continue
+		}
+		score := p.Sniff(content)
+		if score <= 0 {
+			continue
+		}
+		if (ext != "" && slices.Contains(p.Extensions(), ext)) || (mediaType != "" && slices.Contains(p.MIMETypes(), mediaType)) {
+			score += hintConfidence
+		}
+		if score > bestScore {
+			best, bestScore = p, score
+		}
+	}
+	if best == nil {
+		return 0, false
+	}
+	return best.Format(), true
+}
+
+// DetectFormat determines the format of the uploaded file and returns the file ready to be
+// parsed with the encoding it was decoded from. The format named by opts.Format is used
+// instead of detecting it. Binary formats are parsed as they are uploaded and text formats
+// are decoded to UTF-8.
+func DetectFormat(f multipart.File, opts interfaces.ParseOptions) (TextFormat, multipart.File, string, error) {
+	if opts.Format != "" {
+		p, err := ParserNamed(opts.Format)
+		if err != nil {
+			return 0, nil, "", err
+		}
+		if p.Binary() {
+			if _, err := f.Seek(0, io.SeekStart); err != nil {
+				return 0, nil, "", err
+			}
+			return p.Format(), f, EncodingUTF8, nil
+		}
+		decoded, enc, err := DecodeFile(f, opts.Encoding)
+		if err != nil {
+			return 0, nil, "", err
+		}
+		return p.Format(), decoded, enc, nil
+	}
+
+	fileType, binary, err := detectBinaryFormat(f, opts.FileName, opts.ContentType)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	// binary formats store their text as UTF-8
+	if binary {
+		return fileType, f, EncodingUTF8, nil
+	}
+	decoded, enc, err := DecodeFile(f, opts.Encoding)
+	if err != nil {
+		return 0, nil, "", err
+	}
+	if fileType, err = detectTextFormat(decoded, opts.FileName, opts.ContentType); err != nil {
+		return 0, nil, "", err
+	}
+	return fileType, decoded, enc, nil
+}
+
+// uploadHints sets the name and content type of the uploaded file as the hints for detecting
+// its format
+func uploadHints(opts interfaces.ParseOptions, fh *multipart.FileHeader) interfaces.ParseOptions {
+	opts.FileName = fh.Filename
+	opts.ContentType = fh.Header.Get("Content-Type")
+	return opts
+}
+
+// parseFile parses the file with the parser of its format. The cues of timed formats are
+// limited to the time range of the options.
+func parseFile(f multipart.File, fileType TextFormat, opts interfaces.ParseOptions, c *cleanup) (parsed, error) {
+	if err := checkOptions(fileType, opts); err != nil {
+		return parsed{}, err
+	}
+	p, ok := parserOf(fileType)
+	if !ok {
+		return parsed{}, errors.New("file must be one of " + strings.Join(ParserNames(), ", "))
+	}
+	seg := newSegmenter(opts.Language, opts.Policy)
+	seg.cleanup = c
+	result, err := p.Parse(f, opts, seg)
+	if err != nil {
+		return parsed{}, err
+	}
+	if IsTimed(fileType) {
+		result.cues = inTimeRange(result.cues, opts.StartTime, opts.EndTime)
+	}
+	return result, nil
+}
+
+// parsedCues is the parse function of a format whose parser only returns cues
+func parsedCues(parse func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) ([]cue, error)) func(multipart.File, interfaces.ParseOptions, segmenter) (parsed, error) {
+	return func(f multipart.File, opts interfaces.ParseOptions, seg segmenter) (parsed, error) {
+		cues, err := parse(f, opts, seg)
+		return parsed{cues: cues}, err
+	}
+}
+
+// sniffIf returns the confidence when the check of the content is true
+func sniffIf(check func(content []byte) bool, confidence int) func(content []byte) int {
+	return func(content []byte) int {
+		if check(content) {
+			return confidence
+		}
+		return 0
+	}
+}
+
+// sniffFirstLines returns the confidence when a line at the start of the content passes the
+// check, like a subtitle timing
+func sniffFirstLines(check func(line string) bool, confidence int) func(content []byte) int {
+	return func(content []byte) int {
+		for i, line := range strings.SplitN(string(content), "\n", sniffLines+1) {
+			if i < sniffLines && check(strings.TrimSuffix(line, "\r")) {
+				return confidence
+			}
+		}
+		return 0
+	}
+}
@@ -0,0 +1,137 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParserNamed(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	// every format is registered once
+	names := ParserNames()
+	require.Len(t, names, int(JsonTranscript)+1)
+	for fileType := Srt; fileType <= JsonTranscript; fileType++ {
+		p, ok := parserOf(fileType)
+		require.True(t, ok)
+		require.Equal(t, fileType, p.Format())
+	}
+
+	p, err := ParserNamed(" Paragraph ")
+	require.NoError(t, err)
+	require.Equal(t, Paragraph, p.Format())
+	p, err = ParserNamed("pdf")
+	require.NoError(t, err)
+	require.True(t, p.Binary())
+
+	_, err = ParserNamed("doc")
+	require.EqualError(t, err, "format must be one of "+strings.Join(names, ", "))
+}
+
+func TestSniffFormat(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	// a bilingual tsv with a Markdown link in one of its sentences
+	linked := "I am going to the [market](https://example.com) today\tVoy al mercado hoy\n" +
+		"We are eating dinner at home tonight\tCenamos en casa esta noche\n"
+
+	tests := []struct {
+		name        string
+		content     string
+		fileName    string
+		contentType string
+		expected    TextFormat
+	}{
+		{
+			name: "short paragraphs",
+			content: "Juan wakes up early. He drinks a coffee.\n" +
+				"Then he walks to work. It is a sunny day.\n" +
+				"He meets his friend Ana. They talk for a while.\n" +
+				"At noon they eat lunch. The food is good.\n",
+			expected: Paragraph,
+		},
+		{
+			name: "wrapped paragraph",
+			content: "Juan wakes up early every morning and\n" +
+				"drinks a coffee before he walks to\n" +
+				"work with his friend Ana, who lives\n" +
+				"next door to him in the city.\n",
+			expected: Paragraph,
+		},
+		{
+			name: "one phrase per line",
+			content: "Where is the train station?\n" +
+				"I would like a coffee, please.\n" +
+				"How much does this cost?\n" +
+				"Can you help me find my hotel?\n",
+			expected: OnePhrasePerLine,
+		},
+		{
+			name:     "tie without a hint",
+			content:  linked,
+			expected: Bilingual,
+		},
+		{
+			name:     "tie broken by the extension",
+			content:  linked,
+			fileName: "notes.md",
+			expected: Markdown,
+		},
+		{
+			name:        "tie broken by the content type",
+			content:     linked,
+			contentType: "text/markdown; charset=utf-8",
+			expected:    Markdown,
+		},
+		{
+			name:     "a hint does not change a certain format",
+			content:  "WEBVTT\n\n00:01.000 --> 00:02.000\nHello there.\n",
+			fileName: "captions.srt",
+			expected: WebVTT,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fileType, ok := sniffFormat([]byte(tt.content), false, tt.fileName, tt.contentType)
+			require.True(t, ok)
+			require.Equal(t, tt.expected, fileType)
+		})
+	}
+
+	_, ok := sniffFormat([]byte("This is not a binary file.\n"), true, "book.epub", "")
+	require.False(t, ok)
+}
+
+func TestGetLinesFormat(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	content := "I am going to the market today\tVoy al mercado hoy\n" +
+		"We are eating dinner at home tonight\tCenamos en casa esta noche\n"
+	af := AudioFile{}
+
+	result, err := af.GetLines(createTmpFile(t, "detected.tsv", content), interfaces.ParseOptions{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"I am going to the market today", "We are eating dinner at home tonight"}, result.Lines)
+	require.Len(t, result.ToPhrases, 2)
+
+	// the format form field parses the tsv as one phrase per line
+	result, err = af.GetLines(createTmpFile(t, "lines.tsv", content), interfaces.ParseOptions{Format: "lines"})
+	require.NoError(t, err)
+	require.Empty(t, result.ToPhrases)
+	require.Len(t, result.Lines, 2)
+	require.Contains(t, result.Lines[0], "Voy al mercado hoy")
+
+	_, err = af.GetLines(createTmpFile(t, "unknown.tsv", content), interfaces.ParseOptions{Format: "doc"})
+	require.ErrorContains(t, err, "format must be one of")
+}
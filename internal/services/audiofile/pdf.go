@@ -0,0 +1,574 @@
+package audiofile
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// maxPdfDepth is how deeply references and form XObjects are followed so a pdf with a
+// cycle cannot recurse forever
+const maxPdfDepth = 32
+
+// maxPdfNesting is how deeply arrays and dictionaries can be nested in each other so a
+// pdf full of [ cannot overflow the stack
+const maxPdfNesting = 256
+
+var (
+	// pdfObjectRegex matches the start of an indirect object like 12 0 obj
+	pdfObjectRegex = regexp.MustCompile(`(\d+)\s+(\d+)\s+obj\b`)
+	// pdfTrailerRegex matches the start of the trailer dictionary of a pdf with an xref table
+	pdfTrailerRegex = regexp.MustCompile(`trailer\s*<<`)
+)
+
+// pdfName is a name like /Type, pdfKeyword is an operator of a content stream or a keyword
+// like endobj and pdfRef is a reference to an indirect object. Strings are []byte and
+// numbers are float64.
+type (
+	pdfName    string
+	pdfKeyword string
+	pdfArray   []any
+	pdfDict    map[pdfName]any
+	pdfRef     struct{ num, gen int }
+	pdfStream  struct {
+		dict pdfDict
+		data []byte
+	}
+)
+
+// pdfDocument is the indirect objects of a pdf by their number and its trailer
+type pdfDocument struct {
+	objects map[int]any
+	trailer pdfDict
+}
+
+// pdfPage is a page of a pdf with the resources used by its content
+type pdfPage struct {
+	resources pdfDict
+	contents  []byte
+}
+
+// openPDF reads every indirect object of the pdf, including the objects of object
+// streams. The objects are found by scanning the file instead of reading the xref table so
+// files with a broken xref table can still be read, and a later object with the same
+// number replaces an earlier one like an incremental update does.
+func openPDF(data []byte) (*pdfDocument, error) {
+	if !bytes.Contains(data[:min(len(data), 1024)], []byte("%PDF-")) {
+		return nil, errors.New("not a pdf file")
+	}
+	doc := &pdfDocument{objects: make(map[int]any), trailer: make(pdfDict)}
+
+	pos := 0
+	for {
+		loc := pdfObjectRegex.FindSubmatchIndex(data[pos:])
+		if loc == nil {
+			break
+		}
+		num, _ := strconv.Atoi(string(data[pos+loc[2] : pos+loc[3]]))
+		lexer := &pdfLexer{data: data, pos: pos + loc[1]}
+		obj, err := lexer.readObject()
+		if err != nil {
+			pos += loc[1]
+			continue
+		}
+		doc.objects[num] = obj
+		// the trailer of a pdf with an xref stream is the dictionary of the stream
+		if stream, ok := obj.(pdfStream); ok && stream.dict["Type"] == pdfName("XRef") {
+			doc.mergeTrailer(stream.dict)
+		}
+		pos = min(lexer.pos, len(data))
+	}
+	for _, loc := range pdfTrailerRegex.FindAllIndex(data, -1) {
+		lexer := &pdfLexer{data: data, pos: loc[0] + len("trailer")}
+		if trailer, err := lexer.readObject(); err == nil {
+			if dict, ok := trailer.(pdfDict); ok {
+				doc.mergeTrailer(dict)
+			}
+		}
+	}
+	if _, ok := doc.trailer["Encrypt"]; ok {
+		return nil, errors.New("encrypted pdf files are not supported")
+	}
+
+	for _, obj := range doc.objects {
+		if stream, ok := obj.(pdfStream); ok && stream.dict["Type"] == pdfName("ObjStm") {
+			doc.readObjectStream(stream)
+		}
+	}
+	return doc, nil
+}
+
+// mergeTrailer adds the entries of a later trailer to the trailer
+func (d *pdfDocument) mergeTrailer(dict pdfDict) {
+	for k, v := range dict {
+		d.trailer[k] = v
+	}
+}
+
+// readObjectStream adds the objects compressed in an object stream that are not already
+// in the document
+func (d *pdfDocument) readObjectStream(stream pdfStream) {
+	data, err := d.decodeStream(stream)
+	if err != nil {
+		return
+	}
+	n, _ := d.resolve(stream.dict["N"]).(float64)
+	first, _ := d.resolve(stream.dict["First"]).(float64)
+	header := &pdfLexer{data: data}
+	for i := 0; i < int(n); i++ {
+		num, err1 := header.readObject()
+		offset, err2 := header.readObject()
+		objNum, ok1 := num.(float64)
+		objOffset, ok2 := offset.(float64)
+		if err1 != nil || err2 != nil || !ok1 || !ok2 {
+			return
+		}
+		if _, ok := d.objects[int(objNum)]; ok {
+			continue
+		}
+		pos := int(first + objOffset)
+		if pos < 0 || pos >= len(data) {
+			continue
+		}
+		lexer := &pdfLexer{data: data, pos: pos}
+		if obj, err := lexer.readObject(); err == nil {
+			d.objects[int(objNum)] = obj
+		}
+	}
+}
+
+// resolve returns the object a reference refers to or the value if it is not a reference
+func (d *pdfDocument) resolve(v any) any {
+	for i := 0; i < maxPdfDepth; i++ {
+		ref, ok := v.(pdfRef)
+		if !ok {
+			return v
+		}
+		v = d.objects[ref.num]
+	}
+	return nil
+}
+
+// dict returns the dictionary of the value, or of the stream, if it is one
+func (d *pdfDocument) dict(v any) pdfDict {
+	switch t := d.resolve(v).(type) {
+	case pdfDict:
+		return t
+	case pdfStream:
+		return t.dict
+	}
+	return nil
+}
+
+// pages returns the pages of the pdf in order with the resources they inherit from the
+// page tree
+func (d *pdfDocument) pages() ([]pdfPage, error) {
+	catalog := d.dict(d.trailer["Root"])
+	if catalog == nil {
+		// a pdf whose trailer was lost still has its catalog
+		for _, obj := range d.objects {
+			if dict, ok := obj.(pdfDict); ok && dict["Type"] == pdfName("Catalog") {
+				catalog = dict
+			}
+		}
+	}
+	if catalog == nil {
+		return nil, errors.New("pdf has no catalog")
+	}
+	var pages []pdfPage
+	d.walkPages(catalog["Pages"], nil, make(map[int]bool), &pages)
+	if len(pages) == 0 {
+		return nil, errors.New("pdf has no pages")
+	}
+	return pages, nil
+}
+
+// walkPages adds the pages of the page tree node to pages. The nodes that were visited
+// are skipped so a page tree that refers to itself is only walked once.
+func (d *pdfDocument) walkPages(v any, resources pdfDict, visited map[int]bool, pages *[]pdfPage) {
+	if ref, ok := v.(pdfRef); ok {
+		if visited[ref.num] {
+			return
+		}
+		visited[ref.num] = true
+	}
+	node := d.dict(v)
+	if node == nil {
+		return
+	}
+	if r := d.dict(node["Resources"]); r != nil {
+		resources = r
+	}
+	kids, ok := d.resolve(node["Kids"]).(pdfArray)
+	if !ok {
+		*pages = append(*pages, pdfPage{resources: resources, contents: d.contents(node["Contents"])})
+		return
+	}
+	for _, kid := range kids {
+		d.walkPages(kid, resources, visited, pages)
+	}
+}
+
+// contents returns the decoded content streams of a page joined together
+func (d *pdfDocument) contents(v any) []byte {
+	var contents []byte
+	switch t := d.resolve(v).(type) {
+	case pdfStream:
+		contents, _ = d.decodeStream(t)
+	case pdfArray:
+		for _, part := range t {
+			if stream, ok := d.resolve(part).(pdfStream); ok {
+				data, err := d.decodeStream(stream)
+				if err == nil {
+					contents = append(append(contents, data...), '\n')
+				}
+			}
+		}
+	}
+	return contents
+}
+
+// decodeStream applies the filters of the stream to its data
+func (d *pdfDocument) decodeStream(stream pdfStream) ([]byte, error) {
+	var filters []any
+	switch f := d.resolve(stream.dict["Filter"]).(type) {
+	case pdfName:
+		filters = []any{f}
+	case pdfArray:
+		filters = f
+	}
+	data := stream.data
+	for _, filter := range filters {
+		var r io.Reader
+		switch d.resolve(filter) {
+		case pdfName("FlateDecode"), pdfName("Fl"):
+			zr, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				// some writers leave out the zlib header
+				r = flate.NewReader(bytes.NewReader(data))
+			} else {
+				r = zr
+			}
+		case pdfName("ASCIIHexDecode"), pdfName("AHx"):
+			data = bytes.TrimSuffix(bytes.Join(bytes.Fields(data), nil), []byte(">"))
+			if len(data)%2 == 1 {
+				data = append(data, '0')
+			}
+			decoded, err := hex.DecodeString(string(data))
+			if err != nil {
+				return nil, err
+			}
+			data = decoded
+			continue
+		case pdfName("ASCII85Decode"), pdfName("A85"):
+			data = bytes.TrimPrefix(bytes.TrimSpace(data), []byte("<~"))
+			if i := bytes.Index(data, []byte("~>")); i >= 0 {
+				data = data[:i]
+			}
+			r = ascii85.NewDecoder(bytes.NewReader(data))
+		default:
+			return nil, fmt.Errorf("unsupported pdf filter %v", filter)
+		}
+		decoded, err := io.ReadAll(io.LimitReader(r, maxEntrySize+1))
+		if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, err
+		}
+		if len(decoded) > maxEntrySize {
+			return nil, errors.New("pdf stream is too large")
+		}
+		data = decoded
+	}
+	return data, nil
+}
+
+// pdfLexer reads the objects of a pdf or the operands and operators of a content stream
+type pdfLexer struct {
+	data []byte
+	pos  int
+	// nesting is how many arrays and dictionaries are being read
+	nesting int
+}
+
+// isPdfSpace checks if the byte is pdf white space
+func isPdfSpace(b byte) bool {
+	return b == ' ' || b == '\n' || b == '\r' || b == '\t' || b == '\f' || b == 0
+}
+
+// isPdfDelimiter checks if the byte ends a name, number or keyword
+func isPdfDelimiter(b byte) bool {
+	return isPdfSpace(b) || bytes.IndexByte([]byte("()<>[]{}/%"), b) >= 0
+}
+
+// skipSpace skips white space and comments
+func (l *pdfLexer) skipSpace() {
+	for l.pos < len(l.data) {
+		switch {
+		case isPdfSpace(l.data[l.pos]):
+			l.pos++
+		case l.data[l.pos] == '%':
+			for l.pos < len(l.data) && l.data[l.pos] != '\n' && l.data[l.pos] != '\r' {
+				l.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// readObject reads the next object. It returns io.EOF at the end of the data.
+func (l *pdfLexer) readObject() (any, error) {
+	l.skipSpace()
+	if l.pos >= len(l.data) {
+		return nil, io.EOF
+	}
+	switch c := l.data[l.pos]; {
+	case c == '/':
+		l.pos++
+		return pdfName(l.readRegular()), nil
+	case c == '(':
+		return l.readLiteralString(), nil
+	case c == '<' && l.pos+1 < len(l.data) && l.data[l.pos+1] == '<':
+		if err := l.nest(); err != nil {
+			return nil, err
+		}
+		defer l.unnest()
+		l.pos += 2
+		return l.readDictionary()
+	case c == '<':
+		return l.readHexString(), nil
+	case c == '[':
+		if err := l.nest(); err != nil {
+			return nil, err
+		}
+		defer l.unnest()
+		l.pos++
+		var array pdfArray
+		for {
+			l.skipSpace()
+			if l.pos >= len(l.data) {
+				return nil, errors.New("unterminated pdf array")
+			}
+			if l.data[l.pos] == ']' {
+				l.pos++
+				return array, nil
+			}
+			obj, err := l.readObject()
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, obj)
+		}
+	case c == ']' || c == '>' || c == ')' || c == '{' || c == '}':
+		l.pos++
+		return pdfKeyword([]byte{c}), nil
+	}
+
+	token := l.readRegular()
+	switch token {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	n, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return pdfKeyword(token), nil
+	}
+	// an integer followed by another integer and R is a reference
+	if num, err := strconv.Atoi(token); err == nil {
+		save := l.pos
+		l.skipSpace()
+		if gen, err := strconv.Atoi(l.readRegular()); err == nil {
+			l.skipSpace()
+			if l.readRegular() == "R" {
+				return pdfRef{num: num, gen: gen}, nil
+			}
+		}
+		l.pos = save
+	}
+	return n, nil
+}
+
+// nest starts reading an array or dictionary
+func (l *pdfLexer) nest() error {
+	if l.nesting >= maxPdfNesting {
+		return errors.New("pdf objects are nested too deeply")
+	}
+	l.nesting++
+	return nil
+}
+
+// unnest ends reading an array or dictionary
+func (l *pdfLexer) unnest() {
+	l.nesting--
+}
+
+// readRegular reads a name, number or keyword
+func (l *pdfLexer) readRegular() string {
+	start := l.pos
+	for l.pos < len(l.data) && !isPdfDelimiter(l.data[l.pos]) {
+		l.pos++
+	}
+	token := l.data[start:l.pos]
+	// names can have characters written as #xx
+	if bytes.IndexByte(token, '#') >= 0 {
+		var b []byte
+		for i := 0; i < len(token); i++ {
+			if token[i] == '#' && i+2 < len(token) {
+				if v, err := strconv.ParseUint(string(token[i+1:i+3]), 16, 8); err == nil {
+					b = append(b, byte(v))
+					i += 2
+					continue
+				}
+			}
+			b = append(b, token[i])
+		}
+		return string(b)
+	}
+	return string(token)
+}
+
+// readDictionary reads the entries of a dictionary after its << and the data of the
+// stream that follows it
+func (l *pdfLexer) readDictionary() (any, error) {
+	dict := make(pdfDict)
+	for {
+		l.skipSpace()
+		if l.pos+1 < len(l.data) && l.data[l.pos] == '>' && l.data[l.pos+1] == '>' {
+			l.pos += 2
+			break
+		}
+		key, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		name, ok := key.(pdfName)
+		if !ok {
+			return nil, errors.New("invalid pdf dictionary key")
+		}
+		value, err := l.readObject()
+		if err != nil {
+			return nil, err
+		}
+		dict[name] = value
+	}
+
+	save := l.pos
+	l.skipSpace()
+	if !bytes.HasPrefix(l.data[l.pos:], []byte("stream")) {
+		l.pos = save
+		return dict, nil
+	}
+	l.pos += len("stream")
+	if l.pos < len(l.data) && l.data[l.pos] == '\r' {
+		l.pos++
+	}
+	if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+		l.pos++
+	}
+	start := l.pos
+	// the length is used if it is direct and correct, otherwise the data ends at endstream
+	if length, ok := dict["Length"].(float64); ok && start+int(length) <= len(l.data) {
+		end := start + int(length)
+		rest := bytes.TrimLeft(l.data[end:min(end+32, len(l.data))], " \r\n\t")
+		if bytes.HasPrefix(rest, []byte("endstream")) {
+			l.pos = end + bytes.Index(l.data[end:], []byte("endstream")) + len("endstream")
+			return pdfStream{dict: dict, data: l.data[start:end]}, nil
+		}
+	}
+	end := bytes.Index(l.data[start:], []byte("endstream"))
+	if end < 0 {
+		return nil, errors.New("unterminated pdf stream")
+	}
+	l.pos = start + end + len("endstream")
+	data := bytes.TrimSuffix(l.data[start:start+end], []byte("\n"))
+	return pdfStream{dict: dict, data: bytes.TrimSuffix(data, []byte("\r"))}, nil
+}
+
+// readLiteralString reads a string in parentheses with its escapes
+func (l *pdfLexer) readLiteralString() []byte {
+	l.pos++
+	var s []byte
+	depth := 1
+	for l.pos < len(l.data) {
+		c := l.data[l.pos]
+		l.pos++
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return s
+			}
+		case '\\':
+			if l.pos >= len(l.data) {
+				return s
+			}
+			e := l.data[l.pos]
+			l.pos++
+			switch e {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r':
+				// a backslash at the end of a line continues the string
+				if l.pos < len(l.data) && l.data[l.pos] == '\n' {
+					l.pos++
+				}
+				continue
+			case '\n':
+				continue
+			default:
+				if e >= '0' && e <= '7' {
+					v := int(e - '0')
+					for i := 0; i < 2 && l.pos < len(l.data) && l.data[l.pos] >= '0' && l.data[l.pos] <= '7'; i++ {
+						v = v*8 + int(l.data[l.pos]-'0')
+						l.pos++
+					}
+					c = byte(v)
+				} else {
+					c = e
+				}
+			}
+		}
+		s = append(s, c)
+	}
+	return s
+}
+
+// readHexString reads a string of hex digits in angle brackets
+func (l *pdfLexer) readHexString() []byte {
+	l.pos++
+	var digits []byte
+	for l.pos < len(l.data) && l.data[l.pos] != '>' {
+		if !isPdfSpace(l.data[l.pos]) {
+			digits = append(digits, l.data[l.pos])
+		}
+		l.pos++
+	}
+	if l.pos < len(l.data) {
+		l.pos++
+	}
+	if len(digits)%2 == 1 {
+		digits = append(digits, '0')
+	}
+	s, _ := hex.DecodeString(string(digits))
+	return s
+}
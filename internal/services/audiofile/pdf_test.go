@@ -0,0 +1,162 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenPDF(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		pages int
+		err   string
+	}{
+		{
+			name: "pages inherit resources",
+			input: "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+				"2 0 obj << /Type /Pages /Kids [3 0 R 4 0 R] /Resources << /Font << /F1 5 0 R >> >> >> endobj\n" +
+				"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+				"4 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+				"5 0 obj << /Type /Font /Subtype /Type1 >> endobj\n" +
+				"trailer << /Root 1 0 R >>\n%%EOF",
+			pages: 2,
+		},
+		{
+			name:  "not a pdf",
+			input: "just some text",
+			err:   "not a pdf file",
+		},
+		{
+			name: "encrypted",
+			input: "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+				"trailer << /Root 1 0 R /Encrypt 9 0 R >>\n%%EOF",
+			err: "encrypted pdf files are not supported",
+		},
+		{
+			name: "page tree refers to itself",
+			input: "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+				"2 0 obj << /Type /Pages /Kids [2 0 R 2 0 R 3 0 R] /Resources << /Font << /F1 5 0 R >> >> >> endobj\n" +
+				"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+				"5 0 obj << /Type /Font /Subtype /Type1 >> endobj\n" +
+				"trailer << /Root 1 0 R >>\n%%EOF",
+			pages: 1,
+		},
+		{
+			name:  "truncated in a hex string",
+			input: "%PDF-1.4\n1 0 obj <",
+			err:   "pdf has no catalog",
+		},
+		{
+			name:  "arrays nested too deeply",
+			input: "%PDF-1.4\n1 0 obj " + strings.Repeat("[", 100000),
+			err:   "pdf has no catalog",
+		},
+		{
+			name: "object stream with a negative first offset",
+			input: "%PDF-1.4\n3 0 obj << /Type /ObjStm /N 1 /First -50 /Length 4 >> stream\n1 0 \nendstream endobj\n" +
+				"trailer << /Root 1 0 R >>\n%%EOF",
+			err: "pdf has no catalog",
+		},
+		{
+			name:  "no pages",
+			input: "%PDF-1.4\n1 0 obj << /Type /Catalog >> endobj\ntrailer << /Root 1 0 R >>\n%%EOF",
+			err:   "pdf has no pages",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := openPDF([]byte(tt.input))
+			if err == nil {
+				var pages []pdfPage
+				pages, err = doc.pages()
+				if tt.err == "" {
+					require.Len(t, pages, tt.pages)
+					for _, page := range pages {
+						require.NotNil(t, doc.dict(page.resources["Font"])["F1"])
+					}
+				}
+			}
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestPdfLexer(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected any
+	}{
+		{name: "number", input: "-1.5", expected: -1.5},
+		{name: "reference", input: "12 0 R", expected: pdfRef{num: 12, gen: 0}},
+		{name: "name with escape", input: "/A#20B", expected: pdfName("A B")},
+		{name: "literal string", input: `(a \(b\) \101\nc)`, expected: []byte("a (b) A\nc")},
+		{name: "hex string", input: "<48 6f7>", expected: []byte("Hop")},
+		{name: "array", input: "[1 (a) /B]", expected: pdfArray{1.0, []byte("a"), pdfName("B")}},
+		{name: "dictionary", input: "<< /A 1 /B [true null] >>", expected: pdfDict{"A": 1.0, "B": pdfArray{true, nil}}},
+		{name: "operator", input: "% comment\nTJ", expected: pdfKeyword("TJ")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lexer := &pdfLexer{data: []byte(tt.input)}
+			obj, err := lexer.readObject()
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, obj)
+		})
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	doc := &pdfDocument{objects: make(map[int]any)}
+	tests := []struct {
+		name     string
+		stream   pdfStream
+		expected string
+	}{
+		{
+			name:     "no filter",
+			stream:   pdfStream{dict: pdfDict{}, data: []byte("BT ET")},
+			expected: "BT ET",
+		},
+		{
+			name:     "ascii hex",
+			stream:   pdfStream{dict: pdfDict{"Filter": pdfName("ASCIIHexDecode")}, data: []byte("42 54 2>")},
+			expected: "BT ",
+		},
+		{
+			name:     "ascii85 then flate",
+			stream:   pdfStream{dict: pdfDict{"Filter": pdfArray{pdfName("A85"), pdfName("Fl")}}, data: []byte("Garg^;/csF!!D$X:]~>")},
+			expected: "BT ET",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := doc.decodeStream(tt.stream)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, string(data))
+		})
+	}
+}
@@ -0,0 +1,873 @@
+package audiofile
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/unicode/norm"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "pdf",
+		format:     Pdf,
+		binary:     true,
+		mimeTypes:  []string{"application/pdf"},
+		extensions: []string{".pdf"},
+		sniff:      sniffIf(isPdf, certainConfidence),
+		parse: func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) (parsed, error) {
+			cues, text, err := parsePdf(f, seg)
+			return parsed{cues: cues, text: text}, err
+		},
+	})
+}
+
+const (
+	// pdfKerningSpace is the adjustment of a TJ array, in thousandths of the font size, that
+	// is wide enough to be a space between words
+	pdfKerningSpace = 200
+	// pdfWordGap is the gap between two pieces of text on a line, as a part of the font
+	// size, that is a space between words
+	pdfWordGap = 0.2
+	// pdfParagraphGap is how many times the usual distance between the lines of a page the
+	// distance to the next line must be to start a new paragraph
+	pdfParagraphGap = 1.4
+	// pdfDefaultWidth is the width of a glyph, in thousandths of the font size, of a font
+	// without widths like the standard 14 fonts
+	pdfDefaultWidth = 500
+)
+
+var (
+	// pdfPageNumberRegex matches a header or footer that is only a page number like 12,
+	// - 12 -, Page 12 of 40 or xii
+	pdfPageNumberRegex = regexp.MustCompile(`(?i)^(?:(?:page|p\.|pág\.?|página|seite|pagina)\s*)?[-–—(\[]?\s*(?:\d+|[ivxlcdm]+)\s*[-–—)\]]?\s*(?:(?:of|/|de|von|di)\s*\d+)?$`)
+	// pdfDigitsRegex matches the numbers of a header or footer, which change on each page
+	pdfDigitsRegex = regexp.MustCompile(`\d+`)
+	// pdfAccents are the combining marks of the accents in glyph names like eacute
+	pdfAccents = map[string]rune{
+		"acute": '́', "grave": '̀', "circumflex": '̂', "tilde": '̃',
+		"dieresis": '̈', "ring": '̊', "cedilla": '̧', "caron": '̌',
+		"breve": '̆', "ogonek": '̨', "macron": '̄', "dotaccent": '̇',
+		"hungarumlaut": '̋',
+	}
+	// pdfGlyphNames are the glyph names of the Differences of a font encoding that are not
+	// a letter or a letter with an accent
+	pdfGlyphNames = map[string]string{
+		"space": " ", "exclam": "!", "quotedbl": "\"", "numbersign": "#", "dollar": "$",
+		"percent": "%", "ampersand": "&", "quotesingle": "'", "parenleft": "(", "parenright": ")",
+		"asterisk": "*", "plus": "+", "comma": ",", "hyphen": "-", "period": ".", "slash": "/",
+		"zero": "0", "one": "1", "two": "2", "three": "3", "four": "4", "five": "5", "six": "6",
+		"seven": "7", "eight": "8", "nine": "9", "colon": ":", "semicolon": ";", "less": "<",
+		"equal": "=", "greater": ">", "question": "?", "at": "@", "bracketleft": "[",
+		"backslash": "\\", "bracketright": "]", "asciicircum": "^", "underscore": "_",
+		"grave": "`", "braceleft": "{", "bar": "|", "braceright": "}", "asciitilde": "~",
+		"quoteleft": "‘", "quoteright": "’", "quotedblleft": "“", "quotedblright": "”",
+		"quotesinglbase": "‚", "quotedblbase": "„", "guillemotleft": "«", "guillemotright": "»",
+		"guilsinglleft": "‹", "guilsinglright": "›", "endash": "–", "emdash": "—",
+		"bullet": "•", "ellipsis": "…", "exclamdown": "¡", "questiondown": "¿",
+		"fi": "fi", "fl": "fl", "ff": "ff", "ffi": "ffi", "ffl": "ffl", "germandbls": "ß",
+		"ae": "æ", "AE": "Æ", "oe": "œ", "OE": "Œ", "oslash": "ø", "Oslash": "Ø",
+		"dotlessi": "ı", "degree": "°", "section": "§", "paragraph": "¶", "copyright": "©",
+		"registered": "®", "trademark": "™", "periodcentered": "·", "nbspace": " ",
+		"softhyphen": "­", "minus": "−", "multiply": "×", "divide": "÷", "euro": "€",
+		"sterling": "£", "yen": "¥", "cent": "¢", "dagger": "†", "daggerdbl": "‡",
+	}
+)
+
+// pdfMatrix is a transformation matrix [a b c d e f]
+type pdfMatrix [6]float64
+
+// pdfIdentity is the matrix that does not transform
+var pdfIdentity = pdfMatrix{1, 0, 0, 1, 0, 0}
+
+// multiply returns m × n
+func (m pdfMatrix) multiply(n pdfMatrix) pdfMatrix {
+	return pdfMatrix{
+		m[0]*n[0] + m[1]*n[2], m[0]*n[1] + m[1]*n[3],
+		m[2]*n[0] + m[3]*n[2], m[2]*n[1] + m[3]*n[3],
+		m[4]*n[0] + m[5]*n[2] + n[4], m[4]*n[1] + m[5]*n[3] + n[5],
+	}
+}
+
+// pdfCodespace is a range of character codes of a font with the same number of bytes
+type pdfCodespace struct {
+	low, high []byte
+}
+
+// pdfFont decodes the strings shown with a font into text and their widths
+type pdfFont struct {
+	// codespaces are the ranges of the codes of the font. A font without them uses codes
+	// of codeBytes bytes.
+	codespaces []pdfCodespace
+	codeBytes  int
+	// toUnicode is the text of each code from the ToUnicode CMap of the font
+	toUnicode map[string]string
+	// encoding is the text of each code of a simple font without a ToUnicode CMap
+	encoding [256]string
+	// widths are the widths of the glyphs by code in thousandths of the font size
+	widths       map[int]float64
+	defaultWidth float64
+}
+
+// pdfTextRun is a string shown on a page with where it begins and ends
+type pdfTextRun struct {
+	text     string
+	x, y     float64
+	endX     float64
+	size     float64
+	spaceGap bool
+}
+
+// pdfLine is a line of text on a page
+type pdfLine struct {
+	text string
+	y    float64
+	size float64
+}
+
+// pdfTextState is the graphics and text state of a content stream
+type pdfTextState struct {
+	ctm        pdfMatrix
+	font       *pdfFont
+	size       float64
+	leading    float64
+	charSpace  float64
+	wordSpace  float64
+	horizontal float64
+	rise       float64
+}
+
+// parsePdf takes a pdf and returns the phrases of its text and the text that was extracted
+// so it can be checked
+func parsePdf(f io.Reader, seg segmenter) ([]cue, string, error) {
+	content, err := io.ReadAll(f)
+	if err != nil {
+		return nil, "", err
+	}
+	text, err := extractPdfText(content)
+	if err != nil {
+		return nil, "", err
+	}
+	if text == "" {
+		return nil, "", errors.New("pdf has no text, it may be a scanned image")
+	}
+	return untimedCues(parseParagraph(strings.NewReader(text), seg)), text, nil
+}
+
+// extractPdfText returns the text of the pages of the pdf in the order it is drawn with a
+// blank line between paragraphs. The headers, footers and page numbers that repeat on the
+// pages are removed and words that are hyphenated at the end of a line are joined.
+func extractPdfText(data []byte) (string, error) {
+	doc, err := openPDF(data)
+	if err != nil {
+		return "", err
+	}
+	pages, err := doc.pages()
+	if err != nil {
+		return "", err
+	}
+
+	pageLines := make([][]pdfLine, len(pages))
+	for i, page := range pages {
+		runs := doc.textRuns(page.contents, page.resources, pdfIdentity, 0, make(map[int]bool))
+		pageLines[i] = pdfLines(runs)
+	}
+	removeRunningHeaders(pageLines)
+	usual := usualLineGap(pageLines)
+
+	var paragraphs []string
+	for _, lines := range pageLines {
+		pageParagraphs := pdfParagraphs(lines, usual)
+		if len(pageParagraphs) == 0 {
+			continue
+		}
+		// a paragraph that continues on the next page is joined with it
+		if n := len(paragraphs); n > 0 && continuesOnNextPage(paragraphs[n-1], pageParagraphs[0]) {
+			paragraphs[n-1] = joinPdfLines(paragraphs[n-1], pageParagraphs[0])
+			pageParagraphs = pageParagraphs[1:]
+		}
+		paragraphs = append(paragraphs, pageParagraphs...)
+	}
+	return strings.Join(paragraphs, "\n\n"), nil
+}
+
+// textRuns runs the content stream and returns the strings it shows. The text of form
+// XObjects drawn by the content is included the first time each is drawn on the page.
+func (d *pdfDocument) textRuns(contents []byte, resources pdfDict, ctm pdfMatrix, depth int, forms map[int]bool) []pdfTextRun {
+	fonts := make(map[pdfName]*pdfFont)
+	fontResources := d.dict(resources["Font"])
+	xObjects := d.dict(resources["XObject"])
+
+	var runs []pdfTextRun
+	state := pdfTextState{ctm: ctm, horizontal: 1}
+	var stack []pdfTextState
+	tm, tlm := pdfIdentity, pdfIdentity
+	spaceGap := false
+	var operands []any
+
+	show := func(s []byte) {
+		if state.font == nil {
+			return
+		}
+		text, width, spaces := state.font.decode(s)
+		trm := pdfMatrix{state.size * state.horizontal, 0, 0, state.size, 0, state.rise}.multiply(tm).multiply(state.ctm)
+		advance := (width/1000*state.size + state.charSpace*float64(utf8.RuneCountInString(text)) + state.wordSpace*float64(spaces)) * state.horizontal
+		tm = pdfMatrix{1, 0, 0, 1, advance, 0}.multiply(tm)
+		end := pdfMatrix{state.size * state.horizontal, 0, 0, state.size, 0, state.rise}.multiply(tm).multiply(state.ctm)
+		size := math.Hypot(trm[2], trm[3])
+		runs = append(runs, pdfTextRun{text: text, x: trm[4], y: trm[5], endX: end[4], size: size, spaceGap: spaceGap})
+		spaceGap = false
+	}
+	nextLine := func(tx, ty float64) {
+		tlm = pdfMatrix{1, 0, 0, 1, tx, ty}.multiply(tlm)
+		tm = tlm
+	}
+
+	lexer := &pdfLexer{data: contents}
+	for {
+		obj, err := lexer.readObject()
+		if err != nil {
+			break
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		numbers := pdfNumbers(operands)
+		switch op {
+		case "q":
+			stack = append(stack, state)
+		case "Q":
+			if len(stack) > 0 {
+				state = stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+			}
+		case "cm":
+			if len(numbers) == 6 {
+				state.ctm = pdfMatrix(numbers).multiply(state.ctm)
+			}
+		case "BT":
+			tm, tlm = pdfIdentity, pdfIdentity
+		case "Tf":
+			if len(operands) == 2 {
+				name, _ := operands[0].(pdfName)
+				if _, ok := fonts[name]; !ok {
+					fonts[name] = d.loadFont(d.dict(fontResources[name]))
+				}
+				state.font = fonts[name]
+				state.size, _ = operands[1].(float64)
+			}
+		case "Td":
+			if len(numbers) == 2 {
+				nextLine(numbers[0], numbers[1])
+			}
+		case "TD":
+			if len(numbers) == 2 {
+				state.leading = -numbers[1]
+				nextLine(numbers[0], numbers[1])
+			}
+		case "Tm":
+			if len(numbers) == 6 {
+				tm, tlm = pdfMatrix(numbers), pdfMatrix(numbers)
+			}
+		case "T*":
+			nextLine(0, -state.leading)
+		case "TL":
+			if len(numbers) == 1 {
+				state.leading = numbers[0]
+			}
+		case "Tc":
+			if len(numbers) == 1 {
+				state.charSpace = numbers[0]
+			}
+		case "Tw":
+			if len(numbers) == 1 {
+				state.wordSpace = numbers[0]
+			}
+		case "Tz":
+			if len(numbers) == 1 {
+				state.horizontal = numbers[0] / 100
+			}
+		case "Ts":
+			if len(numbers) == 1 {
+				state.rise = numbers[0]
+			}
+		case "Tj", "'", "\"":
+			if op == "\"" && len(operands) == 3 {
+				state.wordSpace, _ = operands[0].(float64)
+				state.charSpace, _ = operands[1].(float64)
+			}
+			if op != "Tj" {
+				nextLine(0, -state.leading)
+			}
+			if len(operands) > 0 {
+				if s, ok := operands[len(operands)-1].([]byte); ok {
+					show(s)
+				}
+			}
+		case "TJ":
+			if len(operands) == 1 {
+				array, _ := operands[0].(pdfArray)
+				for _, item := range array {
+					switch v := item.(type) {
+					case []byte:
+						show(v)
+					case float64:
+						tm = pdfMatrix{1, 0, 0, 1, -v / 1000 * state.size * state.horizontal, 0}.multiply(tm)
+						if v <= -pdfKerningSpace {
+							spaceGap = true
+						}
+					}
+				}
+			}
+		case "Do":
+			if len(operands) == 1 && depth < maxPdfDepth {
+				name, _ := operands[0].(pdfName)
+				// a form is only drawn once on a page so forms that draw each other many
+				// times cannot multiply the work
+				if ref, ok := xObjects[name].(pdfRef); ok {
+					if forms[ref.num] {
+						break
+					}
+					forms[ref.num] = true
+				}
+				form, ok := d.resolve(xObjects[name]).(pdfStream)
+				if ok && form.dict["Subtype"] == pdfName("Form") {
+					formResources := d.dict(form.dict["Resources"])
+					if formResources == nil {
+						formResources = resources
+					}
+					matrix := pdfIdentity
+					if m := pdfNumbers(d.resolve(form.dict["Matrix"])); len(m) == 6 {
+						matrix = pdfMatrix(m)
+					}
+					if data, err := d.decodeStream(form); err == nil {
+						runs = append(runs, d.textRuns(data, formResources, matrix.multiply(state.ctm), depth+1, forms)...)
+					}
+				}
+			}
+		case "ID":
+			// the data of an inline image ends at EI
+			lexer.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+	return runs
+}
+
+// skipInlineImage moves past the data of an inline image to the EI operator
+func (l *pdfLexer) skipInlineImage() {
+	for i := l.pos; i+2 < len(l.data); i++ {
+		if l.data[i] == 'E' && l.data[i+1] == 'I' && isPdfSpace(l.data[i-1]) && (i+2 == len(l.data) || isPdfDelimiter(l.data[i+2])) {
+			l.pos = i + 2
+			return
+		}
+	}
+	l.pos = len(l.data)
+}
+
+// pdfNumbers returns the numbers of the operands or of an array
+func pdfNumbers(v any) []float64 {
+	var values []any
+	switch t := v.(type) {
+	case []any:
+		values = t
+	case pdfArray:
+		values = t
+	}
+	numbers := make([]float64, 0, len(values))
+	for _, value := range values {
+		if n, ok := value.(float64); ok {
+			numbers = append(numbers, n)
+		}
+	}
+	return numbers
+}
+
+// loadFont reads the ToUnicode CMap, encoding and widths of a font
+func (d *pdfDocument) loadFont(dict pdfDict) *pdfFont {
+	font := &pdfFont{codeBytes: 1, widths: make(map[int]float64), defaultWidth: pdfDefaultWidth}
+	if dict == nil {
+		font.encoding = pdfBaseEncoding("WinAnsiEncoding")
+		return font
+	}
+
+	if dict["Subtype"] == pdfName("Type0") {
+		font.codeBytes = 2
+		font.defaultWidth = 1000
+		if descendants, ok := d.resolve(dict["DescendantFonts"]).(pdfArray); ok && len(descendants) > 0 {
+			descendant := d.dict(descendants[0])
+			if dw, ok := d.resolve(descendant["DW"]).(float64); ok {
+				font.defaultWidth = dw
+			}
+			font.readCIDWidths(d, d.resolve(descendant["W"]))
+		}
+	} else {
+		first, _ := d.resolve(dict["FirstChar"]).(float64)
+		if widths, ok := d.resolve(dict["Widths"]).(pdfArray); ok {
+			for i, w := range widths {
+				if width, ok := d.resolve(w).(float64); ok {
+					font.widths[int(first)+i] = width
+				}
+			}
+		}
+		if missing, ok := d.resolve(d.dict(dict["FontDescriptor"])["MissingWidth"]).(float64); ok && missing > 0 {
+			font.defaultWidth = missing
+		}
+		font.readEncoding(d, dict["Encoding"])
+	}
+
+	if stream, ok := d.resolve(dict["ToUnicode"]).(pdfStream); ok {
+		if data, err := d.decodeStream(stream); err == nil {
+			font.readCMap(data)
+		}
+	}
+	return font
+}
+
+// readCIDWidths reads the W array of a CIDFont, which has widths for ranges of CIDs like
+// [1 [500 600] 10 20 550]
+func (f *pdfFont) readCIDWidths(d *pdfDocument, w any) {
+	array, _ := w.(pdfArray)
+	for i := 0; i+1 < len(array); {
+		first, ok := d.resolve(array[i]).(float64)
+		if !ok {
+			return
+		}
+		if widths, ok := d.resolve(array[i+1]).(pdfArray); ok {
+			for j, width := range pdfNumbers(widths) {
+				f.widths[int(first)+j] = width
+			}
+			i += 2
+			continue
+		}
+		if i+2 >= len(array) {
+			return
+		}
+		last, _ := d.resolve(array[i+1]).(float64)
+		width, _ := d.resolve(array[i+2]).(float64)
+		for cid := int(first); cid <= int(last) && cid-int(first) < 0x10000; cid++ {
+			f.widths[cid] = width
+		}
+		i += 3
+	}
+}
+
+// readEncoding reads the base encoding and Differences of a simple font
+func (f *pdfFont) readEncoding(d *pdfDocument, v any) {
+	base := "StandardEncoding"
+	var differences pdfArray
+	switch t := d.resolve(v).(type) {
+	case pdfName:
+		base = string(t)
+	case pdfDict:
+		if name, ok := d.resolve(t["BaseEncoding"]).(pdfName); ok {
+			base = string(name)
+		}
+		differences, _ = d.resolve(t["Differences"]).(pdfArray)
+	}
+	f.encoding = pdfBaseEncoding(base)
+
+	code := 0
+	for _, item := range differences {
+		switch t := d.resolve(item).(type) {
+		case float64:
+			code = int(t)
+		case pdfName:
+			if code >= 0 && code < 256 {
+				f.encoding[code] = pdfGlyphText(string(t))
+			}
+			code++
+		}
+	}
+}
+
+// pdfBaseEncoding returns the text of each code of a standard encoding. The standard
+// encoding only differs from WinAnsi in its quotes for the letters and punctuation used in
+// text.
+func pdfBaseEncoding(name string) [256]string {
+	cm := charmap.Windows1252
+	if name == "MacRomanEncoding" {
+		cm = charmap.Macintosh
+	}
+	var encoding [256]string
+	for i := 32; i < 256; i++ {
+		encoding[i] = string(cm.DecodeByte(byte(i)))
+	}
+	if name == "StandardEncoding" {
+		encoding['\''] = "’"
+		encoding['`'] = "‘"
+	}
+	return encoding
+}
+
+// pdfGlyphText returns the text of a glyph name like a, eacute, uni00E9 or quoteright
+func pdfGlyphText(name string) string {
+	name, _, _ = strings.Cut(name, ".")
+	if text, ok := pdfGlyphNames[name]; ok {
+		return text
+	}
+	if len(name) == 1 {
+		return name
+	}
+	if hexCode, ok := strings.CutPrefix(name, "uni"); ok && len(hexCode)%4 == 0 {
+		var units []uint16
+		for i := 0; i < len(hexCode); i += 4 {
+			v, err := strconv.ParseUint(hexCode[i:i+4], 16, 16)
+			if err != nil {
+				return ""
+			}
+			units = append(units, uint16(v))
+		}
+		return string(utf16.Decode(units))
+	}
+	if hexCode, ok := strings.CutPrefix(name, "u"); ok && len(hexCode) >= 4 && len(hexCode) <= 6 {
+		if v, err := strconv.ParseUint(hexCode, 16, 32); err == nil {
+			return string(rune(v))
+		}
+	}
+	// a letter with an accent like eacute or Ccedilla
+	for accent, mark := range pdfAccents {
+		if letter, ok := strings.CutSuffix(name, accent); ok && utf8.RuneCountInString(letter) == 1 {
+			return norm.NFC.String(letter + string(mark))
+		}
+	}
+	return ""
+}
+
+// readCMap reads the codespace ranges and the bfchar and bfrange mappings of a ToUnicode
+// CMap
+func (f *pdfFont) readCMap(data []byte) {
+	f.toUnicode = make(map[string]string)
+	lexer := &pdfLexer{data: data}
+	var operands []any
+	for {
+		obj, err := lexer.readObject()
+		if err != nil {
+			return
+		}
+		op, ok := obj.(pdfKeyword)
+		if !ok {
+			operands = append(operands, obj)
+			continue
+		}
+		switch op {
+		case "endcodespacerange":
+			for i := 0; i+1 < len(operands); i += 2 {
+				low, ok1 := operands[i].([]byte)
+				high, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 && len(low) == len(high) && len(low) > 0 {
+					f.codespaces = append(f.codespaces, pdfCodespace{low: low, high: high})
+				}
+			}
+		case "endbfchar":
+			for i := 0; i+1 < len(operands); i += 2 {
+				code, ok1 := operands[i].([]byte)
+				text, ok2 := operands[i+1].([]byte)
+				if ok1 && ok2 {
+					f.toUnicode[string(code)] = utf16BEText(text)
+				}
+			}
+		case "endbfrange":
+			for i := 0; i+2 < len(operands); i += 3 {
+				f.addRange(operands[i], operands[i+1], operands[i+2])
+			}
+		}
+		if strings.HasPrefix(string(op), "end") || strings.HasPrefix(string(op), "begin") {
+			operands = operands[:0]
+		}
+	}
+}
+
+// addRange adds the mappings of a bfrange from low to high to text that is incremented for
+// each code or to each text of an array
+func (f *pdfFont) addRange(lowValue, highValue, textValue any) {
+	low, ok1 := lowValue.([]byte)
+	high, ok2 := highValue.([]byte)
+	if !ok1 || !ok2 || len(low) != len(high) || len(low) == 0 {
+		return
+	}
+	start, end := pdfCode(low), pdfCode(high)
+	for code := start; code <= end && code-start < 0x10000; code++ {
+		key := make([]byte, len(low))
+		for i, c := len(low)-1, code; i >= 0; i, c = i-1, c>>8 {
+			key[i] = byte(c)
+		}
+		switch text := textValue.(type) {
+		case []byte:
+			if len(text) == 0 {
+				continue
+			}
+			next := slices.Clone(text)
+			next[len(next)-1] += byte(code - start)
+			f.toUnicode[string(key)] = utf16BEText(next)
+		case pdfArray:
+			if i := code - start; i < len(text) {
+				if s, ok := text[i].([]byte); ok {
+					f.toUnicode[string(key)] = utf16BEText(s)
+				}
+			}
+		}
+	}
+}
+
+// pdfCode returns the number of a character code
+func pdfCode(code []byte) int {
+	n := 0
+	for _, b := range code {
+		n = n<<8 | int(b)
+	}
+	return n
+}
+
+// utf16BEText decodes the UTF-16BE text of a ToUnicode CMap
+func utf16BEText(b []byte) string {
+	units := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		units = append(units, uint16(b[i])<<8|uint16(b[i+1]))
+	}
+	return string(utf16.Decode(units))
+}
+
+// decode returns the text of a string shown with the font, its width in thousandths of
+// the font size and how many single byte spaces it has for the word spacing
+func (f *pdfFont) decode(s []byte) (string, float64, int) {
+	var text strings.Builder
+	width := 0.0
+	spaces := 0
+	for i := 0; i < len(s); {
+		n := f.codeLength(s[i:])
+		code := s[i : i+n]
+		i += n
+
+		if w, ok := f.widths[pdfCode(code)]; ok {
+			width += w
+		} else {
+			width += f.defaultWidth
+		}
+		if n == 1 && code[0] == ' ' {
+			spaces++
+		}
+		if t, ok := f.toUnicode[string(code)]; ok {
+			text.WriteString(t)
+		} else if n == 1 {
+			text.WriteString(f.encoding[code[0]])
+		}
+	}
+	return text.String(), width, spaces
+}
+
+// codeLength returns how many bytes the next character code of the string has
+func (f *pdfFont) codeLength(s []byte) int {
+	for _, cs := range f.codespaces {
+		n := len(cs.low)
+		if n > len(s) {
+			continue
+		}
+		inRange := true
+		for i := 0; i < n; i++ {
+			if s[i] < cs.low[i] || s[i] > cs.high[i] {
+				inRange = false
+				break
+			}
+		}
+		if inRange {
+			return n
+		}
+	}
+	return min(f.codeBytes, len(s))
+}
+
+// pdfLines combines the text runs that are on the same line. A run that is far enough
+// from the one before it is separated from it with a space.
+func pdfLines(runs []pdfTextRun) []pdfLine {
+	var lines []pdfLine
+	var text strings.Builder
+	var current pdfLine
+	prevEnd := 0.0
+	for _, run := range runs {
+		if run.text == "" {
+			continue
+		}
+		size := math.Max(run.size, 1)
+		if text.Len() == 0 || math.Abs(run.y-current.y) > size/2 {
+			if text.Len() > 0 {
+				current.text = strings.Join(strings.Fields(text.String()), " ")
+				lines = append(lines, current)
+				text.Reset()
+			}
+			current = pdfLine{y: run.y, size: size}
+		} else if run.spaceGap || run.x-prevEnd > size*pdfWordGap {
+			text.WriteString(" ")
+		}
+		text.WriteString(run.text)
+		current.size = math.Max(current.size, size)
+		prevEnd = run.endX
+	}
+	if text.Len() > 0 {
+		current.text = strings.Join(strings.Fields(text.String()), " ")
+		lines = append(lines, current)
+	}
+	return slices.DeleteFunc(lines, func(l pdfLine) bool { return l.text == "" })
+}
+
+// removeRunningHeaders removes the page numbers and the headers and footers that repeat
+// on at least half of the pages from the top and bottom two lines of each page. The
+// numbers of a header are ignored so a header with the page number in it still repeats.
+func removeRunningHeaders(pageLines [][]pdfLine) {
+	edges := make([][]int, len(pageLines))
+	counts := make(map[string]int)
+	for i, lines := range pageLines {
+		edges[i] = pdfEdgeLines(lines)
+		seen := make(map[string]bool)
+		for _, j := range edges[i] {
+			key := runningHeaderKey(lines[j].text)
+			if !seen[key] {
+				seen[key] = true
+				counts[key]++
+			}
+		}
+	}
+
+	for i, lines := range pageLines {
+		remove := make(map[int]bool)
+		for _, j := range edges[i] {
+			count := counts[runningHeaderKey(lines[j].text)]
+			if pdfPageNumberRegex.MatchString(lines[j].text) || (count >= 2 && count*2 >= len(pageLines)) {
+				remove[j] = true
+			}
+		}
+		kept := lines[:0]
+		for j, line := range lines {
+			if !remove[j] {
+				kept = append(kept, line)
+			}
+		}
+		pageLines[i] = kept
+	}
+}
+
+// pdfEdgeLines returns the indexes of the two highest and two lowest lines of a page
+func pdfEdgeLines(lines []pdfLine) []int {
+	order := make([]int, len(lines))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return -compareFloat(lines[a].y, lines[b].y)
+	})
+	if len(order) <= 4 {
+		return order
+	}
+	return append(order[:2:2], order[len(order)-2:]...)
+}
+
+// compareFloat compares two numbers like cmp.Compare
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// runningHeaderKey returns the text of a header or footer in lower case with its numbers
+// replaced so the headers of different pages can be compared
+func runningHeaderKey(text string) string {
+	return pdfDigitsRegex.ReplaceAllString(strings.ToLower(text), "#")
+}
+
+// usualLineGap returns the median distance between the lines of the pages that follow each
+// other
+func usualLineGap(pageLines [][]pdfLine) float64 {
+	var gaps []float64
+	for _, lines := range pageLines {
+		for i := 1; i < len(lines); i++ {
+			if gap := lines[i-1].y - lines[i].y; gap > 0 {
+				gaps = append(gaps, gap)
+			}
+		}
+	}
+	if len(gaps) == 0 {
+		return 0
+	}
+	slices.Sort(gaps)
+	return gaps[len(gaps)/2]
+}
+
+// pdfParagraphs joins the lines of a page into paragraphs. A paragraph ends when the next
+// line is further below than the usual distance between lines, above it like the top of a
+// new column, or has a different font size like a heading.
+func pdfParagraphs(lines []pdfLine, usual float64) []string {
+	var paragraphs []string
+	for i, line := range lines {
+		if i == 0 {
+			paragraphs = append(paragraphs, line.text)
+			continue
+		}
+		prev := lines[i-1]
+		gap := prev.y - line.y
+		if gap <= 0 || gap > usual*pdfParagraphGap || math.Abs(line.size-prev.size) > prev.size*0.2 {
+			paragraphs = append(paragraphs, line.text)
+			continue
+		}
+		paragraphs[len(paragraphs)-1] = joinPdfLines(paragraphs[len(paragraphs)-1], line.text)
+	}
+	return paragraphs
+}
+
+// joinPdfLines joins a line to the text before it. A word that is hyphenated at the end of
+// the line is joined with the rest of the word on the next line.
+func joinPdfLines(text, line string) string {
+	if isHyphenated(text) && startsLowercase(line) {
+		_, size := utf8.DecodeLastRuneInString(text)
+		return text[:len(text)-size] + line
+	}
+	return text + " " + line
+}
+
+// isHyphenated checks if the text ends with a letter and a hyphen or a soft hyphen
+func isHyphenated(text string) bool {
+	last, size := utf8.DecodeLastRuneInString(text)
+	if last != '-' && last != '­' && last != '‐' {
+		return false
+	}
+	before, _ := utf8.DecodeLastRuneInString(text[:len(text)-size])
+	return unicode.IsLetter(before)
+}
+
+// startsLowercase checks if the text begins with a lower case letter
+func startsLowercase(text string) bool {
+	first, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsLower(first)
+}
+
+// continuesOnNextPage checks if the last paragraph of a page continues on the next page
+// because it does not end a sentence and the next page begins in the middle of one
+func continuesOnNextPage(last, next string) bool {
+	if !startsLowercase(next) {
+		return false
+	}
+	words := strings.Fields(last)
+	return isHyphenated(last) || (len(words) > 0 && wordEnding(words[len(words)-1]) != terminalPunctuation)
+}
+
+// isPdf checks if the content is a pdf, which can have other bytes before its header
+func isPdf(content []byte) bool {
+	return bytes.Contains(content[:min(len(content), 1024)], []byte("%PDF-"))
+}
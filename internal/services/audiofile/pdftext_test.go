@@ -0,0 +1,178 @@
+package audiofile
+
+import (
+	"os"
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractPdfText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		file     string
+		expected string
+	}{
+		{
+			// the header and the page numbers repeat on every page, the words broken at the
+			// end of a line are joined and the paragraph that continues on the next page is
+			// joined with it
+			name: "worksheet",
+			file: "testdata/worksheet.pdf",
+			expected: "La casa de mi abuela está cerca del río. Cada mañana caminamos juntos al mercado para comprar pan y fruta.\n\n" +
+				"Después volvemos a casa y preparamos el desayuno para toda la familia. Nos gusta mucho.\n\n" +
+				"El domingo vamos a la iglesia.\n\n" +
+				"Por la tarde leemos un libro. Mi abuela cuenta historias de su niñez.",
+		},
+		{
+			// a Type0 font with a ToUnicode CMap, a wide TJ adjustment that is a space, a
+			// form XObject and an inline image
+			name:     "cmap",
+			file:     "testdata/cmap.pdf",
+			expected: "Hoé cafe Una frase en un formulario.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := os.ReadFile(tt.file)
+			require.NoError(t, err)
+			text, err := extractPdfText(data)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, text)
+		})
+	}
+}
+
+func TestTextRunsFormCycle(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	// the form draws itself twice, which would be drawn 2^32 times if it was followed each time
+	doc, err := openPDF([]byte("%PDF-1.4\n" +
+		"1 0 obj << /Type /XObject /Subtype /Form /Resources 2 0 R /Length 40 >> stream\n" +
+		"BT /F1 12 Tf (Hi) Tj ET /X1 Do /X1 Do\n\nendstream endobj\n" +
+		"2 0 obj << /Font << /F1 3 0 R >> /XObject << /X1 1 0 R >> >> endobj\n" +
+		"3 0 obj << /Type /Font /Subtype /Type1 >> endobj\n%%EOF"))
+	require.NoError(t, err)
+	runs := doc.textRuns([]byte("/X1 Do /X1 Do"), doc.dict(pdfRef{num: 2}), pdfIdentity, 0, make(map[int]bool))
+	require.Len(t, runs, 1)
+	require.Equal(t, "Hi", runs[0].text)
+}
+
+func TestParsePdf(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	f, err := os.Open("testdata/worksheet.pdf")
+	require.NoError(t, err)
+	defer f.Close()
+	cues, text, err := parsePdf(f, segmenter{})
+	require.NoError(t, err)
+	require.NotEmpty(t, text)
+	require.Equal(t, []string{
+		"La casa de mi abuela está cerca del río.",
+		"Cada mañana caminamos juntos al mercado para comprar pan y fruta.",
+		"Después volvemos a casa y preparamos el desayuno para toda la familia.",
+		"El domingo vamos a la iglesia.",
+		"Por la tarde leemos un libro.",
+		"Mi abuela cuenta historias de su niñez.",
+	}, cueTexts(cues))
+
+	blank := "%PDF-1.4\n1 0 obj << /Type /Catalog /Pages 2 0 R >> endobj\n" +
+		"2 0 obj << /Type /Pages /Kids [3 0 R] >> endobj\n" +
+		"3 0 obj << /Type /Page /Parent 2 0 R >> endobj\n" +
+		"trailer << /Root 1 0 R >>\n%%EOF"
+	_, _, err = parsePdf(strings.NewReader(blank), segmenter{})
+	require.EqualError(t, err, "pdf has no text, it may be a scanned image")
+}
+
+func TestRemoveRunningHeaders(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	page := func(header, body, footer string) []pdfLine {
+		return []pdfLine{{text: header, y: 760}, {text: body, y: 700}, {text: body, y: 686}, {text: body, y: 672}, {text: footer, y: 40}}
+	}
+	pageLines := [][]pdfLine{
+		page("Chapter 1 - The Trip", "first page", "Page 1 of 3"),
+		page("Chapter 2 - The Trip", "second page", "xii"),
+		page("A heading once", "third page", "3"),
+	}
+	removeRunningHeaders(pageLines)
+
+	var texts [][]string
+	for _, lines := range pageLines {
+		var page []string
+		for _, line := range lines {
+			page = append(page, line.text)
+		}
+		texts = append(texts, page)
+	}
+	require.Equal(t, [][]string{
+		{"first page", "first page", "first page"},
+		{"second page", "second page", "second page"},
+		{"A heading once", "third page", "third page", "third page"},
+	}, texts)
+}
+
+func TestJoinPdfLines(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		text     string
+		line     string
+		expected string
+	}{
+		{name: "hyphenated word", text: "caminamos al mer-", line: "cado hoy", expected: "caminamos al mercado hoy"},
+		{name: "soft hyphen", text: "Zusammen­", line: "arbeit", expected: "Zusammenarbeit"},
+		{name: "compound before a capital", text: "the Franco-", line: "Prussian war", expected: "the Franco- Prussian war"},
+		{name: "dash after a space", text: "wait -", line: "here", expected: "wait - here"},
+		{name: "no hyphen", text: "una frase", line: "que sigue", expected: "una frase que sigue"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, joinPdfLines(tt.text, tt.line))
+		})
+	}
+}
+
+func TestPdfGlyphText(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "a", expected: "a"},
+		{name: "eacute", expected: "é"},
+		{name: "Ccedilla", expected: "Ç"},
+		{name: "ntilde", expected: "ñ"},
+		{name: "quoteright", expected: "’"},
+		{name: "uni00FC", expected: "ü"},
+		{name: "u1F600", expected: "😀"},
+		{name: "fi.alt", expected: "fi"},
+		{name: "unknownglyph", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, pdfGlyphText(tt.name))
+		})
+	}
+}
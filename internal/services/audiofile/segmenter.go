@@ -0,0 +1,219 @@
+package audiofile
+
+import (
+	"golang.org/x/text/language"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode"
+)
+
+const (
+	// unspacedMinimumPhraseLength and unspacedMaximumPhraseLength are measured in characters
+	// for languages that do not separate words with spaces
+	unspacedMinimumPhraseLength = 5
+	unspacedMaximumPhraseLength = 20
+)
+
+// unspacedLanguages are the base languages whose writing system does not put spaces
+// between words
+var unspacedLanguages = []string{"zh", "cmn", "ja", "yue", "th", "lo", "km", "my"}
+
+// unspacedScripts are the scripts used by the unspacedLanguages
+var unspacedScripts = []*unicode.RangeTable{
+	unicode.Han,
+	unicode.Hiragana,
+	unicode.Katakana,
+	unicode.Thai,
+	unicode.Lao,
+	unicode.Khmer,
+	unicode.Myanmar,
+}
+
+// segmenter decides how phrases are measured and split. Languages like Chinese and Japanese
+// are measured in characters and split on full-width punctuation, all other languages are
+// measured in words.
+type segmenter struct {
+	// declared is the base language the user declared for the file. When it is empty the
+	// script of each line is detected.
+	declared string
+	// policy is the phrase length policy of the request
+	policy interfaces.PhrasePolicy
+	// cleanup removes the annotations of subtitles and scripts that are not spoken. When it
+	// is nil the text is not cleaned.
+	cleanup *cleanup
+}
+
+// newSegmenter returns a segmenter for the user declared BCP 47 language (ja, zh-TW, cmn)
+// and phrase policy
+func newSegmenter(lang string, policy interfaces.PhrasePolicy) segmenter {
+	seg := segmenter{policy: policy}
+	if tag, err := language.Parse(lang); err == nil {
+		base, _ := tag.Base()
+		seg.declared = base.String()
+	}
+	return seg
+}
+
+// wordLimits returns the minimum and maximum words of a phrase from the policy or the
+// defaults
+func (s segmenter) wordLimits() (int, int) {
+	minimum, maximum := minimumPhraseLength, maximumPhraseLength
+	if s.policy.MinWords > 0 {
+		minimum = s.policy.MinWords
+	}
+	if s.policy.MaxWords > 0 {
+		maximum = s.policy.MaxWords
+	}
+	return minimum, max(minimum, maximum)
+}
+
+// characterLimits returns the minimum and maximum characters of a phrase in a language
+// that does not separate words with spaces. They are scaled from the word limits by the
+// ratio of the default limits.
+func (s segmenter) characterLimits() (int, int) {
+	minimum, maximum := s.wordLimits()
+	return max(1, minimum*unspacedMinimumPhraseLength/minimumPhraseLength),
+		maximum * unspacedMaximumPhraseLength / maximumPhraseLength
+}
+
+// splitsAfter checks if a long phrase is split after the punctuation
+func (s segmenter) splitsAfter(kind punctuationKind) bool {
+	return kind == terminalPunctuation || (kind == clausePunctuation && !s.policy.SentencesOnly)
+}
+
+// unspaced checks if the line should be measured in characters instead of words
+func (s segmenter) unspaced(line string) bool {
+	if s.declared != "" {
+		return slices.Contains(unspacedLanguages, s.declared)
+	}
+	return isUnspacedScript(line)
+}
+
+// isUnspacedScript checks if most of the letters in the line are from a script that does
+// not separate words with spaces
+func isUnspacedScript(line string) bool {
+	letters, unspaced := 0, 0
+	for _, r := range line {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if unicode.In(r, unspacedScripts...) {
+			unspaced++
+		}
+	}
+	return letters > 0 && unspaced*2 > letters
+}
+
+// characterCount counts the letters and numbers in a phrase
+func characterCount(phrase string) int {
+	count := 0
+	for _, r := range phrase {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			count++
+		}
+	}
+	return count
+}
+
+// splitLongUnspacedPhrases splits a long phrase in a language that does not separate words
+// with spaces on full-width punctuation and spaces
+func splitLongUnspacedPhrases(line string, seg segmenter) []string {
+	line = strings.TrimSpace(line)
+	minimum, maximum := seg.characterLimits()
+	count := characterCount(line)
+	// if phrase is too short don't keep it
+	if count < minimum {
+		if hasWords(line) {
+			seg.cleanup.discard(line, interfaces.DiscardTooShort)
+		}
+		return []string{}
+	}
+	if count <= maximum {
+		// if phrase isn't too long don't split it
+		return []string{line}
+	}
+
+	splitString := splitAfterSeparators(line, seg)
+	if len(splitString) < 2 {
+		return []string{line}
+	}
+	splitString = combinePhrases(splitString, characterCount, "", minimum, maximum)
+
+	for i := range splitString {
+		splitString[i] = strings.TrimSpace(splitString[i])
+	}
+	return splitString
+}
+
+// splitAfterSeparators splits the line after each sentence or clause punctuation mark or
+// space, or only after sentence punctuation if the policy is SentencesOnly. Closing quotes
+// and brackets like 」 stay with the piece they close.
+func splitAfterSeparators(line string, seg segmenter) []string {
+	var pieces []string
+	runes := []rune(line)
+	start := 0
+	var letter rune
+	isSeparator := func(r rune) bool {
+		if unicode.IsSpace(r) {
+			return !seg.policy.SentencesOnly
+		}
+		return seg.splitsAfter(punctuationOf(r, letter))
+	}
+	for i := 0; i < len(runes); i++ {
+		if unicode.IsLetter(runes[i]) {
+			letter = runes[i]
+		}
+		if !isSeparator(runes[i]) {
+			continue
+		}
+		for i+1 < len(runes) && (isSeparator(runes[i+1]) || isTrailingMark(runes[i+1])) {
+			i++
+		}
+		if piece := string(runes[start : i+1]); strings.TrimSpace(piece) != "" {
+			pieces = append(pieces, piece)
+		}
+		start = i + 1
+	}
+	if start < len(runes) {
+		pieces = append(pieces, string(runes[start:]))
+	}
+
+	return pieces
+}
+
+// combinePhrases combines any pieces of a split phrase that are shorter than minimum with
+// the piece after them, and combines neighbouring pieces if together they are not longer
+// than maximum. The length of a piece is measured with length.
+func combinePhrases(splitString []string, length func(string) int, sep string, minimum, maximum int) []string {
+	i := 0
+	for i < len(splitString)-1 {
+		// if phrase is small combine it with the next one
+		if length(splitString[i]) < minimum {
+			splitString[i] = splitString[i] + sep + splitString[i+1]
+			// remove the next index of split string
+			splitString = append(splitString[:i+1], splitString[i+2:]...)
+		} else {
+			// if both combined are less than maximum than concat
+			next := splitString[i] + sep + splitString[i+1]
+			if length(next) <= maximum {
+				splitString[i] = next
+				splitString = append(splitString[:i+1], splitString[i+2:]...)
+			}
+		}
+		// else continue
+		i++
+	}
+
+	// now check the last index and pen ultimate of the split string and combine if shorter than minimum
+	if len(splitString) > 1 {
+		lastElem := len(splitString) - 1
+		if length(splitString[lastElem]) < minimum || length(splitString[lastElem-1]) < minimum {
+			splitString[lastElem-1] = splitString[lastElem-1] + sep + splitString[lastElem]
+			splitString = splitString[:lastElem]
+		}
+	}
+
+	return splitString
+}
@@ -0,0 +1,143 @@
+package audiofile
+
+import (
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSegmenterUnspaced tests choosing the measure from the declared or detected language
+func TestSegmenterUnspaced(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		lang     string
+		line     string
+		expected bool
+	}{
+		{name: "detected japanese", line: "今日はいい天気ですね", expected: true},
+		{name: "detected chinese", line: "這是一個很長的句子", expected: true},
+		{name: "detected thai", line: "สวัสดีครับ คุณสบายดีไหม", expected: true},
+		{name: "detected english", line: "This is a test phrase", expected: false},
+		{name: "detected korean uses spaces", line: "오늘은 날씨가 좋네요", expected: false},
+		{name: "detected mostly english", line: "I went to 東京 last summer with my friends", expected: false},
+		{name: "declared japanese", lang: "ja-JP", line: "Tokyo Tower", expected: true},
+		{name: "declared mandarin", lang: "cmn", line: "Ni hao", expected: true},
+		{name: "declared english", lang: "en", line: "今日はいい天気ですね", expected: false},
+		{name: "invalid declared language is detected", lang: "not a language", line: "今日はいい天気ですね", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, newSegmenter(tt.lang, interfaces.PhrasePolicy{}).unspaced(tt.line))
+		})
+	}
+}
+
+// TestSplitLongUnspacedPhrases tests splitting phrases measured in characters
+func TestSplitLongUnspacedPhrases(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		line     string
+		policy   interfaces.PhrasePolicy
+		expected []string
+	}{
+		{
+			name:     "Short phrase (below minimum)",
+			line:     "はい。",
+			expected: []string{},
+		},
+		{
+			name:     "Short phrase with a lower minimum",
+			line:     "はい。",
+			policy:   interfaces.PhrasePolicy{MinWords: 1},
+			expected: []string{"はい。"},
+		},
+		{
+			name:     "Long phrase with a higher maximum",
+			line:     "這是一個很長的句子，我們需要把它分成幾個短的句子，這樣比較容易學習。",
+			policy:   interfaces.PhrasePolicy{MaxWords: 20},
+			expected: []string{"這是一個很長的句子，我們需要把它分成幾個短的句子，這樣比較容易學習。"},
+		},
+		{
+			name:     "Sentences only",
+			line:     "這是一個很長的句子，我們需要把它分成幾個短的句子。這樣比較容易學習，也比較有意思。",
+			policy:   interfaces.PhrasePolicy{SentencesOnly: true},
+			expected: []string{"這是一個很長的句子，我們需要把它分成幾個短的句子。", "這樣比較容易學習，也比較有意思。"},
+		},
+		{
+			name:     "Medium phrase (between min and max)",
+			line:     "今日はいい天気ですね。",
+			expected: []string{"今日はいい天気ですね。"},
+		},
+		{
+			name:     "Long phrase with full-width punctuation",
+			line:     "這是一個很長的句子，我們需要把它分成幾個短的句子，這樣比較容易學習。",
+			expected: []string{"這是一個很長的句子，", "我們需要把它分成幾個短的句子，", "這樣比較容易學習。"},
+		},
+		{
+			name:     "Short pieces are combined",
+			line:     "ええ、そうです。私は昨日友達と一緒に東京へ行きました！",
+			expected: []string{"ええ、そうです。", "私は昨日友達と一緒に東京へ行きました！"},
+		},
+		{
+			name:     "Closing quotes stay with the sentence",
+			line:     "「本当にそう思いますか？」と彼女は静かに聞きましたが、誰も答えませんでした。",
+			expected: []string{"「本当にそう思いますか？」", "と彼女は静かに聞きましたが、", "誰も答えませんでした。"},
+		},
+		{
+			name:     "Long phrase without punctuation is kept",
+			line:     "私は昨日友達と一緒に東京へ行って美味しいラーメンを食べました",
+			expected: []string{"私は昨日友達と一緒に東京へ行って美味しいラーメンを食べました"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, splitLongUnspacedPhrases(tt.line, segmenter{policy: tt.policy}))
+		})
+	}
+}
+
+// TestCombinePhrases tests combining the pieces of a split phrase
+func TestCombinePhrases(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		pieces   []string
+		expected []string
+	}{
+		{
+			name:     "short last piece is combined with the one before it",
+			pieces:   []string{"one two three four five six seven,", "eight nine ten eleven twelve thirteen,", "fourteen."},
+			expected: []string{"one two three four five six seven,", "eight nine ten eleven twelve thirteen, fourteen."},
+		},
+		{
+			// the last two pieces were both dropped when they were combined
+			name:     "short last piece after a long one is kept",
+			pieces:   []string{"one two three four five six seven,", "one two three four five six seven eight nine ten,", "the end."},
+			expected: []string{"one two three four five six seven,", "one two three four five six seven eight nine ten, the end."},
+		},
+		{
+			name:     "neighbours that fit are combined",
+			pieces:   []string{"one two three four,", "five six seven eight,", "nine ten eleven twelve thirteen fourteen."},
+			expected: []string{"one two three four, five six seven eight,", "nine ten eleven twelve thirteen fourteen."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := combinePhrases(tt.pieces, wordCount, " ", minimumPhraseLength, maximumPhraseLength)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
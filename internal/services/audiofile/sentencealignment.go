@@ -0,0 +1,237 @@
+package audiofile
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// sentenceLengthVariance is the variance of the length of a translation for each
+	// character of the original sentence measured by Gale and Church
+	sentenceLengthVariance = 6.8
+	// anchorPenalty is added to the cost of a match whose sentences end with different
+	// question or exclamation marks or contain different numbers
+	anchorPenalty = 3.0
+	// alignmentBand is how many translations before or after the diagonal a sentence can be
+	// aligned with, so long texts are aligned in linear memory instead of quadratic
+	alignmentBand = 100
+)
+
+// numberRegex matches the numbers in a sentence that are written the same in its translation
+var numberRegex = regexp.MustCompile(`\p{Nd}+`)
+
+// sentenceMatch is a number of sentences of the text matched with a number of sentences of
+// the translation and how likely it is by Gale and Church
+type sentenceMatch struct {
+	sentences    int
+	translations int
+	prior        float64
+}
+
+// sentenceMatches are the matches that are tried. Most sentences are translated by one
+// sentence and a few are split in two or merged into one, or left out.
+var sentenceMatches = []sentenceMatch{
+	{sentences: 1, translations: 1, prior: 0.89},
+	{sentences: 1, translations: 2, prior: 0.0445},
+	{sentences: 2, translations: 1, prior: 0.0445},
+	{sentences: 2, translations: 2, prior: 0.011},
+	{sentences: 1, translations: 0, prior: 0.00495},
+	{sentences: 0, translations: 1, prior: 0.00495},
+}
+
+// sentenceAnchor is the mark a sentence ends with and the numbers in it, which are found
+// once for each sentence instead of for every match that is tried
+type sentenceAnchor struct {
+	mark    rune
+	numbers []string
+}
+
+// sentenceLink is a group of sentences of the text aligned with a group of sentences of the
+// translation
+type sentenceLink struct {
+	sentences    []string
+	translations []string
+}
+
+// alignSentences aligns the sentences of a text with the sentences of its translation by
+// their lengths like Gale and Church. The lengths are compared in letters and numbers scaled
+// by the ratio of the lengths of the whole texts, so languages that need more or fewer
+// characters are still aligned. Matches whose sentences end with different question or
+// exclamation marks or have different numbers are less likely. It returns the links in the
+// order of the texts. Only the alignments near the diagonal from the first sentences to the
+// last are tried like Gale and Church.
+func alignSentences(sentences, translations []string) []sentenceLink {
+	lengths := sentenceLengths(sentences)
+	translationLengths := sentenceLengths(translations)
+	anchors := sentenceAnchors(sentences)
+	translationAnchors := sentenceAnchors(translations)
+	ratio := 1.0
+	if total, translationTotal := totalLength(lengths), totalLength(translationLengths); total > 0 && translationTotal > 0 {
+		ratio = float64(translationTotal) / float64(total)
+	}
+
+	// the band is wide enough that every row overlaps the next when there are many more
+	// translations than sentences
+	n, total := len(sentences), len(translations)
+	band := max(alignmentBand, total/max(n, 1)+2)
+
+	// costs[i][j-starts[i]] is the lowest cost of aligning the first i sentences with the
+	// first j translations and moves[i][j-starts[i]] is the last match of it
+	starts := make([]int, n+1)
+	costs := make([][]float64, n+1)
+	moves := make([][]int8, n+1)
+	for i := range costs {
+		diagonal := 0
+		if n > 0 {
+			diagonal = i * total / n
+		}
+		starts[i] = max(0, diagonal-band)
+		end := min(total, diagonal+band)
+		costs[i] = make([]float64, end-starts[i]+1)
+		moves[i] = make([]int8, end-starts[i]+1)
+		for j := range costs[i] {
+			costs[i][j] = math.Inf(1)
+		}
+	}
+	costs[0][0] = 0
+
+	for i := 0; i <= n; i++ {
+		for k, c := range costs[i] {
+			if math.IsInf(c, 1) {
+				continue
+			}
+			j := starts[i] + k
+			for m, match := range sentenceMatches {
+				ni, nj := i+match.sentences, j+match.translations
+				if ni > n || nj < starts[ni] || nj-starts[ni] >= len(costs[ni]) {
+					continue
+				}
+				cost := c + matchCost(match,
+					totalLength(lengths[i:ni]), totalLength(translationLengths[j:nj]), ratio,
+					anchors[i:ni], translationAnchors[j:nj])
+				if cost < costs[ni][nj-starts[ni]] {
+					costs[ni][nj-starts[ni]] = cost
+					moves[ni][nj-starts[ni]] = int8(m)
+				}
+			}
+		}
+	}
+
+	var links []sentenceLink
+	for i, j := n, total; i > 0 || j > 0; {
+		match := sentenceMatches[moves[i][j-starts[i]]]
+		links = append(links, sentenceLink{
+			sentences:    sentences[i-match.sentences : i],
+			translations: translations[j-match.translations : j],
+		})
+		i, j = i-match.sentences, j-match.translations
+	}
+	slices.Reverse(links)
+	return links
+}
+
+// matchCost returns the negative log of how likely the sentences are a translation of each
+// other from the prior of the match and how much their lengths differ from the ratio
+func matchCost(match sentenceMatch, length, translationLength int, ratio float64, sentences, translations []sentenceAnchor) float64 {
+	mean := (float64(length) + float64(translationLength)/ratio) / 2
+	cost := -math.Log(match.prior)
+	if mean > 0 {
+		delta := (float64(length)*ratio - float64(translationLength)) / math.Sqrt(mean*sentenceLengthVariance)
+		// the probability of a difference at least this large in a normal distribution
+		probability := math.Erfc(math.Abs(delta) / math.Sqrt2)
+		cost -= math.Log(max(probability, math.SmallestNonzeroFloat64))
+	}
+	if len(sentences) > 0 && len(translations) > 0 && !anchorsMatch(sentences, translations) {
+		cost += anchorPenalty
+	}
+	return cost
+}
+
+// anchorsMatch checks if the last sentences of both groups end with the same kind of mark,
+// like a question mark and ？, and if both groups contain numbers that they are the same
+func anchorsMatch(sentences, translations []sentenceAnchor) bool {
+	if sentences[len(sentences)-1].mark != translations[len(translations)-1].mark {
+		return false
+	}
+	var numbers, translationNumbers []string
+	for _, anchor := range sentences {
+		numbers = append(numbers, anchor.numbers...)
+	}
+	for _, anchor := range translations {
+		translationNumbers = append(translationNumbers, anchor.numbers...)
+	}
+	if len(numbers) == 0 || len(translationNumbers) == 0 {
+		return true
+	}
+	slices.Sort(numbers)
+	slices.Sort(translationNumbers)
+	return slices.Equal(numbers, translationNumbers)
+}
+
+// endingMark returns ? for a sentence that is a question, ! for an exclamation and . for
+// any other sentence
+func endingMark(sentence string) rune {
+	sentence = strings.TrimRightFunc(sentence, func(r rune) bool {
+		return unicode.IsSpace(r) || isTrailingMark(r)
+	})
+	last, _ := utf8.DecodeLastRuneInString(sentence)
+	switch last {
+	case '?', '？', '؟':
+		return '?'
+	case '!', '！':
+		return '!'
+	default:
+		return '.'
+	}
+}
+
+// sentenceAnchors returns the ending mark and numbers of each sentence
+func sentenceAnchors(sentences []string) []sentenceAnchor {
+	anchors := make([]sentenceAnchor, len(sentences))
+	for i, sentence := range sentences {
+		anchors[i] = sentenceAnchor{mark: endingMark(sentence), numbers: numberRegex.FindAllString(sentence, -1)}
+	}
+	return anchors
+}
+
+// sentenceLengths returns the number of letters and numbers of each sentence
+func sentenceLengths(sentences []string) []int {
+	lengths := make([]int, len(sentences))
+	for i, sentence := range sentences {
+		lengths[i] = characterCount(sentence)
+	}
+	return lengths
+}
+
+// totalLength returns the sum of the lengths
+func totalLength(lengths []int) int {
+	total := 0
+	for _, length := range lengths {
+		total += length
+	}
+	return total
+}
+
+// kind returns the number of sentences of each side of the link like 2:1
+func (l sentenceLink) kind() string {
+	return fmt.Sprintf("%d:%d", len(l.sentences), len(l.translations))
+}
+
+// sentenceAlignmentReport returns the links as the alignment report of a ParseResult
+func sentenceAlignmentReport(links []sentenceLink) []interfaces.SentenceAlignment {
+	report := make([]interfaces.SentenceAlignment, len(links))
+	for i, link := range links {
+		report[i] = interfaces.SentenceAlignment{
+			Kind:        link.kind(),
+			Text:        strings.Join(link.sentences, " "),
+			Translation: strings.Join(link.translations, " "),
+		}
+	}
+	return report
+}
@@ -0,0 +1,184 @@
+package audiofile
+
+import (
+	"fmt"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAlignSentences(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name         string
+		sentences    []string
+		translations []string
+		kinds        []string
+	}{
+		{
+			name:         "one to one",
+			sentences:    []string{"The train leaves at nine.", "Where is the station?", "Thank you very much for your help."},
+			translations: []string{"El tren sale a las nueve.", "¿Dónde está la estación?", "Muchas gracias por su ayuda."},
+			kinds:        []string{"1:1", "1:1", "1:1"},
+		},
+		{
+			name: "translation split in two",
+			sentences: []string{
+				"We arrived late.",
+				"The old man, who had waited for us all night in the cold, finally opened the door and let us in.",
+				"It was warm inside.",
+			},
+			translations: []string{
+				"Llegamos tarde.",
+				"El anciano nos había esperado toda la noche en el frío.",
+				"Por fin abrió la puerta y nos dejó entrar.",
+				"Dentro hacía calor.",
+			},
+			kinds: []string{"1:1", "1:2", "1:1"},
+		},
+		{
+			name: "text split in two",
+			sentences: []string{
+				"I bought bread.",
+				"The baker was very friendly.",
+				"She gave me a cake for free.",
+				"Then I went home.",
+			},
+			translations: []string{
+				"Compré pan.",
+				"La panadera fue muy amable y me regaló un pastel.",
+				"Luego me fui a casa.",
+			},
+			kinds: []string{"1:1", "2:1", "1:1"},
+		},
+		{
+			name:         "empty translation",
+			sentences:    []string{"Hello."},
+			translations: nil,
+			kinds:        []string{"1:0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := alignSentences(tt.sentences, tt.translations)
+			var kinds []string
+			for _, link := range links {
+				kinds = append(kinds, link.kind())
+			}
+			require.Equal(t, tt.kinds, kinds)
+		})
+	}
+}
+
+func TestAlignSentencesBand(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	var long, longTranslations []string
+	for i := 0; i < 1000; i++ {
+		long = append(long, fmt.Sprintf("Sentence number %d is here.", i))
+		longTranslations = append(longTranslations, fmt.Sprintf("La frase número %d está aquí.", i))
+	}
+	var many []string
+	for i := 0; i < 500; i++ {
+		many = append(many, "Sí.")
+	}
+
+	tests := []struct {
+		name         string
+		sentences    []string
+		translations []string
+		links        int
+	}{
+		{
+			// every sentence is only compared with the translations near it
+			name:         "long texts",
+			sentences:    long,
+			translations: longTranslations,
+			links:        1000,
+		},
+		{
+			name:         "many more translations",
+			sentences:    []string{"Yes.", "No."},
+			translations: many,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			links := alignSentences(tt.sentences, tt.translations)
+			var sentences, translations []string
+			for _, link := range links {
+				sentences = append(sentences, link.sentences...)
+				translations = append(translations, link.translations...)
+			}
+			require.Equal(t, tt.sentences, sentences)
+			require.Equal(t, tt.translations, translations)
+			if tt.links > 0 {
+				require.Len(t, links, tt.links)
+			}
+		})
+	}
+}
+
+func TestAnchorsMatch(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name         string
+		sentences    []string
+		translations []string
+		expected     bool
+	}{
+		{
+			name:         "same marks",
+			sentences:    []string{"Where is it?"},
+			translations: []string{"¿Dónde está?"},
+			expected:     true,
+		},
+		{
+			name:         "full width question mark",
+			sentences:    []string{"Where is it?"},
+			translations: []string{"どこですか？"},
+			expected:     true,
+		},
+		{
+			name:         "question and statement",
+			sentences:    []string{"Where is it?"},
+			translations: []string{"Está aquí."},
+			expected:     false,
+		},
+		{
+			name:         "quoted exclamation",
+			sentences:    []string{"He said “stop!”"},
+			translations: []string{"Dijo «¡para!»"},
+			expected:     true,
+		},
+		{
+			name:         "same numbers",
+			sentences:    []string{"It costs 25 euros in 2024."},
+			translations: []string{"En 2024 cuesta 25 euros."},
+			expected:     true,
+		},
+		{
+			name:         "different numbers",
+			sentences:    []string{"It costs 25 euros."},
+			translations: []string{"Cuesta 30 euros."},
+			expected:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, anchorsMatch(sentenceAnchors(tt.sentences), sentenceAnchors(tt.translations)))
+		})
+	}
+}
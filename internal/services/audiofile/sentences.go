@@ -0,0 +1,246 @@
+package audiofile
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// abbreviations are the words of a language that are written with a period that does not
+// end the sentence. They are lower case and without the final period.
+type abbreviations struct {
+	// titles come before a name (Mr. Smith) and never end a sentence
+	titles []string
+	// others (etc., p.m.) only end a sentence when the next word is capitalized
+	others []string
+	// ordinals is set for languages that write ordinal numbers with a period (3. Oktober)
+	ordinals bool
+}
+
+// languageAbbreviations are the abbreviations of each base language
+var languageAbbreviations = map[string]abbreviations{
+	"en": {
+		titles: []string{"mr", "mrs", "ms", "dr", "prof", "st", "sr", "jr", "mt", "gen", "col", "capt", "lt", "sgt", "rev", "hon", "gov", "sen", "rep", "pres", "messrs", "vs", "fig"},
+		others: []string{"etc", "e.g", "i.e", "a.m", "p.m", "inc", "ltd", "co", "corp", "jan", "feb", "apr", "jun", "jul", "aug", "sep", "sept", "oct", "nov", "dec", "approx", "dept", "est", "vol", "ca", "cf", "al", "u.s", "u.k"},
+	},
+	"es": {
+		titles: []string{"sr", "sra", "srta", "sres", "dr", "dra", "lic", "ing", "prof", "d", "dña", "ud", "uds"},
+		others: []string{"etc", "pág", "núm", "aprox", "p.ej", "a.m", "p.m", "ee.uu", "cía"},
+	},
+	"fr": {
+		titles: []string{"m", "mme", "mlle", "mm", "dr", "pr", "me", "st", "ste"},
+		others: []string{"etc", "p.ex", "av", "bd", "env", "cf", "n.b", "apr", "j.-c"},
+	},
+	"de": {
+		titles:   []string{"hr", "hrn", "fr", "dr", "prof", "st"},
+		others:   []string{"z.b", "bzw", "usw", "ca", "evtl", "ggf", "inkl", "nr", "str", "vgl", "u.a", "d.h", "s.o", "s.u", "bspw", "abs", "jh"},
+		ordinals: true,
+	},
+	"it": {
+		titles: []string{"sig", "sigg", "sig.ra", "dott", "dott.ssa", "prof", "avv", "ing", "geom", "on"},
+		others: []string{"ecc", "es", "pag", "ca", "n", "tel"},
+	},
+	"pt": {
+		titles: []string{"sr", "sra", "srta", "dr", "dra", "prof", "profa", "exmo", "exma", "v.exa"},
+		others: []string{"etc", "p.ex", "pág", "aprox", "n.º", "av"},
+	},
+	"nl": {
+		titles: []string{"dhr", "mevr", "dr", "prof", "mr", "ir", "drs", "ing"},
+		others: []string{"bijv", "enz", "o.a", "m.b.t", "d.w.z", "i.p.v", "ca", "nr", "z.g.a.n"},
+	},
+	"ru": {
+		titles: []string{"г", "гг", "ул", "им", "проф", "акад"},
+		others: []string{"т.е", "т.д", "т.п", "т.к", "др", "пр", "см", "стр", "руб", "коп", "тыс", "млн", "млрд", "н.э"},
+	},
+}
+
+// allAbbreviations are used when the language of the file is not known
+var allAbbreviations = mergeAbbreviations()
+
+// mergeAbbreviations combines the abbreviations of every language. Ordinals are left out
+// because they are only used by some languages.
+func mergeAbbreviations() abbreviations {
+	var all abbreviations
+	for _, abbr := range languageAbbreviations {
+		all.titles = append(all.titles, abbr.titles...)
+		all.others = append(all.others, abbr.others...)
+	}
+	return all
+}
+
+// abbreviations returns the abbreviations of the declared language or of every language
+// when it is not declared
+func (s segmenter) abbreviations() abbreviations {
+	if abbr, ok := languageAbbreviations[s.declared]; ok {
+		return abbr
+	}
+	return allAbbreviations
+}
+
+// splitOnEndingPunctuation splits the text into sentences. The terminal punctuation and any
+// closing quotes or brackets after it stay with the sentence. Periods in abbreviations,
+// initials, decimal numbers and ellipses that continue the sentence do not end it, and a
+// blank line always ends a sentence.
+func splitOnEndingPunctuation(text string, seg segmenter) []string {
+	abbr := seg.abbreviations()
+	runes := []rune(text)
+	var sentences []string
+	add := func(sentence []rune) {
+		s := strings.TrimSpace(string(sentence))
+		if !strings.ContainsFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsNumber(r) }) {
+			return
+		}
+		if seg.unspaced(s) {
+			s = strings.NewReplacer("\r", "", "\n", "").Replace(s)
+		} else {
+			s = strings.Join(strings.Fields(s), " ")
+		}
+		sentences = append(sentences, s)
+	}
+
+	start := 0
+	// letter is the last letter seen and decides which script's punctuation table is used
+	var letter rune
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsLetter(r) {
+			letter = r
+		}
+		if r == '\n' && isBlankLineAfter(runes, i) {
+			add(runes[start:i])
+			start = i + 1
+			continue
+		}
+		if !isSentenceMark(r, letter) {
+			continue
+		}
+
+		// the run of marks like ?! or ...
+		marksEnd := i + 1
+		for marksEnd < len(runes) && isSentenceMark(runes[marksEnd], letter) {
+			marksEnd++
+		}
+		// closing quotes and brackets belong to the sentence they close
+		end := marksEnd
+		for end < len(runes) && isTrailingMark(runes[end]) {
+			end++
+		}
+
+		if isSentenceEnd(runes, i, marksEnd, end, abbr) {
+			add(runes[start:end])
+			start = end
+		}
+		i = end - 1
+	}
+	add(runes[start:])
+
+	return sentences
+}
+
+// isSentenceMark checks if the rune is terminal punctuation or an ellipsis
+func isSentenceMark(r, letter rune) bool {
+	return r == '…' || punctuationOf(r, letter) == terminalPunctuation
+}
+
+// isBlankLineAfter checks if the newline at i is followed by a line with only whitespace
+func isBlankLineAfter(runes []rune, i int) bool {
+	for j := i + 1; j < len(runes); j++ {
+		switch {
+		case runes[j] == '\n':
+			return true
+		case !unicode.IsSpace(runes[j]):
+			return false
+		}
+	}
+	return false
+}
+
+// isSentenceEnd decides if the marks from first to marksEnd, followed by the closing quotes
+// and brackets up to end, end the sentence
+func isSentenceEnd(runes []rune, first, marksEnd, end int, abbr abbreviations) bool {
+	if end == len(runes) {
+		return true
+	}
+	// ascii marks inside a word like 3.50, example.com or Yahoo! do not end the sentence,
+	// full-width marks and marks before languages without spaces are not followed by a space.
+	// A quote that is followed by more text (「行きましょう！」と言った) continues the sentence.
+	if !unicode.IsSpace(runes[end]) {
+		if end > marksEnd {
+			return false
+		}
+		return (runes[first] >= 0x80 && runes[first] != '…') || unicode.In(runes[end], unspacedScripts...)
+	}
+	next := nextWordStart(runes, end)
+	if next == 0 {
+		return true
+	}
+	// a quote that ends in a mark and continues with a lower case word ("Really?" she asked)
+	if end > marksEnd && unicode.IsLower(next) {
+		return false
+	}
+
+	marks := string(runes[first:marksEnd])
+	switch {
+	case marks == "...", marks == "…", strings.Trim(marks, ".…") == "" && len(marks) > 1:
+		// an ellipsis only ends the sentence when the next word is not lower case
+		return !unicode.IsLower(next)
+	case marks != ".":
+		return true
+	}
+
+	word := wordBefore(runes, first)
+	if isInitial(word) {
+		return false
+	}
+	word = strings.ToLower(word)
+	switch {
+	case word == "":
+		return true
+	case slices.Contains(abbr.titles, word):
+		return false
+	case abbr.ordinals && isNumber(word):
+		return false
+	case slices.Contains(abbr.others, word) || strings.Contains(word, "."):
+		// abbreviations and acronyms like u.s. end the sentence before a capitalized word
+		return unicode.IsUpper(next)
+	}
+
+	return true
+}
+
+// nextWordStart returns the first letter or number after the whitespace and opening quotes
+// and brackets at i, or 0 if there is none
+func nextWordStart(runes []rune, i int) rune {
+	for ; i < len(runes); i++ {
+		r := runes[i]
+		if unicode.IsLetter(r) || unicode.IsNumber(r) {
+			return r
+		}
+		if !unicode.IsSpace(r) && !unicode.In(r, unicode.Ps, unicode.Pi) && !strings.ContainsRune("\"'¿¡", r) {
+			return r
+		}
+	}
+	return 0
+}
+
+// wordBefore returns the word that ends at i without any opening quotes or brackets
+func wordBefore(runes []rune, i int) string {
+	start := i
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	return strings.TrimLeftFunc(string(runes[start:i]), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// isInitial checks if the word is a single capital letter like the J. in J. R. R. Tolkien
+func isInitial(word string) bool {
+	runes := []rune(word)
+	return len(runes) == 1 && unicode.IsUpper(runes[0])
+}
+
+// isNumber checks if the word is only digits
+func isNumber(word string) bool {
+	return word != "" && strings.IndexFunc(word, func(r rune) bool { return !unicode.IsDigit(r) }) == -1
+}
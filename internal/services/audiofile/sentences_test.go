@@ -0,0 +1,148 @@
+package audiofile
+
+import (
+	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSplitOnEndingPunctuation tests the splitOnEndingPunctuation helper function
+func TestSplitOnEndingPunctuation(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		lang     string
+		input    string
+		expected []string
+	}{
+		{
+			name:     "Simple sentences with periods",
+			input:    "This is the first sentence. This is the second sentence.",
+			expected: []string{"This is the first sentence.", "This is the second sentence."},
+		},
+		{
+			name:     "Mixed punctuation",
+			input:    "First sentence! Second sentence? Third sentence.",
+			expected: []string{"First sentence!", "Second sentence?", "Third sentence."},
+		},
+		{
+			name:     "Abbreviations, decimals and times",
+			input:    "Mr. Smith paid $3.50 at 5 p.m. Then he went home.",
+			expected: []string{"Mr. Smith paid $3.50 at 5 p.m.", "Then he went home."},
+		},
+		{
+			name:     "Abbreviation before a lower case word",
+			input:    "We bought apples, pears, etc. and went home.",
+			expected: []string{"We bought apples, pears, etc. and went home."},
+		},
+		{
+			name:     "Initials and acronyms",
+			input:    "J. R. R. Tolkien moved to the U.S. in winter. He liked it.",
+			expected: []string{"J. R. R. Tolkien moved to the U.S. in winter.", "He liked it."},
+		},
+		{
+			name:     "Ellipsis that continues the sentence",
+			input:    "I was thinking... maybe we should go. Or not… We will see…",
+			expected: []string{"I was thinking... maybe we should go.", "Or not…", "We will see…"},
+		},
+		{
+			name:     "Ellipsis alone is not a sentence",
+			input:    "... Hello there. ...",
+			expected: []string{"Hello there."},
+		},
+		{
+			name:     "Closing quotes and brackets stay with the sentence",
+			input:    `He said "I am tired." She left. (It was late.) "Really?" she asked.`,
+			expected: []string{`He said "I am tired."`, "She left.", "(It was late.)", `"Really?" she asked.`},
+		},
+		{
+			name:     "Repeated marks",
+			input:    "What?! No way!!! Okay.",
+			expected: []string{"What?!", "No way!!!", "Okay."},
+		},
+		{
+			name:     "Urls and domains are not split",
+			input:    "Visit example.com for more. Thanks!",
+			expected: []string{"Visit example.com for more.", "Thanks!"},
+		},
+		{
+			name:     "Blank line ends a sentence",
+			input:    "Chapter One\n\nIt was a dark\nand stormy night.",
+			expected: []string{"Chapter One", "It was a dark and stormy night."},
+		},
+		{
+			name:     "Spanish abbreviations",
+			lang:     "es",
+			input:    "La Sra. García llegó a las 9 a.m. y se fue. ¿Vienes?",
+			expected: []string{"La Sra. García llegó a las 9 a.m. y se fue.", "¿Vienes?"},
+		},
+		{
+			name:     "German ordinals",
+			lang:     "de",
+			input:    "Am 3. Oktober ist ein Feiertag. Das ist z.B. wichtig.",
+			expected: []string{"Am 3. Oktober ist ein Feiertag.", "Das ist z.B. wichtig."},
+		},
+		{
+			name:     "English numbers are not ordinals",
+			lang:     "en",
+			input:    "The answer is 42. Everyone knows it.",
+			expected: []string{"The answer is 42.", "Everyone knows it."},
+		},
+		{
+			name:     "Japanese full stop without spaces",
+			input:    "今日はいい天気ですね。「散歩に行きましょう！」と彼は言った。",
+			expected: []string{"今日はいい天気ですね。", "「散歩に行きましょう！」と彼は言った。"},
+		},
+		{
+			name:     "Arabic question mark",
+			input:    "كيف حالك؟ أنا بخير، شكرا.",
+			expected: []string{"كيف حالك؟", "أنا بخير، شكرا."},
+		},
+		{
+			name:     "Urdu full stop",
+			input:    "میں ٹھیک ہوں۔ آپ کیسے ہیں؟",
+			expected: []string{"میں ٹھیک ہوں۔", "آپ کیسے ہیں؟"},
+		},
+		{
+			name:     "Hindi danda and double danda",
+			input:    "मैं ठीक हूँ। आप कैसे हैं॥",
+			expected: []string{"मैं ठीक हूँ।", "आप कैसे हैं॥"},
+		},
+		{
+			name:     "Greek question mark and semicolon",
+			input:    "Τι κάνεις; Καλά είμαι.",
+			expected: []string{"Τι κάνεις;", "Καλά είμαι."},
+		},
+		{
+			name:     "Latin semicolon does not end the sentence",
+			input:    "I came; I saw. I left.",
+			expected: []string{"I came; I saw.", "I left."},
+		},
+		{
+			name:     "Armenian full stop and colon",
+			input:    "Ո՞ւր ես գնում։ Ես գնում եմ տուն:",
+			expected: []string{"Ո՞ւր ես գնում։", "Ես գնում եմ տուն:"},
+		},
+		{
+			name:     "Amharic full stop",
+			input:    "ሰላም ነው። እንዴት ነህ፧",
+			expected: []string{"ሰላም ነው።", "እንዴት ነህ፧"},
+		},
+		{
+			name:     "Arabic question mark after a latin word",
+			input:    "هل عندك iPhone؟ نعم",
+			expected: []string{"هل عندك iPhone؟", "نعم"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitOnEndingPunctuation(tt.input, newSegmenter(tt.lang, interfaces.PhrasePolicy{}))
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,444 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	// sqliteHeaderSize is the size of the database header at the start of the first page
+	sqliteHeaderSize = 100
+	// sqliteMaxDepth is the deepest b-tree that is read so a corrupt file cannot loop forever
+	sqliteMaxDepth = 64
+)
+
+// sqliteMagic is the first 16 bytes of every SQLite 3 database
+var sqliteMagic = []byte("SQLite format 3\x00")
+
+// sqliteDB is a read only SQLite 3 database in memory. It only reads the tables it needs
+// for imports like Anki collections so it does not need cgo.
+type sqliteDB struct {
+	data     []byte
+	pageSize int
+	// usable is the page size without the bytes reserved at the end of each page
+	usable int
+	tables map[string]sqliteTable
+}
+
+// sqliteTable is the root page and column names of a table
+type sqliteTable struct {
+	root    int
+	columns []string
+	// rowidColumn is the column that is an alias of the rowid (INTEGER PRIMARY KEY) or -1
+	rowidColumn  int
+	withoutRowid bool
+}
+
+// sqliteRow is a row of a table by column name. The values are nil, int64, float64, string
+// or []byte.
+type sqliteRow map[string]any
+
+// openSQLite reads the header and schema of the database
+func openSQLite(data []byte) (*sqliteDB, error) {
+	if len(data) < sqliteHeaderSize || !bytes.HasPrefix(data, sqliteMagic) {
+		return nil, errors.New("not a SQLite database")
+	}
+	pageSize := int(binary.BigEndian.Uint16(data[16:18]))
+	if pageSize == 1 {
+		pageSize = 65536
+	}
+	if pageSize < 512 || pageSize&(pageSize-1) != 0 {
+		return nil, fmt.Errorf("invalid SQLite page size: %d", pageSize)
+	}
+	// text encoding 1 is UTF-8, 2 and 3 are UTF-16 which is not supported
+	if encoding := binary.BigEndian.Uint32(data[56:60]); encoding > 1 {
+		return nil, errors.New("only UTF-8 SQLite databases are supported")
+	}
+	db := &sqliteDB{
+		data:     data,
+		pageSize: pageSize,
+		usable:   pageSize - int(data[20]),
+		tables:   make(map[string]sqliteTable),
+	}
+
+	// the schema table is on page 1 with the columns type, name, tbl_name, rootpage and sql
+	schema := sqliteTable{root: 1, columns: []string{"type", "name", "tbl_name", "rootpage", "sql"}, rowidColumn: -1}
+	rows, err := db.readTable(schema)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		kind, _ := row["type"].(string)
+		name, _ := row["name"].(string)
+		root, _ := row["rootpage"].(int64)
+		sql, _ := row["sql"].(string)
+		if kind != "table" || root <= 0 {
+			continue
+		}
+		table := parseCreateTable(sql)
+		table.root = int(root)
+		db.tables[strings.ToLower(name)] = table
+	}
+	return db, nil
+}
+
+// hasTable checks if the database has the table
+func (db *sqliteDB) hasTable(name string) bool {
+	_, ok := db.tables[strings.ToLower(name)]
+	return ok
+}
+
+// rows returns every row of the table in the order of its primary key
+func (db *sqliteDB) rows(name string) ([]sqliteRow, error) {
+	table, ok := db.tables[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("table %s not found", name)
+	}
+	return db.readTable(table)
+}
+
+// readTable reads every row of the table b-tree
+func (db *sqliteDB) readTable(table sqliteTable) ([]sqliteRow, error) {
+	var rows []sqliteRow
+	err := db.walk(table.root, 0, make(map[int]bool), func(rowid int64, payload []byte) error {
+		values, err := decodeRecord(payload)
+		if err != nil {
+			return err
+		}
+		row := make(sqliteRow, len(table.columns))
+		for i, column := range table.columns {
+			var value any
+			if i < len(values) {
+				value = values[i]
+			}
+			if i == table.rowidColumn && value == nil {
+				value = rowid
+			}
+			row[column] = value
+		}
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+// page returns the page with the number, which starts at 1
+func (db *sqliteDB) page(number int) ([]byte, error) {
+	start := (number - 1) * db.pageSize
+	if number < 1 || start+db.pageSize > len(db.data) {
+		return nil, fmt.Errorf("SQLite page %d is out of range", number)
+	}
+	return db.data[start : start+db.pageSize], nil
+}
+
+// walk calls fn with the rowid and payload of every cell of the table or index b-tree in
+// order. The rowid is 0 for the cells of an index, which is how WITHOUT ROWID tables are
+// stored. A page that was already visited is an error so a corrupt b-tree is read once.
+func (db *sqliteDB) walk(number, depth int, visited map[int]bool, fn func(int64, []byte) error) error {
+	if depth > sqliteMaxDepth {
+		return errors.New("SQLite b-tree is too deep")
+	}
+	if visited[number] {
+		return fmt.Errorf("SQLite page %d is in the b-tree more than once", number)
+	}
+	visited[number] = true
+	page, err := db.page(number)
+	if err != nil {
+		return err
+	}
+	header := 0
+	if number == 1 {
+		header = sqliteHeaderSize
+	}
+	if len(page) < header+12 {
+		return errors.New("SQLite page is too small")
+	}
+	kind := page[header]
+	cells := int(binary.BigEndian.Uint16(page[header+3:]))
+	pointers := header + 8
+	if kind == 0x02 || kind == 0x05 {
+		pointers = header + 12
+	}
+	if pointers+cells*2 > len(page) {
+		return errors.New("SQLite page has too many cells")
+	}
+
+	for i := 0; i < cells; i++ {
+		offset := int(binary.BigEndian.Uint16(page[pointers+i*2:]))
+		if offset >= len(page) {
+			return errors.New("SQLite cell is out of range")
+		}
+		cell := page[offset:]
+		if (kind == 0x05 || kind == 0x02) && len(cell) < 4 {
+			return errors.New("SQLite cell is out of range")
+		}
+		switch kind {
+		case 0x0d: // table leaf: payload size, rowid, payload
+			size, n := readVarint(cell)
+			rowid, m := readVarint(cell[n:])
+			payload, err := db.payload(cell[n+m:], size, false)
+			if err != nil {
+				return err
+			}
+			if err := fn(rowid, payload); err != nil {
+				return err
+			}
+		case 0x05: // table interior: left child, key
+			if err := db.walk(int(binary.BigEndian.Uint32(cell)), depth+1, visited, fn); err != nil {
+				return err
+			}
+		case 0x0a: // index leaf: payload size, payload
+			size, n := readVarint(cell)
+			payload, err := db.payload(cell[n:], size, true)
+			if err != nil {
+				return err
+			}
+			if err := fn(0, payload); err != nil {
+				return err
+			}
+		case 0x02: // index interior: left child, payload size, payload
+			if err := db.walk(int(binary.BigEndian.Uint32(cell)), depth+1, visited, fn); err != nil {
+				return err
+			}
+			size, n := readVarint(cell[4:])
+			payload, err := db.payload(cell[4+n:], size, true)
+			if err != nil {
+				return err
+			}
+			if err := fn(0, payload); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("invalid SQLite page type: %d", kind)
+		}
+	}
+
+	if kind == 0x02 || kind == 0x05 {
+		return db.walk(int(binary.BigEndian.Uint32(page[header+8:])), depth+1, visited, fn)
+	}
+	return nil
+}
+
+// payload returns the payload of a cell that starts at the beginning of local and continues
+// on overflow pages if it is too large for the page
+func (db *sqliteDB) payload(local []byte, size int64, index bool) ([]byte, error) {
+	u := int64(db.usable)
+	maxLocal := u - 35
+	if index {
+		maxLocal = (u-12)*64/255 - 23
+	}
+	if size < 0 || size > int64(len(db.data)) {
+		return nil, errors.New("SQLite payload is too large")
+	}
+	if size <= maxLocal {
+		if size > int64(len(local)) {
+			return nil, errors.New("SQLite payload is out of range")
+		}
+		return local[:size], nil
+	}
+
+	minLocal := (u-12)*32/255 - 23
+	localSize := minLocal + (size-minLocal)%(u-4)
+	if localSize > maxLocal {
+		localSize = minLocal
+	}
+	if localSize+4 > int64(len(local)) {
+		return nil, errors.New("SQLite payload is out of range")
+	}
+	payload := make([]byte, 0, size)
+	payload = append(payload, local[:localSize]...)
+	next := int(binary.BigEndian.Uint32(local[localSize:]))
+	for pages := 0; int64(len(payload)) < size; pages++ {
+		if next == 0 || pages > len(db.data)/db.pageSize {
+			return nil, errors.New("SQLite overflow pages are corrupt")
+		}
+		page, err := db.page(next)
+		if err != nil {
+			return nil, err
+		}
+		chunk := min(u-4, size-int64(len(payload)))
+		payload = append(payload, page[4:4+chunk]...)
+		next = int(binary.BigEndian.Uint32(page))
+	}
+	return payload, nil
+}
+
+// decodeRecord decodes the values of a record
+func decodeRecord(payload []byte) ([]any, error) {
+	headerSize, n := readVarint(payload)
+	if headerSize < int64(n) || headerSize > int64(len(payload)) {
+		return nil, errors.New("SQLite record header is corrupt")
+	}
+	var types []int64
+	for i := n; i < int(headerSize); {
+		serial, m := readVarint(payload[i:])
+		types = append(types, serial)
+		i += m
+	}
+
+	body := payload[headerSize:]
+	values := make([]any, len(types))
+	for i, serial := range types {
+		size := serialSize(serial)
+		if size > int64(len(body)) {
+			return nil, errors.New("SQLite record is corrupt")
+		}
+		value := body[:size]
+		body = body[size:]
+		switch {
+		case serial == 0:
+			values[i] = nil
+		case serial >= 1 && serial <= 6:
+			values[i] = readInt(value)
+		case serial == 7:
+			values[i] = math.Float64frombits(binary.BigEndian.Uint64(value))
+		case serial == 8:
+			values[i] = int64(0)
+		case serial == 9:
+			values[i] = int64(1)
+		case serial >= 12 && serial%2 == 0:
+			values[i] = value
+		case serial >= 13:
+			values[i] = string(value)
+		default:
+			return nil, fmt.Errorf("invalid SQLite serial type: %d", serial)
+		}
+	}
+	return values, nil
+}
+
+// serialSize returns the number of bytes of a value of the serial type
+func serialSize(serial int64) int64 {
+	switch {
+	case serial >= 1 && serial <= 4:
+		return serial
+	case serial == 5:
+		return 6
+	case serial == 6 || serial == 7:
+		return 8
+	case serial >= 12:
+		return (serial - 12) / 2
+	default:
+		return 0
+	}
+}
+
+// readInt reads a big endian two's complement integer of 1 to 8 bytes
+func readInt(b []byte) int64 {
+	var v int64
+	if len(b) > 0 && b[0]&0x80 != 0 {
+		v = -1
+	}
+	for _, c := range b {
+		v = v<<8 | int64(c)
+	}
+	return v
+}
+
+// readVarint reads a SQLite variable length integer and returns it and its length
+func readVarint(b []byte) (int64, int) {
+	var v uint64
+	for i := 0; i < 9 && i < len(b); i++ {
+		if i == 8 {
+			return int64(v<<8 | uint64(b[i])), 9
+		}
+		v = v<<7 | uint64(b[i]&0x7f)
+		if b[i]&0x80 == 0 {
+			return int64(v), i + 1
+		}
+	}
+	return int64(v), len(b)
+}
+
+// parseCreateTable reads the column names of a CREATE TABLE statement. The primary key
+// columns of a WITHOUT ROWID table are stored first so they are moved to the front.
+func parseCreateTable(sql string) sqliteTable {
+	table := sqliteTable{rowidColumn: -1}
+	open, end := strings.Index(sql, "("), strings.LastIndex(sql, ")")
+	if open < 0 || end < open {
+		return table
+	}
+	table.withoutRowid = strings.Contains(strings.ToUpper(sql[end:]), "WITHOUT ROWID")
+
+	var primaryKey []string
+	for _, definition := range splitTopLevel(sql[open+1 : end]) {
+		fields := strings.Fields(definition)
+		if len(fields) == 0 {
+			continue
+		}
+		upper := strings.ToUpper(definition)
+		switch strings.ToUpper(fields[0]) {
+		case "PRIMARY":
+			if start, stop := strings.Index(definition, "("), strings.LastIndex(definition, ")"); start >= 0 && stop > start {
+				for _, column := range strings.Split(definition[start+1:stop], ",") {
+					if names := strings.Fields(column); len(names) > 0 {
+						primaryKey = append(primaryKey, unquoteIdentifier(names[0]))
+					}
+				}
+			}
+			continue
+		case "CONSTRAINT", "UNIQUE", "CHECK", "FOREIGN":
+			continue
+		}
+		column := unquoteIdentifier(fields[0])
+		if strings.Contains(upper, "PRIMARY KEY") {
+			primaryKey = append(primaryKey, column)
+			if len(fields) > 1 && strings.ToUpper(fields[1]) == "INTEGER" {
+				table.rowidColumn = len(table.columns)
+			}
+		}
+		table.columns = append(table.columns, column)
+	}
+
+	if table.withoutRowid {
+		table.rowidColumn = -1
+		columns := append([]string{}, primaryKey...)
+		for _, column := range table.columns {
+			if !containsFold(primaryKey, column) {
+				columns = append(columns, column)
+			}
+		}
+		table.columns = columns
+	}
+	return table
+}
+
+// splitTopLevel splits the column definitions of a CREATE TABLE statement on the commas
+// that are not in parentheses
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquoteIdentifier removes the quotes of an identifier like "name", `name` or [name]
+func unquoteIdentifier(s string) string {
+	return strings.ToLower(strings.Trim(s, "\"`[]'"))
+}
+
+// containsFold checks if the names contain the name ignoring case
+func containsFold(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package audiofile
+
+import (
+	"encoding/binary"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadVarint(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name     string
+		input    []byte
+		expected int64
+		length   int
+	}{
+		{name: "one byte", input: []byte{0x7f}, expected: 127, length: 1},
+		{name: "two bytes", input: []byte{0x81, 0x00}, expected: 128, length: 2},
+		{name: "nine bytes", input: []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}, expected: -1, length: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, n := readVarint(tt.input)
+			require.Equal(t, tt.expected, v)
+			require.Equal(t, tt.length, n)
+		})
+	}
+}
+
+func TestParseCreateTable(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name         string
+		sql          string
+		columns      []string
+		rowidColumn  int
+		withoutRowid bool
+	}{
+		{
+			name:        "integer primary key",
+			sql:         "CREATE TABLE notes (id integer primary key, guid text not null, flds text not null)",
+			columns:     []string{"id", "guid", "flds"},
+			rowidColumn: 0,
+		},
+		{
+			name:        "quoted names and a check",
+			sql:         "CREATE TABLE \"decks\" (`id` INTEGER, [name] text CHECK (length(name) > 0), UNIQUE (name))",
+			columns:     []string{"id", "name"},
+			rowidColumn: -1,
+		},
+		{
+			name:         "empty primary key columns",
+			sql:          "CREATE TABLE t (a text, b text, PRIMARY KEY (a, ), PRIMARY KEY ()) without rowid",
+			columns:      []string{"a", "b"},
+			rowidColumn:  -1,
+			withoutRowid: true,
+		},
+		{
+			name:         "without rowid",
+			sql:          "CREATE TABLE fields (name text NOT NULL, ntid integer NOT NULL, ord integer NOT NULL, PRIMARY KEY (ntid, ord)) without rowid",
+			columns:      []string{"ntid", "ord", "name"},
+			rowidColumn:  -1,
+			withoutRowid: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table := parseCreateTable(tt.sql)
+			require.Equal(t, tt.columns, table.columns)
+			require.Equal(t, tt.rowidColumn, table.rowidColumn)
+			require.Equal(t, tt.withoutRowid, table.withoutRowid)
+		})
+	}
+}
+
+func TestOpenSQLite(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	_, err := openSQLite([]byte("not a database"))
+	require.EqualError(t, err, "not a SQLite database")
+}
+
+func TestSQLiteWalk(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	// interiorPage is a table interior page with one cell at the offset whose left child is
+	// the page and whose right child is page 4
+	interiorPage := func(offset, child int) []byte {
+		page := make([]byte, 512)
+		page[0] = 0x05
+		binary.BigEndian.PutUint16(page[3:], 1)
+		binary.BigEndian.PutUint32(page[8:], 4)
+		binary.BigEndian.PutUint16(page[12:], uint16(offset))
+		if offset+4 <= len(page) {
+			binary.BigEndian.PutUint32(page[offset:], uint32(child))
+		}
+		return page
+	}
+	leafPage := make([]byte, 512)
+	leafPage[0] = 0x0d
+
+	tests := []struct {
+		name string
+		page []byte
+		err  string
+	}{
+		{
+			name: "cell at the end of the page",
+			page: interiorPage(510, 3),
+			err:  "SQLite cell is out of range",
+		},
+		{
+			name: "page is its own child",
+			page: interiorPage(100, 2),
+			err:  "SQLite page 2 is in the b-tree more than once",
+		},
+		{
+			name: "leaf children",
+			page: interiorPage(100, 3),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := append(append(append(make([]byte, 512), tt.page...), leafPage...), leafPage...)
+			db := &sqliteDB{data: data, pageSize: 512, usable: 512}
+			err := db.walk(2, 0, make(map[int]bool), func(int64, []byte) error { return nil })
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -1,7 +1,6 @@
 package audiofile
 
 import (
-	"bufio"
 	"errors"
 	"io"
 	"regexp"
@@ -15,73 +14,129 @@ const (
 	Srt TextFormat = iota
 	OnePhrasePerLine
 	Paragraph
+	WebVTT
+	Ass
+	Ttml
+	Bilingual
+	Anki
+	Epub
+	Docx
+	Odt
+	Pdf
+	Html
+	Lrc
+	Kindle
+	Markdown
+	Json3
+	Srv3
+	JsonTranscript
 )
 
-// DetectTextFormat determines the format of the uploaded text file
-func DetectTextFormat(fileStream io.ReadSeeker) (TextFormat, error) {
-	if fileStream == nil {
-		return 0, errors.New("fileStream is nil")
-	}
-
-	// Check if the file is in SRT format
-	reader := bufio.NewReader(fileStream)
-	scanner := bufio.NewScanner(reader)
-
-	for i := 0; i < 15 && scanner.Scan(); i++ {
-		line := scanner.Text()
-		if srtFormatCheck(line) {
-			// Seek back to the beginning of the file
-			if _, err := fileStream.Seek(0, io.SeekStart); err != nil {
-				return 0, err
-			}
-
-			return Srt, nil
-		}
-	}
-
-	// Seek back to the beginning of the file
-	if _, err := fileStream.Seek(0, io.SeekStart); err != nil {
-		return 0, err
+// detectBinaryFormat determines the binary format the registered parsers are the most
+// confident the file has, with the name and content type of the upload as hints
+func detectBinaryFormat(fileStream io.ReadSeeker, fileName, contentType string) (TextFormat, bool, error) {
+	content, err := readContent(fileStream)
+	if err != nil {
+		return 0, false, err
 	}
+	fileType, ok := sniffFormat(content, true, fileName, contentType)
+	return fileType, ok, nil
+}
 
-	// Read all content to analyze line length
-	reader = bufio.NewReader(fileStream)
-	content, err := io.ReadAll(reader)
+// detectTextFormat determines the text format the registered parsers are the most confident
+// the file has, with the name and content type of the upload as hints. Any text that is not
+// in another format is a paragraph or one phrase per line.
+func detectTextFormat(fileStream io.ReadSeeker, fileName, contentType string) (TextFormat, error) {
+	content, err := readContent(fileStream)
 	if err != nil {
 		return 0, err
 	}
-
 	lines := strings.FieldsFunc(string(content), func(r rune) bool {
 		return r == '\r' || r == '\n'
 	})
-
-	lineCount := len(lines)
-	if lineCount == 0 {
+	if len(lines) == 0 {
 		return 0, errors.New("file is empty")
 	}
+	fileType, _ := sniffFormat(content, false, fileName, contentType)
+	return fileType, nil
+}
 
-	// Calculate average line length
-	totalLength := 0
-	for _, line := range lines {
-		totalLength += len(line)
+// readContent reads the whole file and seeks back to its beginning for the parser
+func readContent(fileStream io.ReadSeeker) ([]byte, error) {
+	if fileStream == nil {
+		return nil, errors.New("fileStream is nil")
+	}
+	if _, err := fileStream.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	content, err := io.ReadAll(fileStream)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fileStream.Seek(0, io.SeekStart); err != nil {
+		return nil, err
 	}
-	averageLineLength := float64(totalLength) / float64(lineCount)
+	return content, nil
+}
 
-	// Heuristics for determining format
-	if lineCount > 3 && averageLineLength < 80 {
-		// Seek back to the beginning of the file
-		if _, err := fileStream.Seek(0, io.SeekStart); err != nil {
-			return 0, err
+// sniffParagraph is the confidence that the text is paragraphs, which is any text but is
+// more likely when its lines are prose
+func sniffParagraph(content []byte) int {
+	if isProse(contentLines(content)) {
+		return proseConfidence
+	}
+	return fallbackConfidence
+}
+
+// sniffOnePhrasePerLine is the confidence that the text is one phrase per line, which it can
+// be when its lines are short
+func sniffOnePhrasePerLine(content []byte) int {
+	if isOnePhrasePerLine(contentLines(content)) {
+		return shortLineConfidence
+	}
+	return 0
+}
+
+// contentLines returns the lines of the content that are not empty
+func contentLines(content []byte) []string {
+	var lines []string
+	for _, line := range strings.FieldsFunc(string(content), func(r rune) bool { return r == '\r' || r == '\n' }) {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
 		}
-		return OnePhrasePerLine, nil
 	}
+	return lines
+}
 
-	// Reset file position
-	if _, err := fileStream.Seek(0, io.SeekStart); err != nil {
-		return 0, err
+// isProse checks if most of the lines have more than one sentence or are wrapped in the
+// middle of a sentence that the next line continues, which short paragraphs have but a list
+// of phrases does not
+func isProse(lines []string) bool {
+	prose := 0
+	for i, line := range lines {
+		words := strings.Fields(line)
+		switch {
+		case len(splitOnEndingPunctuation(line, segmenter{})) > 1:
+			prose++
+		case i+1 < len(lines) && wordEnding(words[len(words)-1]) == notPunctuation && startsLowercase(lines[i+1]):
+			prose++
+		}
 	}
+	return prose*2 > len(lines)
+}
 
-	return Paragraph, nil
+// isOnePhrasePerLine checks if there are more than 3 lines and they are short enough on
+// average to be one phrase per line
+func isOnePhrasePerLine(lines []string) bool {
+	if len(lines) <= 3 {
+		return false
+	}
+	// Calculate average line length
+	totalLength := 0
+	for _, line := range lines {
+		totalLength += len(line)
+	}
+	return float64(totalLength)/float64(len(lines)) < 80
 }
 
 // srtTimestampRegex is a compiled regular expression for detecting SRT timestamps
@@ -91,3 +146,23 @@ var srtTimestampRegex = regexp.MustCompile(`\d{2}:\d{2}:\d{2},\d{3}\s-->\s\d{2}:
 func srtFormatCheck(line string) bool {
 	return srtTimestampRegex.MatchString(line)
 }
+
+// vttTimestampRegex is a compiled regular expression for detecting WebVTT timestamps where
+// the hours are optional and milliseconds are separated by a period
+var vttTimestampRegex = regexp.MustCompile(`(\d{2,}:)?\d{2}:\d{2}\.\d{3}\s+-->\s+(\d{2,}:)?\d{2}:\d{2}\.\d{3}`)
+
+// vttFormatCheck checks if a line matches the WebVTT timestamp format (00:00.000 --> 00:00:00.000)
+func vttFormatCheck(line string) bool {
+	return vttTimestampRegex.MatchString(line)
+}
+
+// assFormatCheck checks if a line is one of the section headers ([Script Info], [V4+ Styles],
+// [Events]) or the Dialogue lines of an ASS/SSA file
+func assFormatCheck(line string) bool {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+	switch strings.ToLower(line) {
+	case "[script info]", "[v4+ styles]", "[v4 styles]", "[events]":
+		return true
+	}
+	return strings.HasPrefix(line, "Dialogue:") && strings.Count(line, ",") >= 9
+}
@@ -1,10 +1,14 @@
 package audiofile
 
 import (
+	"archive/zip"
 	"bytes"
 	"errors"
 	"io"
+	"mime/multipart"
+	"os"
 	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
 	"talkliketv.com/tltv/internal/util"
 	"testing"
 
@@ -12,7 +16,7 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestDetectTextFormat(t *testing.T) {
+func TestDetectFormat(t *testing.T) {
 	if util.Test != "unit" && !testing.Short() {
 		t.Skip("skipping unit test")
 	}
@@ -29,13 +33,118 @@ This is the second subtitle line.
 00:00:10,800 --> 00:00:14,000
 This is the third subtitle line.`
 
-		reader := strings.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		format, err := detectFormat(reader)
 
 		assert.NoError(t, err)
 		assert.Equal(t, Srt, format)
 	})
 
+	t.Run("detect WebVTT format", func(t *testing.T) {
+		content := `WEBVTT - This file has cues
+
+00:01.000 --> 00:04.000 align:start position:10%
+This is the first subtitle line.
+
+00:05.000 --> 00:08.000
+This is the second subtitle line.`
+
+		reader := textFile(content)
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, WebVTT, format)
+	})
+
+	t.Run("detect WebVTT format without header", func(t *testing.T) {
+		content := `1
+00:00:01.000 --> 00:00:04.000
+This is the first subtitle line.`
+
+		reader := textFile(content)
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, WebVTT, format)
+	})
+
+	t.Run("detect ASS format", func(t *testing.T) {
+		content := `[Script Info]
+ScriptType: v4.00+
+
+[Events]
+Format: Layer, Start, End, Style, Name, MarginL, MarginR, MarginV, Effect, Text
+Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,{\an8}This is the first subtitle line.`
+
+		reader := textFile(content)
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Ass, format)
+	})
+
+	t.Run("detect TTML format", func(t *testing.T) {
+		reader := textFile(ttmlSample)
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Ttml, format)
+	})
+
+	t.Run("detect Lrc format", func(t *testing.T) {
+		reader := textFile("[ti:Song]\n[ar:Singer]\n[00:12.34]This is the first line.\n[00:15.00]This is the second line.\n")
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Lrc, format)
+	})
+
+	t.Run("detect Kindle format", func(t *testing.T) {
+		reader := textFile("Book Title (Author)\r\n- Your Highlight on page 1 | Location 10-11 | Added on Monday, March 4, 2024 10:15:32 PM\r\n\r\nThis is the first highlight.\r\n==========\r\n")
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Kindle, format)
+	})
+
+	t.Run("detect Markdown format", func(t *testing.T) {
+		reader := textFile("# Greetings\n\n- This is line one.\n- This is line two.\n\n## Goodbyes\n\n- This is line three.\n- This is line four.\n")
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Markdown, format)
+	})
+
+	t.Run("detect YouTube transcript formats", func(t *testing.T) {
+		transcripts := map[TextFormat]string{
+			Json3:          `{"wireMagic":"pb3","events":[{"tStartMs":160,"dDurationMs":2000,"segs":[{"utf8":"This is the first line."}]}]}`,
+			Srv3:           `<?xml version="1.0" encoding="utf-8" ?><timedtext format="3"><body><p t="160" d="2000">This is the first line.</p></body></timedtext>`,
+			JsonTranscript: `[{"text": "This is the first line.", "start": 0.16, "duration": 2.0}]`,
+		}
+		for expected, transcript := range transcripts {
+			format, err := detectFormat(textFile(transcript))
+
+			assert.NoError(t, err)
+			assert.Equal(t, expected, format)
+		}
+	})
+
+	t.Run("detect Html format", func(t *testing.T) {
+		reader := textFile("<!DOCTYPE html>\n<html>\n<body>\n<p>This is line one.</p>\n<p>This is line two.</p>\n<p>This is line three.</p>\n<p>This is line four.</p>\n</body>\n</html>\n")
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Html, format)
+	})
+
+	t.Run("detect Bilingual format", func(t *testing.T) {
+		reader := textFile("english,spanish\n\"Yes, I do.\",\"Sí, lo hago.\"\nGood night.,Buenas noches.\n")
+		format, err := detectFormat(reader)
+
+		assert.NoError(t, err)
+		assert.Equal(t, Bilingual, format)
+	})
+
 	t.Run("detect OnePhrasePerLine format", func(t *testing.T) {
 		content := `This is line one.
 This is line two.
@@ -43,8 +152,8 @@ This is line three.
 This is line four.
 This is line five.`
 
-		reader := strings.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		format, err := detectFormat(reader)
 
 		assert.NoError(t, err)
 		assert.Equal(t, OnePhrasePerLine, format)
@@ -55,8 +164,8 @@ This is line five.`
 
 This is another paragraph with multiple sentences. It's also quite long to ensure that the average line length will exceed our threshold. The detector should identify this as a paragraph format rather than one phrase per line.`
 
-		reader := strings.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		format, err := detectFormat(reader)
 
 		assert.NoError(t, err)
 		assert.Equal(t, Paragraph, format)
@@ -64,15 +173,15 @@ This is another paragraph with multiple sentences. It's also quite long to ensur
 
 	t.Run("empty file", func(t *testing.T) {
 		content := ``
-		reader := strings.NewReader(content)
-		_, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		_, err := detectFormat(reader)
 
 		assert.Error(t, err)
 		assert.Equal(t, "file is empty", err.Error())
 	})
 
 	t.Run("nil reader", func(t *testing.T) {
-		_, err := DetectTextFormat(nil)
+		_, err := detectFormat(nil)
 
 		assert.Error(t, err)
 		assert.Equal(t, "fileStream is nil", err.Error())
@@ -83,7 +192,7 @@ This is another paragraph with multiple sentences. It's also quite long to ensur
 			readErr: errors.New("mock read error"),
 		}
 
-		_, err := DetectTextFormat(mockReader)
+		_, err := detectFormat(mockReader)
 
 		assert.Error(t, err)
 		assert.Equal(t, "mock read error", err.Error())
@@ -97,7 +206,7 @@ This is another paragraph with multiple sentences. It's also quite long to ensur
 			seekErr:        errors.New("mock seek error"),
 		}
 
-		_, err := DetectTextFormat(mockReader)
+		_, err := detectFormat(mockReader)
 
 		assert.Error(t, err)
 		assert.Equal(t, "mock seek error", err.Error())
@@ -112,7 +221,7 @@ This is another paragraph with multiple sentences. It's also quite long to ensur
 			seekErr:        errors.New("mock seek error"),
 		}
 
-		_, err := DetectTextFormat(reader)
+		_, err := detectFormat(reader)
 
 		assert.Error(t, err)
 		assert.Equal(t, "mock seek error", err.Error())
@@ -124,8 +233,8 @@ This is another paragraph with multiple sentences. It's also quite long to ensur
 This is another normal line.
 This is a third normal line.`
 
-		reader := strings.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		format, err := detectFormat(reader)
 
 		assert.NoError(t, err)
 		assert.Equal(t, Srt, format, "Should detect as SRT when timestamp is found")
@@ -139,8 +248,8 @@ This is a third normal line.`
 		}
 		content := strings.Join(lines, "\n")
 
-		reader := strings.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		format, err := detectFormat(reader)
 
 		assert.NoError(t, err)
 		assert.Equal(t, OnePhrasePerLine, format, "Should detect as OnePhrasePerLine when many short lines")
@@ -152,8 +261,8 @@ This is a third normal line.`
 		lines := []string{longLine, longLine, longLine}
 		content := strings.Join(lines, "\n")
 
-		reader := strings.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := textFile(content)
+		format, err := detectFormat(reader)
 
 		assert.NoError(t, err)
 		assert.Equal(t, Paragraph, format, "Should detect as Paragraph when few long lines")
@@ -219,6 +328,105 @@ func TestSrtFormatCheck(t *testing.T) {
 	}
 }
 
+func TestVttFormatCheck(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		line     string
+		expected bool
+	}{
+		{
+			name:     "valid VTT timestamp",
+			line:     "00:01:23.456 --> 00:04:56.789",
+			expected: true,
+		},
+		{
+			name:     "valid VTT timestamp without hours",
+			line:     "01:23.456 --> 04:56.789",
+			expected: true,
+		},
+		{
+			name:     "valid VTT timestamp with cue settings",
+			line:     "00:01:23.456 --> 00:04:56.789 line:0 position:20% align:start",
+			expected: true,
+		},
+		{
+			name:     "SRT timestamp",
+			line:     "00:01:23,456 --> 00:04:56,789",
+			expected: false,
+		},
+		{
+			name:     "completely different text",
+			line:     "This is just some random text",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := vttFormatCheck(tt.line)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestAssFormatCheck(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		line     string
+		expected bool
+	}{
+		{
+			name:     "script info section",
+			line:     "[Script Info]",
+			expected: true,
+		},
+		{
+			name:     "events section",
+			line:     "[Events]",
+			expected: true,
+		},
+		{
+			name:     "dialogue line",
+			line:     "Dialogue: 0,0:00:01.00,0:00:04.00,Default,,0,0,0,,Hello there",
+			expected: true,
+		},
+		{
+			name:     "dialogue in a script",
+			line:     "Dialogue: the conversation between two characters",
+			expected: false,
+		},
+		{
+			name:     "bracketed description",
+			line:     "[Music playing]",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := assFormatCheck(tt.line)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+// detectFormat detects the format of the file without a name or content type as hints
+func detectFormat(f multipart.File) (TextFormat, error) {
+	format, _, _, err := DetectFormat(f, interfaces.ParseOptions{})
+	return format, err
+}
+
+// textFile is an uploaded file with the content
+func textFile(content string) transcodedFile {
+	return transcodedFile{bytes.NewReader([]byte(content))}
+}
+
 // A mock reader/seeker for testing error cases
 type mockReadSeeker struct {
 	content        []byte
@@ -243,6 +451,17 @@ func (m *mockReadSeeker) Read(p []byte) (int, error) {
 	return n, nil
 }
 
+func (m *mockReadSeeker) ReadAt(p []byte, off int64) (int, error) {
+	if m.readErr != nil {
+		return 0, m.readErr
+	}
+	return bytes.NewReader(m.content).ReadAt(p, off)
+}
+
+func (m *mockReadSeeker) Close() error {
+	return nil
+}
+
 func (m *mockReadSeeker) Seek(offset int64, whence int) (int64, error) {
 	m.seekCount++
 
@@ -283,6 +502,19 @@ This is subtitle one.
 This is subtitle two.`,
 			expected: Srt,
 		},
+		{
+			name: "typical WebVTT file",
+			content: `WEBVTT
+
+1
+00:00:01.000 --> 00:00:04.000
+This is subtitle one.
+
+2
+00:00:05.000 --> 00:00:09.000
+This is subtitle two.`,
+			expected: WebVTT,
+		},
 		{
 			name: "script with short lines",
 			content: `ALICE: Hello Bob.
@@ -303,8 +535,8 @@ We had everything before us, we had nothing before us, we were all going direct
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
-			reader := strings.NewReader(tc.content)
-			format, err := DetectTextFormat(reader)
+			reader := textFile(tc.content)
+			format, err := detectFormat(reader)
 
 			require.NoError(t, err)
 			assert.Equal(t, tc.expected, format)
@@ -330,10 +562,64 @@ func TestBufferHandling(t *testing.T) {
 		// Create a file that's exactly 4096 bytes (common buffer size)
 		content := bytes.Repeat([]byte("Line of text.\n"), 341)
 
-		reader := bytes.NewReader(content)
-		format, err := DetectTextFormat(reader)
+		reader := transcodedFile{bytes.NewReader(content)}
+		format, err := detectFormat(reader)
 
 		require.NoError(t, err)
 		assert.Equal(t, OnePhrasePerLine, format)
 	})
 }
+
+func TestDetectFormatBinary(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	apkg, err := os.ReadFile("testdata/legacy.apkg")
+	require.NoError(t, err)
+	epub, err := os.ReadFile("testdata/book.epub")
+	require.NoError(t, err)
+	docx, err := os.ReadFile("testdata/lesson.docx")
+	require.NoError(t, err)
+	odt, err := os.ReadFile("testdata/vocabulary.odt")
+	require.NoError(t, err)
+	pdf, err := os.ReadFile("testdata/worksheet.pdf")
+	require.NoError(t, err)
+	var plainZip bytes.Buffer
+	w := zip.NewWriter(&plainZip)
+	_, err = w.Create("notes.txt")
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	tests := []struct {
+		name     string
+		content  []byte
+		format   TextFormat
+		isBinary bool
+	}{
+		{name: "anki package", content: apkg, format: Anki, isBinary: true},
+		{name: "epub", content: epub, format: Epub, isBinary: true},
+		{name: "docx", content: docx, format: Docx, isBinary: true},
+		{name: "odt", content: odt, format: Odt, isBinary: true},
+		{name: "pdf", content: pdf, format: Pdf, isBinary: true},
+		{name: "zip without a collection", content: plainZip.Bytes(), isBinary: false},
+		{name: "text", content: []byte("This is the first sentence.\n"), isBinary: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := transcodedFile{bytes.NewReader(tt.content)}
+			format, f, _, err := DetectFormat(reader, interfaces.ParseOptions{})
+			require.NoError(t, err)
+			if tt.isBinary {
+				require.Equal(t, tt.format, format)
+			}
+			// binary files are parsed as they are and text files once they are decoded
+			require.Equal(t, tt.isBinary, f == multipart.File(reader))
+			// the file is at the start again for the parser
+			pos, err := f.Seek(0, io.SeekCurrent)
+			require.NoError(t, err)
+			require.Zero(t, pos)
+		})
+	}
+}
@@ -0,0 +1,264 @@
+package audiofile
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"time"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "ttml",
+		format:     Ttml,
+		mimeTypes:  []string{"application/ttml+xml"},
+		extensions: []string{".ttml", ".dfxp", ".xml"},
+		sniff:      sniffIf(isTtml, certainConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseTtml(f, seg), nil
+		}),
+	})
+}
+
+// ttmlNamespaces are the namespaces of the TTML root <tt> element, including the older
+// DFXP (ttaf1) drafts that are still used by broadcasters
+var ttmlNamespaces = []string{
+	"http://www.w3.org/ns/ttml",
+	"http://www.w3.org/2006/10/ttaf1",
+	"http://www.w3.org/2006/04/ttaf1",
+	"http://www.w3.org/2006/02/ttaf1",
+}
+
+var (
+	// ttmlClockTimeRegex matches hh:mm:ss, hh:mm:ss.fraction and hh:mm:ss:frames(.subframes)
+	ttmlClockTimeRegex = regexp.MustCompile(`^(\d{2,}):(\d{2}):(\d{2})(?:(\.\d+)|:(\d{2,})(?:\.(\d+))?)?$`)
+	// ttmlOffsetTimeRegex matches an offset time like 1.5s, 100ms, 25f or 1234567t
+	ttmlOffsetTimeRegex = regexp.MustCompile(`^(\d+(?:\.\d+)?)(h|m|s|ms|f|t)$`)
+)
+
+// ttmlTiming holds the ttp parameters of the root element that are needed to resolve
+// frame and tick based times
+type ttmlTiming struct {
+	frameRate    float64
+	subFrameRate float64
+	tickRate     float64
+}
+
+// parseTtml takes a TTML or DFXP file and parses the text of each <p> element into phrases
+// in the order the cues begin
+func parseTtml(f io.Reader, seg segmenter) []cue {
+	cues, err := ttmlCues(f)
+	if err != nil && len(cues) == 0 {
+		return nil
+	}
+
+	var phrases []cue
+	for _, c := range cues {
+		c.text = replaceFmt(c.text, seg.cleanup)
+		phrases = append(phrases, cuePhrases(c, seg)...)
+	}
+
+	return phrases
+}
+
+// ttmlCues walks the XML and returns a cue for every <p> element. <br/> elements are
+// replaced with a space and the text of nested <span> elements is kept. The begin and end
+// of a <p> are offset by the begin of the <body> and <div> elements that contain it.
+func ttmlCues(f io.Reader) ([]cue, error) {
+	var cues []cue
+	timing := ttmlTiming{frameRate: 30, subFrameRate: 1, tickRate: 1}
+	// offsets is a stack of the begin time of every open element
+	var offsets []time.Duration
+	var text strings.Builder
+	var current *cue
+	inParagraph := 0
+
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	decoder.CharsetReader = utf8CharsetReader
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return cues, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			parent := time.Duration(0)
+			if len(offsets) > 0 {
+				parent = offsets[len(offsets)-1]
+			}
+			if t.Name.Local == "tt" && len(offsets) == 0 {
+				timing = newTtmlTiming(t.Attr)
+			}
+			begin := parent
+			if value := ttmlAttr(t.Attr, "begin"); value != "" {
+				if d, err := timing.parse(value); err == nil {
+					begin += d
+				}
+			}
+			offsets = append(offsets, begin)
+
+			switch {
+			case t.Name.Local == "p" && inParagraph == 0:
+				inParagraph = 1
+				text.Reset()
+				current = &cue{start: begin, end: timing.end(t.Attr, parent, begin)}
+			case inParagraph > 0:
+				inParagraph++
+				if t.Name.Local == "br" {
+					text.WriteString(" ")
+				}
+			}
+		case xml.EndElement:
+			if len(offsets) > 0 {
+				offsets = offsets[:len(offsets)-1]
+			}
+			if inParagraph == 0 {
+				continue
+			}
+			inParagraph--
+			if inParagraph == 0 && current != nil {
+				current.text = strings.Join(strings.Fields(text.String()), " ")
+				if current.text != "" {
+					cues = append(cues, *current)
+				}
+				current = nil
+			}
+		case xml.CharData:
+			if inParagraph > 0 {
+				text.Write(t)
+			}
+		}
+	}
+
+	slices.SortStableFunc(cues, func(a, b cue) int {
+		return cmp.Compare(a.start, b.start)
+	})
+
+	return cues, nil
+}
+
+// newTtmlTiming reads the ttp:frameRate, ttp:frameRateMultiplier, ttp:subFrameRate and
+// ttp:tickRate attributes of the root element
+func newTtmlTiming(attrs []xml.Attr) ttmlTiming {
+	timing := ttmlTiming{frameRate: 30, subFrameRate: 1, tickRate: 1}
+	if v, err := strconv.ParseFloat(ttmlAttr(attrs, "frameRate"), 64); err == nil && v > 0 {
+		timing.frameRate = v
+	}
+	if numerator, denominator, ok := strings.Cut(ttmlAttr(attrs, "frameRateMultiplier"), " "); ok {
+		n, nErr := strconv.ParseFloat(numerator, 64)
+		d, dErr := strconv.ParseFloat(strings.TrimSpace(denominator), 64)
+		if nErr == nil && dErr == nil && n > 0 && d > 0 {
+			timing.frameRate = timing.frameRate * n / d
+		}
+	}
+	if v, err := strconv.ParseFloat(ttmlAttr(attrs, "subFrameRate"), 64); err == nil && v > 0 {
+		timing.subFrameRate = v
+	}
+	if v, err := strconv.ParseFloat(ttmlAttr(attrs, "tickRate"), 64); err == nil && v > 0 {
+		timing.tickRate = v
+	} else if ttmlAttr(attrs, "frameRate") != "" {
+		// when only the frame rate is given the tick rate is the frame rate times the sub frame rate
+		timing.tickRate = timing.frameRate * timing.subFrameRate
+	}
+
+	return timing
+}
+
+// end returns the end time of an element from its end or dur attribute
+func (tt ttmlTiming) end(attrs []xml.Attr, parent, begin time.Duration) time.Duration {
+	if d, err := tt.parse(ttmlAttr(attrs, "end")); err == nil {
+		return parent + d
+	}
+	if d, err := tt.parse(ttmlAttr(attrs, "dur")); err == nil {
+		return begin + d
+	}
+	return begin
+}
+
+// parse converts a TTML clock time (00:00:01.500, 00:00:01:12) or offset time (1.5s, 100ms,
+// 36f, 15000000t) to a time.Duration
+func (tt ttmlTiming) parse(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if m := ttmlClockTimeRegex.FindStringSubmatch(value); m != nil {
+		hours, _ := strconv.Atoi(m[1])
+		minutes, _ := strconv.Atoi(m[2])
+		seconds, _ := strconv.ParseFloat(m[3]+m[4], 64)
+		total := float64(hours*3600+minutes*60) + seconds
+		if m[5] != "" {
+			frames, _ := strconv.ParseFloat(m[5], 64)
+			if m[6] != "" {
+				subFrames, _ := strconv.ParseFloat(m[6], 64)
+				frames += subFrames / tt.subFrameRate
+			}
+			total += frames / tt.frameRate
+		}
+		return time.Duration(total * float64(time.Second)), nil
+	}
+	if m := ttmlOffsetTimeRegex.FindStringSubmatch(value); m != nil {
+		count, _ := strconv.ParseFloat(m[1], 64)
+		var seconds float64
+		switch m[2] {
+		case "h":
+			seconds = count * 3600
+		case "m":
+			seconds = count * 60
+		case "s":
+			seconds = count
+		case "ms":
+			seconds = count / 1000
+		case "f":
+			seconds = count / tt.frameRate
+		case "t":
+			seconds = count / tt.tickRate
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	return 0, errors.New("invalid ttml time expression: " + value)
+}
+
+// ttmlAttr returns the value of the attribute with the local name or an empty string
+func ttmlAttr(attrs []xml.Attr, name string) string {
+	for _, attr := range attrs {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// utf8CharsetReader ignores the encoding declared in the XML prolog because uploaded files
+// are decoded to UTF-8 before they are parsed
+func utf8CharsetReader(_ string, input io.Reader) (io.Reader, error) {
+	return input, nil
+}
+
+// isTtml checks if the root element of the content is a TTML <tt> element
+func isTtml(content []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.CharsetReader = utf8CharsetReader
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "tt" &&
+				(start.Name.Space == "" || slices.Contains(ttmlNamespaces, start.Name.Space))
+		}
+	}
+}
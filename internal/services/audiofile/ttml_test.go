@@ -0,0 +1,160 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ttmlSample = `<?xml version="1.0" encoding="UTF-8"?>
+<tt xmlns="http://www.w3.org/ns/ttml" xmlns:tts="http://www.w3.org/ns/ttml#styling"
+    xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:tickRate="10000000" xml:lang="en">
+  <head>
+    <styling>
+      <style xml:id="s1" tts:color="white"/>
+    </styling>
+  </head>
+  <body>
+    <div>
+      <p begin="50000000t" end="90000000t">The second cue is in the<br/>file first.</p>
+      <p begin="10000000t" end="40000000t" style="s1">
+        Hello world, <span tts:fontStyle="italic">this is</span> a test subtitle.
+      </p>
+      <p begin="100000000t" end="110000000t"></p>
+    </div>
+  </body>
+</tt>`
+
+// TestParseTtml tests the parseTtml function
+func TestParseTtml(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name:     "Tick based TTML",
+			content:  ttmlSample,
+			expected: []string{"Hello world, this is a test subtitle.", "The second cue is in the file first."},
+		},
+		{
+			name: "DFXP with clock times and entities",
+			content: `<?xml version="1.0" encoding="utf-8"?>
+<tt xmlns="http://www.w3.org/2006/10/ttaf1" xml:lang="es">
+  <body>
+    <div>
+      <p begin="00:00:01.00" end="00:00:04.00">¿Dónde está la estación &amp; el tren?</p>
+      <p begin="00:00:05.00" dur="3s">[Music] Vamos a la playa mañana.</p>
+    </div>
+  </body>
+</tt>`,
+			expected: []string{"¿Dónde está la estación & el tren?", "Vamos a la playa mañana."},
+		},
+		{
+			name:     "Not XML",
+			content:  "This is not a ttml file.",
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseTtml(strings.NewReader(tt.content), segmenter{})
+
+			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
+			for i, phrase := range tt.expected {
+				if i < len(result) {
+					assert.Equal(t, phrase, strings.TrimSpace(result[i].text), "Result should match expected phrase")
+				}
+			}
+		})
+	}
+}
+
+// TestTtmlCues tests that the begin and end of each cue are resolved
+func TestTtmlCues(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	content := `<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttp="http://www.w3.org/ns/ttml#parameter" ttp:frameRate="25">
+  <body begin="00:00:10.000">
+    <div begin="2s">
+      <p begin="00:00:01:05" end="00:00:03:00">Offset by the body and the div.</p>
+      <p begin="500ms" dur="1.5s">Duration instead of an end.</p>
+    </div>
+  </body>
+</tt>`
+
+	cues, err := ttmlCues(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Len(t, cues, 2)
+
+	assert.Equal(t, 12*time.Second+500*time.Millisecond, cues[0].start)
+	assert.Equal(t, 14*time.Second, cues[0].end)
+	assert.Equal(t, "Duration instead of an end.", cues[0].text)
+
+	assert.Equal(t, 13*time.Second+200*time.Millisecond, cues[1].start)
+	assert.Equal(t, 15*time.Second, cues[1].end)
+	assert.Equal(t, "Offset by the body and the div.", cues[1].text)
+}
+
+// TestTtmlCuesDeclaredEncoding tests that the encoding in the XML prolog is ignored because
+// the file has already been decoded to UTF-8
+func TestTtmlCuesDeclaredEncoding(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	content := `<?xml version="1.0" encoding="Shift_JIS"?>
+<tt xmlns="http://www.w3.org/ns/ttml">
+  <body><div><p begin="1s" end="2s">今日はいい天気ですね。</p></div></body>
+</tt>`
+
+	assert.True(t, isTtml([]byte(content)))
+	cues, err := ttmlCues(strings.NewReader(content))
+	require.NoError(t, err)
+	require.Len(t, cues, 1)
+	assert.Equal(t, "今日はいい天気ですね。", cues[0].text)
+}
+
+// TestTtmlTimingParse tests the clock time and offset time expressions
+func TestTtmlTimingParse(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	timing := ttmlTiming{frameRate: 25, subFrameRate: 2, tickRate: 10000000}
+	tests := []struct {
+		value    string
+		expected time.Duration
+		hasError bool
+	}{
+		{value: "00:00:01.500", expected: 1500 * time.Millisecond},
+		{value: "01:02:03", expected: time.Hour + 2*time.Minute + 3*time.Second},
+		{value: "00:00:01:05", expected: 1200 * time.Millisecond},
+		{value: "00:00:00:01.1", expected: 60 * time.Millisecond},
+		{value: "2.5s", expected: 2500 * time.Millisecond},
+		{value: "250ms", expected: 250 * time.Millisecond},
+		{value: "1m", expected: time.Minute},
+		{value: "50f", expected: 2 * time.Second},
+		{value: "15000000t", expected: 1500 * time.Millisecond},
+		{value: "tomorrow", hasError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			result, err := timing.parse(tt.value)
+			if tt.hasError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package audiofile
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"mime/multipart"
+	"regexp"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "vtt",
+		format:     WebVTT,
+		mimeTypes:  []string{"text/vtt"},
+		extensions: []string{".vtt"},
+		sniff:      sniffWebVTT,
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseWebVTT(f, seg), nil
+		}),
+	})
+}
+
+// vttRubyTextRegex matches ruby annotations <rt>...</rt> which are pronunciation
+// hints and should not be part of the phrase
+var vttRubyTextRegex = regexp.MustCompile(`(?s)<rt>.*?</rt>`)
+
+// vttTagRegex matches WebVTT cue spans like <v Speaker>, <c.yellow>, <i>, <lang en>
+// and inline timestamps like <00:00:01.000>
+var vttTagRegex = regexp.MustCompile(`<[^>]*>`)
+
+// parseWebVTT takes a WebVTT file and parses the cue text into phrases with the time of
+// the cue. The header, NOTE, STYLE and REGION blocks, cue identifiers and the cue settings
+// after the timing are skipped
+func parseWebVTT(f io.Reader, seg segmenter) []cue {
+	var phrases []cue
+	for _, block := range vttBlocks(f) {
+		c, ok := vttCue(block)
+		if !ok {
+			continue
+		}
+		c.text = replaceFmt(c.text, seg.cleanup)
+
+		phrases = append(phrases, cuePhrases(c, seg)...)
+	}
+
+	return phrases
+}
+
+// vttBlocks splits a WebVTT file into blocks of lines separated by blank lines
+func vttBlocks(f io.Reader) [][]string {
+	var blocks [][]string
+	var block []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		line = strings.TrimPrefix(line, "\ufeff")
+		if strings.TrimSpace(line) == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, block)
+				block = nil
+			}
+			continue
+		}
+		block = append(block, line)
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, block)
+	}
+
+	return blocks
+}
+
+// vttCue returns the timing and cleaned payload of a cue block and false if the block is
+// not a cue (header, comment, style or region definition)
+func vttCue(block []string) (cue, bool) {
+	first := strings.TrimSpace(block[0])
+	if isVttHeader(first) ||
+		vttBlockIs(first, "NOTE") ||
+		vttBlockIs(first, "STYLE") ||
+		vttBlockIs(first, "REGION") {
+		return cue{}, false
+	}
+
+	// the cue identifier is optional so look for the timing line
+	timing := -1
+	for i, line := range block {
+		if strings.Contains(line, "-->") {
+			timing = i
+			break
+		}
+	}
+	if timing == -1 || timing == len(block)-1 {
+		return cue{}, false
+	}
+	start, end, _ := parseTimingLine(block[timing])
+
+	text := strings.Join(block[timing+1:], " ")
+	text = vttRubyTextRegex.ReplaceAllString(text, "")
+	text = vttTagRegex.ReplaceAllString(text, "")
+	text = html.UnescapeString(text)
+	// remove the directional marks that &lrm; and &rlm; are decoded to
+	text = strings.NewReplacer("\u200e", "", "\u200f", "", "\u00a0", " ").Replace(text)
+
+	return cue{start: start, end: end, text: text}, true
+}
+
+// isVttHeader checks if a line is the WEBVTT file header
+func isVttHeader(line string) bool {
+	line = strings.TrimPrefix(line, "\ufeff")
+	return line == "WEBVTT" || strings.HasPrefix(line, "WEBVTT ") || strings.HasPrefix(line, "WEBVTT\t")
+}
+
+// sniffWebVTT is certain that the content is WebVTT when it starts with the WEBVTT header
+// and likely when a line at the start of it is a WebVTT timing
+func sniffWebVTT(content []byte) int {
+	first, _, _ := strings.Cut(string(content), "\n")
+	if isVttHeader(strings.TrimSpace(first)) {
+		return certainConfidence
+	}
+	return sniffFirstLines(vttFormatCheck, likelyConfidence)(content)
+}
+
+// vttBlockIs checks if the first line of a block starts with the given keyword followed
+// by whitespace or the end of the line
+func vttBlockIs(line, keyword string) bool {
+	if !strings.HasPrefix(line, keyword) {
+		return false
+	}
+	rest := line[len(keyword):]
+	return rest == "" || rest[0] == ' ' || rest[0] == '\t'
+}
@@ -0,0 +1,96 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestParseWebVTT tests the parseWebVTT function
+func TestParseWebVTT(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		content  string
+		expected []string
+	}{
+		{
+			name: "Basic WebVTT file",
+			content: `WEBVTT
+
+00:00:01.000 --> 00:00:05.000
+Hello world this is a test subtitle.
+
+00:00:06.000 --> 00:00:10.000
+Another subtitle line for testing.`,
+			expected: []string{"Hello world this is a test subtitle.", "Another subtitle line for testing."},
+		},
+		{
+			name: "Header metadata, notes, styles and regions",
+			content: "\ufeffWEBVTT - Episode 3\r\nKind: captions\r\nLanguage: en\r\n\r\n" +
+				"STYLE\r\n::cue { color: yellow; }\r\n\r\n" +
+				"REGION\r\nid:fred width:40%\r\n\r\n" +
+				"NOTE This is a comment that\r\nspans two lines\r\n\r\n" +
+				"00:00:01.000 --> 00:00:05.000 region:fred align:left\r\n" +
+				"This cue comes after the notes.\r\n",
+			expected: []string{"This cue comes after the notes."},
+		},
+		{
+			name: "Cue identifiers and multi line cues",
+			content: `WEBVTT
+
+intro
+00:01.000 --> 00:04.000 position:10%,line-left align:left size:35%
+Where did he go
+before the party started?
+
+2
+00:05.000 --> 00:09.000
+I think he went down this lane.`,
+			expected: []string{"Where did he go before the party started?", "I think he went down this lane."},
+		},
+		{
+			name: "Voice and styling spans",
+			content: `WEBVTT
+
+00:00:01.000 --> 00:00:05.000
+<v Roger Bingham>We are in New York City</v>
+
+00:00:06.000 --> 00:00:10.000
+<v.loud Neil>And this is <c.yellow><i>really</i></c> &amp; truly <00:00:08.000>important</v>
+
+00:00:11.000 --> 00:00:15.000
+<ruby>東京<rt>とうきょう</rt></ruby> is a city we really love`,
+			expected: []string{"We are in New York City", "And this is really & truly important", "東京 is a city we really love"},
+		},
+		{
+			name: "Blocks without timing are skipped",
+			content: `WEBVTT
+
+this block has no timing line
+
+00:00:06.000 --> 00:00:10.000
+
+00:00:11.000 --> 00:00:15.000
+The only phrase that should remain.`,
+			expected: []string{"The only phrase that should remain."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := parseWebVTT(strings.NewReader(tt.content), segmenter{})
+
+			assert.Equal(t, len(tt.expected), len(result), "Result length should match expected")
+			for i, phrase := range tt.expected {
+				if i < len(result) {
+					assert.Equal(t, phrase, strings.TrimSpace(result[i].text), "Result should match expected phrase")
+				}
+			}
+		})
+	}
+}
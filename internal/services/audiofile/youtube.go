@@ -0,0 +1,292 @@
+package audiofile
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"mime/multipart"
+	"strconv"
+	"strings"
+	"talkliketv.com/tltv/internal/interfaces"
+	"time"
+)
+
+func init() {
+	registerParser(formatParser{
+		name:       "json3",
+		format:     Json3,
+		mimeTypes:  []string{"application/json"},
+		extensions: []string{".json3", ".json"},
+		sniff:      sniffIf(isJson3, certainConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseJson3(f, seg)
+		}),
+	})
+	registerParser(formatParser{
+		name:       "srv3",
+		format:     Srv3,
+		mimeTypes:  []string{"text/xml", "application/xml"},
+		extensions: []string{".srv3", ".xml"},
+		sniff:      sniffIf(isSrv3, certainConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseSrv3(f, seg)
+		}),
+	})
+	registerParser(formatParser{
+		name:       "json",
+		format:     JsonTranscript,
+		mimeTypes:  []string{"application/json"},
+		extensions: []string{".json"},
+		sniff:      sniffIf(isJsonTranscript, strongConfidence),
+		parse: parsedCues(func(f multipart.File, _ interfaces.ParseOptions, seg segmenter) ([]cue, error) {
+			return parseJsonTranscript(f, seg)
+		}),
+	})
+}
+
+// captionPause is the silence between two caption fragments that ends a sentence of auto
+// captions, which have no punctuation
+const captionPause = 1500 * time.Millisecond
+
+// json3Transcript is a YouTube timedtext transcript in the json3 format. Each event is shown
+// from tStartMs for dDurationMs, and the text of an auto caption is split into a segment for
+// each word.
+type json3Transcript struct {
+	Events []struct {
+		TStartMs    int64 `json:"tStartMs"`
+		DDurationMs int64 `json:"dDurationMs"`
+		Segs        []struct {
+			UTF8 string `json:"utf8"`
+		} `json:"segs"`
+	} `json:"events"`
+}
+
+// transcriptLine is a line of the JSON array of the transcripts exported by subtitle
+// extensions and libraries like youtube-transcript-api, whose times are in seconds
+type transcriptLine struct {
+	Start    transcriptSeconds  `json:"start"`
+	Duration *transcriptSeconds `json:"duration"`
+	Dur      *transcriptSeconds `json:"dur"`
+	End      *transcriptSeconds `json:"end"`
+	Text     string             `json:"text"`
+}
+
+// transcriptSeconds is a time in seconds that is written as a number or a string
+type transcriptSeconds time.Duration
+
+// UnmarshalJSON reads a number of seconds like 1.5 or "1.5"
+func (s *transcriptSeconds) UnmarshalJSON(b []byte) error {
+	seconds, err := strconv.ParseFloat(strings.Trim(string(b), `"`), 64)
+	if err != nil {
+		return err
+	}
+	*s = transcriptSeconds(time.Duration(seconds * float64(time.Second)))
+	return nil
+}
+
+// isJson3 checks if the content is a YouTube transcript in the json3 format
+func isJson3(content []byte) bool {
+	content = bytes.TrimSpace(content)
+	if !bytes.HasPrefix(content, []byte("{")) {
+		return false
+	}
+	var transcript json3Transcript
+	if err := json.Unmarshal(content, &transcript); err != nil {
+		return false
+	}
+	for _, event := range transcript.Events {
+		if len(event.Segs) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// isSrv3 checks if the content is a YouTube transcript in the srv3 XML format, whose root
+// element is <timedtext>
+func isSrv3(content []byte) bool {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	decoder.Strict = false
+	decoder.CharsetReader = utf8CharsetReader
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return false
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local == "timedtext"
+		}
+	}
+}
+
+// isJsonTranscript checks if the content is a JSON array of lines with a start and a text
+func isJsonTranscript(content []byte) bool {
+	content = bytes.TrimSpace(content)
+	if !bytes.HasPrefix(content, []byte("[")) {
+		return false
+	}
+	var lines []map[string]json.RawMessage
+	if err := json.Unmarshal(content, &lines); err != nil || len(lines) == 0 {
+		return false
+	}
+	_, hasStart := lines[0]["start"]
+	_, hasText := lines[0]["text"]
+	return hasStart && hasText
+}
+
+// parseJson3 takes a YouTube json3 transcript and returns the phrases of its events with the
+// fragments of auto captions merged into sentences
+func parseJson3(f io.Reader, seg segmenter) ([]cue, error) {
+	var transcript json3Transcript
+	if err := json.NewDecoder(f).Decode(&transcript); err != nil {
+		return nil, err
+	}
+
+	var fragments []cue
+	for _, event := range transcript.Events {
+		var text strings.Builder
+		for _, s := range event.Segs {
+			text.WriteString(s.UTF8)
+		}
+		start := time.Duration(event.TStartMs) * time.Millisecond
+		fragments = append(fragments, cue{
+			start: start,
+			end:   start + time.Duration(event.DDurationMs)*time.Millisecond,
+			text:  text.String(),
+		})
+	}
+	return captionPhrases(fragments, seg), nil
+}
+
+// parseSrv3 takes a YouTube srv3 transcript and returns the phrases of its <p> elements,
+// whose times t and d are in milliseconds, with the fragments of auto captions merged into
+// sentences. The text of the <s> elements of the words of an auto caption is joined.
+func parseSrv3(f io.Reader, seg segmenter) ([]cue, error) {
+	var fragments []cue
+	var text strings.Builder
+	var current *cue
+
+	decoder := xml.NewDecoder(f)
+	decoder.Strict = false
+	decoder.CharsetReader = utf8CharsetReader
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "p":
+				current = &cue{}
+				text.Reset()
+				for _, attr := range t.Attr {
+					ms, err := strconv.ParseInt(attr.Value, 10, 64)
+					if err != nil {
+						continue
+					}
+					switch attr.Name.Local {
+					case "t":
+						current.start = time.Duration(ms) * time.Millisecond
+					case "d":
+						current.end = time.Duration(ms) * time.Millisecond
+					}
+				}
+				current.end += current.start
+			case "br":
+				text.WriteString(" ")
+			}
+		case xml.CharData:
+			if current != nil {
+				text.Write(t)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "p" && current != nil {
+				current.text = text.String()
+				fragments = append(fragments, *current)
+				current = nil
+			}
+		}
+	}
+	return captionPhrases(fragments, seg), nil
+}
+
+// parseJsonTranscript takes a JSON array of lines with a start, a duration, dur or end and a
+// text and returns their phrases with the fragments of auto captions merged into sentences
+func parseJsonTranscript(f io.Reader, seg segmenter) ([]cue, error) {
+	var lines []transcriptLine
+	if err := json.NewDecoder(f).Decode(&lines); err != nil {
+		return nil, err
+	}
+
+	fragments := make([]cue, len(lines))
+	for i, line := range lines {
+		start := time.Duration(line.Start)
+		end := start
+		switch {
+		case line.End != nil:
+			end = time.Duration(*line.End)
+		case line.Duration != nil:
+			end = start + time.Duration(*line.Duration)
+		case line.Dur != nil:
+			end = start + time.Duration(*line.Dur)
+		}
+		fragments[i] = cue{start: start, end: end, text: line.Text}
+	}
+	return captionPhrases(fragments, seg), nil
+}
+
+// captionPhrases cleans the caption fragments and merges them into sentences before they are
+// split into phrases. A fragment is added to the one before it until a fragment ends a
+// sentence, there is a pause of captionPause before the next fragment or the sentence has
+// the most words of a phrase, which is when auto captions without punctuation are ended.
+func captionPhrases(fragments []cue, seg segmenter) []cue {
+	_, maxWords := seg.wordLimits()
+	_, maxChars := seg.characterLimits()
+
+	var phrases []cue
+	var sentence cue
+	var last time.Duration
+	endSentence := func() {
+		if sentence.text != "" {
+			phrases = append(phrases, cuePhrases(sentence, seg)...)
+		}
+		sentence = cue{}
+	}
+	for _, fragment := range fragments {
+		text := replaceFmt(strings.Join(strings.Fields(fragment.text), " "), seg.cleanup)
+		if text == "" {
+			continue
+		}
+		if sentence.text != "" && fragment.start-last > captionPause {
+			endSentence()
+		}
+		last = max(fragment.end, fragment.start)
+
+		if sentence.text == "" {
+			sentence = cue{start: fragment.start, text: text}
+		} else if seg.unspaced(text) {
+			sentence.text += text
+		} else {
+			sentence.text += " " + text
+		}
+		sentence.end = last
+
+		words := strings.Fields(sentence.text)
+		switch {
+		case wordEnding(words[len(words)-1]) == terminalPunctuation:
+			endSentence()
+		case seg.unspaced(sentence.text) && characterCount(sentence.text) >= maxChars:
+			endSentence()
+		case !seg.unspaced(sentence.text) && len(words) >= maxWords:
+			endSentence()
+		}
+	}
+	endSentence()
+	return phrases
+}
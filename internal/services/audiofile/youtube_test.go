@@ -0,0 +1,132 @@
+package audiofile
+
+import (
+	"strings"
+	"talkliketv.com/tltv/internal/util"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseJson3(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	// an auto caption with a segment for each word, a line break event and a second caption
+	// after a pause
+	transcript := `{"wireMagic":"pb3","events":[
+		{"tStartMs":0,"dDurationMs":90000,"id":1,"wpWinPosId":1},
+		{"tStartMs":160,"dDurationMs":2000,"wWinId":1,"segs":[{"utf8":"so","acAsrConf":0},{"utf8":" today","tOffsetMs":400},{"utf8":" we","tOffsetMs":800}]},
+		{"tStartMs":2160,"dDurationMs":10,"aAppend":1,"segs":[{"utf8":"\n"}]},
+		{"tStartMs":2170,"dDurationMs":1800,"wWinId":1,"segs":[{"utf8":"are"},{"utf8":" going"},{"utf8":" to"},{"utf8":" cook."}]},
+		{"tStartMs":7000,"dDurationMs":2000,"wWinId":1,"segs":[{"utf8":"[Music]"}]},
+		{"tStartMs":9000,"dDurationMs":3000,"wWinId":1,"segs":[{"utf8":"First you need some eggs"}]}
+	]}`
+	cues, err := parseJson3(strings.NewReader(transcript), segmenter{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"so today we are going to cook.", "First you need some eggs"}, cueTexts(cues))
+	require.Equal(t, 160*time.Millisecond, cues[0].start)
+	require.Equal(t, 3970*time.Millisecond, cues[0].end)
+	require.Equal(t, 9*time.Second, cues[1].start)
+}
+
+func TestParseSrv3(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	transcript := `<?xml version="1.0" encoding="utf-8" ?><timedtext format="3">
+<head><ws id="0"/></head>
+<body>
+<p t="1000" d="2500" w="1"><s ac="0">where</s><s t="300" ac="0"> is</s><s t="600" ac="0"> the</s></p>
+<p t="3500" d="20" w="1" a="1">
+</p>
+<p t="3520" d="2000" w="1"><s>train station?</s></p>
+<p t="8000" d="2000">I don&#39;t know, sorry.</p>
+</body>
+</timedtext>`
+	cues, err := parseSrv3(strings.NewReader(transcript), segmenter{})
+	require.NoError(t, err)
+	require.Equal(t, []string{"where is the train station?", "I don't know, sorry."}, cueTexts(cues))
+	require.Equal(t, time.Second, cues[0].start)
+	require.Equal(t, 5520*time.Millisecond, cues[0].end)
+	require.Equal(t, 10*time.Second, cues[1].end)
+}
+
+func TestParseJsonTranscript(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name       string
+		transcript string
+		expected   []string
+		start, end time.Duration
+	}{
+		{
+			name:       "youtube-transcript-api",
+			transcript: `[{"text": "this is the first part", "start": 0.5, "duration": 1.2}, {"text": "of a sentence.", "start": 1.7, "duration": 1.1}]`,
+			expected:   []string{"this is the first part of a sentence."},
+			start:      500 * time.Millisecond,
+			end:        2800 * time.Millisecond,
+		},
+		{
+			name:       "string times with an end",
+			transcript: `[{"start": "10", "end": "12.5", "text": "This is a whole sentence."}]`,
+			expected:   []string{"This is a whole sentence."},
+			start:      10 * time.Second,
+			end:        12500 * time.Millisecond,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cues, err := parseJsonTranscript(strings.NewReader(tt.transcript), segmenter{})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, cueTexts(cues))
+			require.Equal(t, tt.start, cues[0].start)
+			require.Equal(t, tt.end, cues[0].end)
+		})
+	}
+}
+
+func TestCaptionPhrases(t *testing.T) {
+	if util.Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+
+	tests := []struct {
+		name      string
+		fragments []cue
+		expected  []string
+	}{
+		{
+			name: "a pause ends a sentence",
+			fragments: []cue{
+				{start: 0, end: time.Second, text: "so this is"},
+				{start: time.Second, end: 2 * time.Second, text: "my kitchen"},
+				{start: 4 * time.Second, end: 5 * time.Second, text: "and this is my oven"},
+			},
+			expected: []string{"so this is my kitchen", "and this is my oven"},
+		},
+		{
+			name: "auto captions without punctuation end at the most words of a phrase",
+			fragments: []cue{
+				{start: 0, end: time.Second, text: "one two three four"},
+				{start: time.Second, end: 2 * time.Second, text: "five six seven eight"},
+				{start: 2 * time.Second, end: 3 * time.Second, text: "nine ten eleven twelve"},
+				{start: 3 * time.Second, end: 4 * time.Second, text: "thirteen fourteen fifteen sixteen"},
+			},
+			expected: []string{"one two three four five six seven eight nine ten eleven twelve", "thirteen fourteen fifteen sixteen"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, cueTexts(captionPhrases(tt.fragments, segmenter{})))
+		})
+	}
+}
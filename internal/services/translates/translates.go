@@ -242,10 +242,14 @@ func (t *Translate) CreateTTS(ctx context.Context, title interfaces.Title, voice
 		return title.TitlePhrases, nil
 	}
 
-	// Translation needed - translate first
-	translates, err := t.TranslatePhrases(ctx, title, lang)
-	if err != nil {
-		return nil, fmt.Errorf("translating phrases: %w", err)
+	// Translation needed - use the translations of a bilingual file if they are in the
+	// language of the voice or translate first
+	translates := title.ToPhrases
+	if len(translates) == 0 || !sameLanguage(title.ToLang, lang.Code) {
+		translates, err = t.TranslatePhrases(ctx, title, lang)
+		if err != nil {
+			return nil, fmt.Errorf("translating phrases: %w", err)
+		}
 	}
 
 	// Create directory for audio files
@@ -261,6 +265,18 @@ func (t *Translate) CreateTTS(ctx context.Context, title interfaces.Title, voice
 	return translates, nil
 }
 
+// sameLanguage checks if two language tags like es and es-ES are the same language
+func sameLanguage(a, b string) bool {
+	tagA, errA := language.Parse(a)
+	tagB, errB := language.Parse(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	baseA, _ := tagA.Base()
+	baseB, _ := tagB.Base()
+	return baseA == baseB
+}
+
 // Helper function for path existence check
 func pathExists(path string) (bool, error) {
 	_, err := os.Stat(path)
@@ -468,6 +468,91 @@ func TestCreateTTS(t *testing.T) {
 			},
 			expectedError: false,
 		},
+		{
+			name: "Successful TTS creation - bilingual translations",
+			title: interfaces.Title{
+				TitleLang: "English",
+				TitlePhrases: []interfaces.Phrase{
+					{ID: 0, Text: "Hello"},
+					{ID: 1, Text: "World"},
+				},
+				ToPhrases: []interfaces.Phrase{
+					{ID: 0, Text: "Buenas"},
+					{ID: 1, Text: "Mundo entero"},
+				},
+				ToLang: "es",
+			},
+			voice: interfaces.Voice{
+				Name:         "es-ES-Standard-A",
+				Language:     "Spanish",
+				LanguageCode: "es-ES",
+				SsmlGender:   interfaces.MALE,
+			},
+			setupMocks: func(mockClient *mock.MockTTSClientInterface, mockModels *mock.MockModelsStore) {
+				mockModels.EXPECT().
+					GetLanguage(gomock.Any(), "Spanish").
+					Return(interfaces.Language{
+						Name: "Spanish",
+						Code: "es-ES",
+					}, nil)
+
+				// TranslateTexts is not expected because the translations are given
+				mockClient.EXPECT().
+					ProcessPhrase(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&texttospeechpb.SynthesizeSpeechResponse{
+						AudioContent: []byte("audio content"),
+					}, nil).Times(2)
+			},
+			expectedResult: []interfaces.Phrase{
+				{ID: 0, Text: "Buenas"},
+				{ID: 1, Text: "Mundo entero"},
+			},
+			expectedError: false,
+		},
+		{
+			name: "Successful TTS creation - bilingual translations in another language",
+			title: interfaces.Title{
+				TitleLang: "English",
+				TitlePhrases: []interfaces.Phrase{
+					{ID: 0, Text: "Hello"},
+					{ID: 1, Text: "World"},
+				},
+				ToPhrases: []interfaces.Phrase{
+					{ID: 0, Text: "Bonjour"},
+					{ID: 1, Text: "Monde"},
+				},
+				ToLang: "fr",
+			},
+			voice: interfaces.Voice{
+				Name:         "es-ES-Standard-A",
+				Language:     "Spanish",
+				LanguageCode: "es-ES",
+				SsmlGender:   interfaces.MALE,
+			},
+			setupMocks: func(mockClient *mock.MockTTSClientInterface, mockModels *mock.MockModelsStore) {
+				mockModels.EXPECT().
+					GetLanguage(gomock.Any(), "Spanish").
+					Return(interfaces.Language{
+						Name: "Spanish",
+						Code: "es-ES",
+					}, nil)
+
+				// the French translations are not spoken by a Spanish voice
+				mockClient.EXPECT().
+					TranslateTexts(gomock.Any(), []string{"Hello", "World"}, language.Make("es-ES")).
+					Return([]string{"Hola", "Mundo"}, nil)
+				mockClient.EXPECT().
+					ProcessPhrase(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(&texttospeechpb.SynthesizeSpeechResponse{
+						AudioContent: []byte("audio content"),
+					}, nil).Times(2)
+			},
+			expectedResult: []interfaces.Phrase{
+				{ID: 0, Text: "Hola"},
+				{ID: 1, Text: "Mundo"},
+			},
+			expectedError: false,
+		},
 		{
 			name: "GetLanguage error",
 			title: interfaces.Title{
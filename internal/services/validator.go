@@ -4,10 +4,15 @@ import (
 	"errors"
 	"fmt"
 	"github.com/labstack/echo/v4"
+	"golang.org/x/text/encoding/htmlindex"
+	"golang.org/x/text/language"
 	"net/mail"
+	"slices"
 	"strconv"
 	"strings"
 	"talkliketv.com/tltv/internal/interfaces"
+	"talkliketv.com/tltv/internal/util"
+	"time"
 	"unicode/utf8"
 )
 
@@ -103,6 +108,220 @@ func (v *Validator) In(value string, list ...string) bool {
 	return false
 }
 
+// ValidateParseOptions reads the optional form values that change how an uploaded file
+// is parsed
+func ValidateParseOptions(e echo.Context) (interfaces.ParseOptions, error) {
+	var opts interfaces.ParseOptions
+
+	// skip_styles is a comma separated list of ASS/SSA style names
+	for _, style := range strings.Split(e.FormValue("skip_styles"), ",") {
+		if style = strings.TrimSpace(style); style != "" {
+			opts.SkipStyles = append(opts.SkipStyles, style)
+		}
+	}
+
+	// cleanup is a comma separated list of cleanup rule names or none
+	for _, rule := range strings.Split(e.FormValue("cleanup"), ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		if rule != "none" && !In(rule, interfaces.CleanupRules...) {
+			return opts, fmt.Errorf("invalid cleanup rule: %s", rule)
+		}
+		opts.Cleanup = append(opts.Cleanup, rule)
+	}
+
+	if lang := strings.TrimSpace(e.FormValue("language")); lang != "" {
+		if _, err := language.Parse(lang); err != nil {
+			return opts, fmt.Errorf("invalid language: %s", lang)
+		}
+		opts.Language = lang
+	}
+
+	if enc := strings.TrimSpace(e.FormValue("encoding")); enc != "" {
+		if _, err := htmlindex.Get(enc); err != nil {
+			return opts, fmt.Errorf("invalid encoding: %s", enc)
+		}
+		opts.Encoding = enc
+	}
+
+	// deck, front_field and back_field choose the notes of an Anki package
+	opts.Deck = strings.TrimSpace(e.FormValue("deck"))
+	opts.FrontField = strings.TrimSpace(e.FormValue("front_field"))
+	opts.BackField = strings.TrimSpace(e.FormValue("back_field"))
+	if opts.FrontField != "" && strings.EqualFold(opts.FrontField, opts.BackField) {
+		return opts, errors.New("front_field and back_field must be different fields")
+	}
+
+	chapters, err := parseChapters(e.FormValue("chapters"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Chapters = chapters
+
+	if value := strings.TrimSpace(e.FormValue("skip_notes")); value != "" {
+		if opts.SkipNotes, err = strconv.ParseBool(value); err != nil {
+			return opts, errors.New("skip_notes must be true or false")
+		}
+	}
+
+	// format names the format the file is parsed as instead of detecting it
+	opts.Format = strings.ToLower(strings.TrimSpace(e.FormValue("format")))
+
+	// dedupe removes the phrases that are near duplicates of an earlier phrase and similarity
+	// is how similar they have to be with fuzzy
+	opts.Dedupe = strings.ToLower(strings.TrimSpace(e.FormValue("dedupe")))
+	if opts.Dedupe != "" && !In(opts.Dedupe, interfaces.DedupeModes...) {
+		return opts, fmt.Errorf("dedupe must be one of %s", strings.Join(interfaces.DedupeModes, ", "))
+	}
+	if value := strings.TrimSpace(e.FormValue("similarity")); value != "" {
+		if opts.Dedupe != interfaces.DedupeFuzzy {
+			return opts, errors.New("similarity can only be used with dedupe fuzzy")
+		}
+		if opts.Similarity, err = strconv.ParseFloat(value, 64); err != nil || opts.Similarity <= 0 || opts.Similarity > 1 {
+			return opts, errors.New("similarity must be a number greater than 0 and at most 1")
+		}
+	}
+
+	// book, from_date and to_date choose the highlights of Kindle clippings
+	opts.Book = strings.TrimSpace(e.FormValue("book"))
+	if opts.FromDate, err = optionalDate(e, "from_date"); err != nil {
+		return opts, err
+	}
+	if opts.ToDate, err = optionalDate(e, "to_date"); err != nil {
+		return opts, err
+	}
+	if !opts.ToDate.IsZero() && opts.ToDate.Before(opts.FromDate) {
+		return opts, errors.New("to_date must not be before from_date")
+	}
+
+	policy, err := validatePhrasePolicy(e)
+	if err != nil {
+		return opts, err
+	}
+	opts.Policy = policy
+
+	if opts.StartTime, err = optionalTimestamp(e, "start_time"); err != nil {
+		return opts, err
+	}
+	if opts.EndTime, err = optionalTimestamp(e, "end_time"); err != nil {
+		return opts, err
+	}
+	if opts.EndTime > 0 && opts.EndTime <= opts.StartTime {
+		return opts, errors.New("end_time must be after start_time")
+	}
+
+	return opts, nil
+}
+
+// maxChapter is the highest chapter number that can be chosen so a range like 1-2000000000
+// cannot fill the memory of the server
+const maxChapter = 10000
+
+// parseChapters reads a comma separated list of chapter numbers and ranges like 1-3, 5 into
+// the sorted numbers of the chapters
+func parseChapters(value string) ([]int, error) {
+	chosen := make(map[int]bool)
+	for _, part := range strings.Split(value, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		first, last, isRange := strings.Cut(part, "-")
+		start, err := strconv.Atoi(strings.TrimSpace(first))
+		end := start
+		if err == nil && isRange {
+			end, err = strconv.Atoi(strings.TrimSpace(last))
+		}
+		if err != nil || start < 1 || end < start {
+			return nil, fmt.Errorf("invalid chapters: %s must be a chapter number or a range like 1-3", part)
+		}
+		if end > maxChapter {
+			return nil, fmt.Errorf("invalid chapters: %s is after chapter %d", part, maxChapter)
+		}
+		for n := start; n <= end; n++ {
+			chosen[n] = true
+		}
+	}
+	var chapters []int
+	for n := range chosen {
+		chapters = append(chapters, n)
+	}
+	slices.Sort(chapters)
+	return chapters, nil
+}
+
+// optionalTimestamp reads an optional timestamp form value like 12:30, 01:02:03 or 90.
+// It returns 0 if the value is not sent.
+func optionalTimestamp(e echo.Context, name string) (time.Duration, error) {
+	value := strings.TrimSpace(e.FormValue(name))
+	if value == "" {
+		return 0, nil
+	}
+	d, err := util.ParseTimestamp(value)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a time like 12:30 or 01:02:03", name)
+	}
+	return d, nil
+}
+
+// optionalDate reads an optional date form value like 2024-03-31. It returns the zero time
+// if the value is not sent.
+func optionalDate(e echo.Context, name string) (time.Time, error) {
+	value := strings.TrimSpace(e.FormValue(name))
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date like 2024-03-31", name)
+	}
+	return date, nil
+}
+
+// validatePhrasePolicy reads the optional min_words, max_words, max_chars and split_on_commas
+// form values. Values that are not sent are left at zero so the parser uses its defaults.
+func validatePhrasePolicy(e echo.Context) (interfaces.PhrasePolicy, error) {
+	var policy interfaces.PhrasePolicy
+	var err error
+
+	if policy.MinWords, err = optionalInt(e, "min_words", 1, 50); err != nil {
+		return policy, err
+	}
+	if policy.MaxWords, err = optionalInt(e, "max_words", 1, 50); err != nil {
+		return policy, err
+	}
+	if policy.MinWords > 0 && policy.MaxWords > 0 && policy.MinWords > policy.MaxWords {
+		return policy, errors.New("min_words must not be greater than max_words")
+	}
+	if policy.MaxChars, err = optionalInt(e, "max_chars", 10, 500); err != nil {
+		return policy, err
+	}
+
+	if value := strings.TrimSpace(e.FormValue("split_on_commas")); value != "" {
+		splitOnCommas, err := strconv.ParseBool(value)
+		if err != nil {
+			return policy, errors.New("split_on_commas must be true or false")
+		}
+		policy.SentencesOnly = !splitOnCommas
+	}
+
+	return policy, nil
+}
+
+// optionalInt reads an optional integer form value that must be between minimum and maximum.
+// It returns 0 if the value is not sent.
+func optionalInt(e echo.Context, name string, minimum, maximum int) (int, error) {
+	value := strings.TrimSpace(e.FormValue(name))
+	if value == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < minimum || n > maximum {
+		return 0, fmt.Errorf("%s must be between %d and %d", name, minimum, maximum)
+	}
+	return n, nil
+}
+
 func ValidateAudioRequest(e echo.Context, m interfaces.ModelsStore) (*interfaces.Title, *interfaces.Voice, *interfaces.Voice, error) {
 	// Extract form values
 	titleName := e.FormValue("title_name")
@@ -1,9 +1,14 @@
 package util
 
 import (
+	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
 var (
@@ -27,31 +32,39 @@ func PathExists(path string) (bool, error) {
 	return false, err
 }
 
-// RemoveDuplicateStr removes duplicate strings from a slice.
-// In this application, it will be particularly useful if someone wants to use
-// song lyrics to build the mp3 files.
-// You cannot use a sort function because you want to keep the order.
-func RemoveDuplicateStr(strSlice []string) []string {
-	allKeys := make(map[string]bool)
-	var list []string
-	for _, item := range strSlice {
-		if _, value := allKeys[item]; !value {
-			allKeys[item] = true
-			list = append(list, item)
-		}
+// ParseTimestamp parses a subtitle timestamp like 01:02:03,456 (srt), 02:03.456 (vtt) or
+// 1:02:03.45 (ass), or a number of seconds like 90 or 90.5, into a time.Duration
+func ParseTimestamp(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	parts := strings.Split(strings.Replace(s, ",", ".", 1), ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("invalid timestamp: %s", s)
 	}
-	return list
-}
 
-// RemoveLongStr removes strings with more than 150 characters.
-func RemoveLongStr(strSlice []string) []string {
-	var list []string
-	for _, item := range strSlice {
-		if len(item) < 150 {
-			list = append(list, item)
+	var seconds float64
+	for i, part := range parts {
+		last := i == len(parts)-1
+		rest := strings.Trim(part, "0123456789")
+		if part == "" || (rest != "" && (!last || rest != ".")) {
+			return 0, fmt.Errorf("invalid timestamp: %s", s)
+		}
+		value, err := strconv.ParseFloat(part, 64)
+		// minutes and seconds after the first part must be less than 60
+		if err != nil || (i > 0 && value >= 60) {
+			return 0, fmt.Errorf("invalid timestamp: %s", s)
 		}
+		seconds = seconds*60 + value
 	}
-	return list
+
+	return time.Duration(math.Round(seconds*1000)) * time.Millisecond, nil
+}
+
+// FormatTimestamp formats a time.Duration as a timestamp like 01:02:03.456 that
+// ParseTimestamp can parse
+func FormatTimestamp(d time.Duration) string {
+	d = d.Round(time.Millisecond)
+	return fmt.Sprintf("%02d:%02d:%02d.%03d",
+		int(d/time.Hour), int(d/time.Minute)%60, int(d/time.Second)%60, int(d/time.Millisecond)%1000)
 }
 
 func GetVMName() (string, error) {
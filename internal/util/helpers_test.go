@@ -0,0 +1,79 @@
+package util
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseTimestamp tests parsing the timestamps of the subtitle formats and numbers of seconds
+func TestParseTimestamp(t *testing.T) {
+	if Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name      string
+		timestamp string
+		expected  time.Duration
+		wantErr   bool
+	}{
+		{name: "srt comma", timestamp: "01:02:03,456", expected: time.Hour + 2*time.Minute + 3456*time.Millisecond},
+		{name: "vtt dot", timestamp: "01:02:03.456", expected: time.Hour + 2*time.Minute + 3456*time.Millisecond},
+		{name: "vtt without hours", timestamp: "02:03.456", expected: 2*time.Minute + 3456*time.Millisecond},
+		{name: "ass centiseconds", timestamp: "1:02:03.45", expected: time.Hour + 2*time.Minute + 3450*time.Millisecond},
+		{name: "hours over 99", timestamp: "123:04:05.000", expected: 123*time.Hour + 4*time.Minute + 5*time.Second},
+		{name: "seconds", timestamp: "90", expected: 90 * time.Second},
+		{name: "fractional seconds", timestamp: "90.5", expected: 90500 * time.Millisecond},
+		{name: "surrounding spaces", timestamp: " 00:00:01,000 ", expected: time.Second},
+		{name: "minutes of 60", timestamp: "1:60", wantErr: true},
+		{name: "seconds of 60", timestamp: "00:01:60.000", wantErr: true},
+		{name: "too many fields", timestamp: "1:02:03:04", wantErr: true},
+		{name: "empty field", timestamp: "01::03", wantErr: true},
+		{name: "letters", timestamp: "01:0a:03", wantErr: true},
+		{name: "fraction before the last field", timestamp: "01.5:03", wantErr: true},
+		{name: "negative", timestamp: "-5", wantErr: true},
+		{name: "empty", timestamp: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseTimestamp(tt.timestamp)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, d)
+		})
+	}
+}
+
+// TestFormatTimestamp tests formatting durations as timestamps that ParseTimestamp parses back
+func TestFormatTimestamp(t *testing.T) {
+	if Test != "unit" && !testing.Short() {
+		t.Skip("skipping unit test")
+	}
+	tests := []struct {
+		name     string
+		d        time.Duration
+		expected string
+	}{
+		{name: "zero", d: 0, expected: "00:00:00.000"},
+		{name: "milliseconds", d: time.Hour + 2*time.Minute + 3456*time.Millisecond, expected: "01:02:03.456"},
+		{name: "rounds to milliseconds", d: 1500600 * time.Microsecond, expected: "00:00:01.501"},
+		{name: "hours over 99", d: 123*time.Hour + 4*time.Minute + 5*time.Second, expected: "123:04:05.000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timestamp := FormatTimestamp(tt.d)
+			assert.Equal(t, tt.expected, timestamp)
+
+			d, err := ParseTimestamp(timestamp)
+			require.NoError(t, err)
+			assert.Equal(t, tt.d.Round(time.Millisecond), d)
+		})
+	}
+}